@@ -0,0 +1,128 @@
+package itunes
+
+type AirPlayDeviceKind int
+
+const (
+	AirPlayKindUnknown AirPlayDeviceKind = iota
+	AirPlayKindComputer
+	AirPlayKindAirPortExpress
+	AirPlayKindAppleTV
+	AirPlayKindAirPlayDevice
+	AirPlayKindBluetooth
+	AirPlayKindHomePod
+)
+
+func (k AirPlayDeviceKind) String() string {
+	switch k {
+	case AirPlayKindUnknown:
+		return "Unknown"
+	case AirPlayKindComputer:
+		return "Computer"
+	case AirPlayKindAirPortExpress:
+		return "AirPortExpress"
+	case AirPlayKindAppleTV:
+		return "AppleTV"
+	case AirPlayKindAirPlayDevice:
+		return "AirPlayDevice"
+	case AirPlayKindBluetooth:
+		return "Bluetooth"
+	case AirPlayKindHomePod:
+		return "HomePod"
+	}
+
+	return ""
+}
+
+func (d *AirPlayDevice) Name() string {
+	return d.name
+}
+
+func (d *AirPlayDevice) Kind() AirPlayDeviceKind {
+	return d.kind
+}
+
+// IsActive reports whether the device was playing when it was fetched.
+func (d *AirPlayDevice) IsActive() bool {
+	return d.active
+}
+
+// IsSelected reports whether the device was an output when it was fetched.
+func (d *AirPlayDevice) IsSelected() bool {
+	return d.selected
+}
+
+// SoundVolume returns the volume of the device when it was fetched.
+func (d *AirPlayDevice) SoundVolume() int {
+	return d.soundVolume
+}
+
+// airPlayOutput is what SelectAirPlayDevices needs of a device.
+type airPlayOutput interface {
+	PersistentID() string
+	IsSelected() bool
+	SetSelected(isSelected bool) error
+}
+
+// SelectAirPlayDevices makes devices the only outputs, they are matched by their persistent IDs.
+func (it *Itunes) SelectAirPlayDevices(devices ...*AirPlayDevice) error {
+	all, err := it.AirPlayDevices()
+	if err != nil {
+		return err
+	}
+	defer closeAirPlayDevices(all)
+
+	outputs := make([]airPlayOutput, len(all))
+	for i, d := range all {
+		outputs[i] = d
+	}
+
+	ids := make([]string, len(devices))
+	for i, d := range devices {
+		ids[i] = d.PersistentID()
+	}
+
+	return selectAirPlayOutputs(outputs, ids)
+}
+
+// selectAirPlayOutputs fails before changing anything if a device is gone.
+func selectAirPlayOutputs(all []airPlayOutput, ids []string) error {
+	known := make(map[string]bool, len(all))
+	for _, d := range all {
+		known[d.PersistentID()] = true
+	}
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !known[id] {
+			return &NotFoundError{Kind: "AirPlay device", ID: id}
+		}
+		selected[id] = true
+	}
+
+	// select first so that there is always at least one output.
+	for _, d := range all {
+		if selected[d.PersistentID()] && !d.IsSelected() {
+			err := d.SetSelected(true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, d := range all {
+		if !selected[d.PersistentID()] && d.IsSelected() {
+			err := d.SetSelected(false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func closeAirPlayDevices(devices []*AirPlayDevice) {
+	for _, d := range devices {
+		d.Close()
+	}
+}
@@ -0,0 +1,79 @@
+package itunes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeOutput struct {
+	id       string
+	selected bool
+	// calls records the changes of all the outputs in order.
+	calls *[]string
+}
+
+func (d *fakeOutput) PersistentID() string {
+	return d.id
+}
+
+func (d *fakeOutput) IsSelected() bool {
+	return d.selected
+}
+
+func (d *fakeOutput) SetSelected(isSelected bool) error {
+	d.selected = isSelected
+	if isSelected {
+		*d.calls = append(*d.calls, "+"+d.id)
+	} else {
+		*d.calls = append(*d.calls, "-"+d.id)
+	}
+	return nil
+}
+
+func newFakeOutputs(calls *[]string, selected ...bool) []airPlayOutput {
+	outputs := make([]airPlayOutput, len(selected))
+	for i, s := range selected {
+		outputs[i] = &fakeOutput{id: string(rune('A' + i)), selected: s, calls: calls}
+	}
+	return outputs
+}
+
+func TestSelectAirPlayOutputs(t *testing.T) {
+	tests := []struct {
+		selected []bool
+		ids      []string
+		expect   []string
+	}{
+		// the new outputs are selected before the old ones are deselected.
+		{[]bool{true, false, false}, []string{"C"}, []string{"+C", "-A"}},
+		{[]bool{true, false, true}, []string{"A", "B"}, []string{"+B", "-C"}},
+		{[]bool{true, true, false}, []string{"A", "B"}, []string{}},
+	}
+
+	for _, test := range tests {
+		calls := []string{}
+		err := selectAirPlayOutputs(newFakeOutputs(&calls, test.selected...), test.ids)
+		if err != nil {
+			t.Errorf("%v: %v", test.ids, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(calls, test.expect) {
+			t.Errorf("%v: expect %v, but %v", test.ids, test.expect, calls)
+		}
+	}
+}
+
+func TestSelectMissingAirPlayOutput(t *testing.T) {
+	calls := []string{}
+	err := selectAirPlayOutputs(newFakeOutputs(&calls, true, false), []string{"B", "Z"})
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.ID != "Z" {
+		t.Errorf("expect Z not to be found, but %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expect no output to change, but %v", calls)
+	}
+}
@@ -0,0 +1,116 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type AirPlayDevice struct {
+	persistentID string
+
+	name        string
+	kind        AirPlayDeviceKind
+	active      bool
+	selected    bool
+	soundVolume int
+}
+
+func parseAirPlayDeviceKind(v string) AirPlayDeviceKind {
+	switch v {
+	case "computer":
+		return AirPlayKindComputer
+	case "AirPort Express":
+		return AirPlayKindAirPortExpress
+	case "Apple TV":
+		return AirPlayKindAppleTV
+	case "AirPlay device":
+		return AirPlayKindAirPlayDevice
+	case "Bluetooth device":
+		return AirPlayKindBluetooth
+	case "HomePod":
+		return AirPlayKindHomePod
+	}
+
+	return AirPlayKindUnknown
+}
+
+func createAirPlayDevice(values []string) (*AirPlayDevice, error) {
+	if len(values) < 6 {
+		return nil, errors.New("values is empty.")
+	}
+
+	volume, err := strconv.ParseInt(values[5], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &AirPlayDevice{
+		persistentID: values[0],
+
+		name:        values[1],
+		kind:        parseAirPlayDeviceKind(values[2]),
+		active:      values[3] == "true",
+		selected:    values[4] == "true",
+		soundVolume: int(volume),
+	}
+
+	return d, nil
+}
+
+// for compatibility
+func (_ *AirPlayDevice) Close() {
+}
+
+func (_ *Itunes) AirPlayDevices() ([]*AirPlayDevice, error) {
+	o, err := execJS(`app.airPlayDevices().forEach(logAirPlayDevice);`)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*AirPlayDevice, 0, 4)
+	for line := range o {
+		columns, err := validateResult(line)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := createAirPlayDevice(columns)
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, nil
+}
+
+func (d *AirPlayDevice) PersistentID() string {
+	return d.persistentID
+}
+
+// the devices are found by their persistent IDs, two devices may have the same name.
+func (d *AirPlayDevice) SetSelected(isSelected bool) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`findAirPlayDeviceByPersistentId(%s).selected = %v;`, jsString(d.persistentID), isSelected))
+	if err != nil {
+		return err
+	}
+
+	d.selected = isSelected
+	return nil
+}
+
+func (d *AirPlayDevice) SetSoundVolume(volume int) error {
+	if volume < 0 || 100 < volume {
+		return &OutOfRangeError{Name: "volume"}
+	}
+
+	_, err := getColumnsByJS(fmt.Sprintf(`findAirPlayDeviceByPersistentId(%s).soundVolume = %d;`, jsString(d.persistentID), volume))
+	if err != nil {
+		return err
+	}
+
+	d.soundVolume = volume
+	return nil
+}
@@ -0,0 +1,31 @@
+package itunes
+
+type AirPlayDevice struct {
+	persistentID string
+
+	name        string
+	kind        AirPlayDeviceKind
+	active      bool
+	selected    bool
+	soundVolume int
+}
+
+// for compatibility
+func (_ *AirPlayDevice) Close() {
+}
+
+func (_ *Itunes) AirPlayDevices() ([]*AirPlayDevice, error) {
+	return nil, &NotSupportedError{Feature: "AirPlayDevices", Platform: "Linux"}
+}
+
+func (d *AirPlayDevice) PersistentID() string {
+	return d.persistentID
+}
+
+func (_ *AirPlayDevice) SetSelected(isSelected bool) error {
+	return &NotSupportedError{Feature: "SetSelected", Platform: "Linux"}
+}
+
+func (_ *AirPlayDevice) SetSoundVolume(volume int) error {
+	return &NotSupportedError{Feature: "AirPlayDevice.SetSoundVolume", Platform: "Linux"}
+}
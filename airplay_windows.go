@@ -0,0 +1,162 @@
+package itunes
+
+import (
+	"fmt"
+
+	"github.com/yaegaki/go-ole-handler"
+)
+
+type AirPlayDevice struct {
+	handler *olehandler.OleHandler
+
+	highID uint32
+	lowID  uint32
+
+	name        string
+	kind        AirPlayDeviceKind
+	active      bool
+	selected    bool
+	soundVolume int
+}
+
+// ITAirPlayDeviceKind
+const (
+	airPlayKindUnknown        = 0
+	airPlayKindComputer       = 1
+	airPlayKindAirPortExpress = 2
+	airPlayKindAppleTV        = 3
+	airPlayKindAirPlayDevice  = 4
+)
+
+// parseAirPlayDeviceKind maps the kinds of the COM interface, which knows neither Bluetooth devices nor HomePods.
+func parseAirPlayDeviceKind(v int) AirPlayDeviceKind {
+	switch v {
+	case airPlayKindComputer:
+		return AirPlayKindComputer
+	case airPlayKindAirPortExpress:
+		return AirPlayKindAirPortExpress
+	case airPlayKindAppleTV:
+		return AirPlayKindAppleTV
+	case airPlayKindAirPlayDevice:
+		return AirPlayKindAirPlayDevice
+	}
+
+	return AirPlayKindUnknown
+}
+
+func createAirPlayDevice(it *Itunes, handler *olehandler.OleHandler) (*AirPlayDevice, error) {
+	v, err := it.handler.GetProperty("ITObjectPersistentIDHigh", handler.Handle)
+	if err != nil {
+		return nil, err
+	}
+	highID := uint32(v.Val)
+
+	v, err = it.handler.GetProperty("ITObjectPersistentIDLow", handler.Handle)
+	if err != nil {
+		return nil, err
+	}
+	lowID := uint32(v.Val)
+
+	name, err := handler.GetStringProperty("Name")
+	if err != nil {
+		return nil, err
+	}
+
+	kind, err := handler.GetIntProperty("Kind")
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := handler.GetBoolProperty("IsActive")
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := handler.GetBoolProperty("IsSelected")
+	if err != nil {
+		return nil, err
+	}
+
+	volume, err := handler.GetIntProperty("SoundVolume")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &AirPlayDevice{
+		handler: handler,
+
+		highID: highID,
+		lowID:  lowID,
+
+		name:        name,
+		kind:        parseAirPlayDeviceKind(kind),
+		active:      active,
+		selected:    selected,
+		soundVolume: volume,
+	}
+
+	return d, nil
+}
+
+func (d *AirPlayDevice) Close() {
+	d.handler.Close()
+}
+
+func (it *Itunes) AirPlayDevices() ([]*AirPlayDevice, error) {
+	collection, err := it.handler.GetOleHandler("AirPlayDevices")
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Close()
+
+	count, err := collection.GetIntProperty("Count")
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*AirPlayDevice, 0, count)
+	for i := 1; i <= count; i++ {
+		var d *AirPlayDevice
+		err = collection.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+			d, err = createAirPlayDevice(it, handler)
+			return err
+		}, i)
+
+		if err != nil {
+			closeAirPlayDevices(devices)
+			return nil, err
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, nil
+}
+
+func (d *AirPlayDevice) PersistentID() string {
+	return fmt.Sprintf("%x%x", d.highID, d.lowID)
+}
+
+func (d *AirPlayDevice) SetSelected(isSelected bool) error {
+	err := d.handler.PutProperty("IsSelected", isSelected)
+	if err != nil {
+		return err
+	}
+
+	d.selected = isSelected
+	return nil
+}
+
+func (d *AirPlayDevice) SetSoundVolume(volume int) error {
+	if volume < 0 || 100 < volume {
+		return &OutOfRangeError{Name: "volume"}
+	}
+
+	err := d.handler.PutProperty("SoundVolume", volume)
+	if err != nil {
+		return err
+	}
+
+	d.soundVolume = volume
+	return nil
+}
@@ -1,5 +1,11 @@
 package itunes
 
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
 type ArtworkFormat int
 
 const (
@@ -38,6 +44,43 @@ func (a ArtworkFormat) Ext() string {
 
 }
 
+// MIMEType returns the media type of the image, such as "image/jpeg".
+func (a ArtworkFormat) MIMEType() string {
+	switch a {
+	case JPEG:
+		return "image/jpeg"
+	case PNG:
+		return "image/png"
+	case BMP:
+		return "image/bmp"
+	}
+
+	return "application/octet-stream"
+}
+
 func (a *Artwork) Format() ArtworkFormat {
 	return a.format
 }
+
+// WriteTo writes the image data to w.
+// Neither platform hands out the data directly, so it is saved to a temporary file first.
+func (a *Artwork) WriteTo(w io.Writer) (int64, error) {
+	dir, err := ioutil.TempDir("", "itunes-artwork")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := a.SaveToFile(dir, "artwork")
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, f)
+}
@@ -0,0 +1,86 @@
+package itunes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Artwork struct {
+	path string
+
+	format ArtworkFormat
+}
+
+// for compatibility
+func (_ *Artwork) Close() {
+}
+
+// detectArtworkFormat reads the format from the data, players cache cover art without an extension.
+func detectArtworkFormat(path string) (ArtworkFormat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Unknown, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 8)
+	n, err := f.Read(head)
+	if err != nil {
+		return Unknown, err
+	}
+
+	switch v := string(head[:n]); {
+	case strings.HasPrefix(v, "\xFF\xD8\xFF"):
+		return JPEG, nil
+	case strings.HasPrefix(v, "\x89PNG\r\n\x1a\n"):
+		return PNG, nil
+	case strings.HasPrefix(v, "BM"):
+		return BMP, nil
+	}
+
+	return Unknown, nil
+}
+
+func (a *Artwork) SaveToFile(directory, name string) (string, error) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = os.Stat(directory)
+	if err != nil {
+		return "", err
+	}
+
+	filepath, err := filepath.Abs(fmt.Sprintf(`%v/%v%v`, directory, name, a.Format().Ext()))
+	if err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(a.path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filepath)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		return "", err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath, nil
+}
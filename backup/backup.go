@@ -0,0 +1,367 @@
+// Package backup saves the user metadata of a library, which lives only inside iTunes,
+// and reapplies it after the library is rebuilt.
+//
+// Ratings, play counts, loved flags, comments and the membership of user playlists are kept
+// in a versioned JSON archive keyed by persistent ID.
+// NewItunes returns the Library of an iTunes application and NewRemote the one of a remote iTunes.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+const archiveVersion = 1
+
+// Track is implemented by *itunes.Track.
+type Track interface {
+	PersistentID() string
+	Location() string
+	Name() string
+	Artist() string
+	Album() string
+	Duration() time.Duration
+	Rating() int
+	PlayedCount() int
+	Comment() string
+	// Loved is false on the platforms without loved tracks, their SetLoved fails.
+	Loved() bool
+
+	SetRating(rating int) error
+	SetPlayedCount(count int) error
+	SetComment(comment string) error
+	SetLoved(loved bool) error
+}
+
+type Playlist interface {
+	PersistentID() string
+	Name() string
+	// TrackIDs returns the persistent IDs of the tracks in the playlist order.
+	TrackIDs() ([]string, error)
+	AddTrack(trackID string) error
+}
+
+type Library interface {
+	Tracks() ([]Track, error)
+	// Playlists returns the user playlists, smart playlists are not saved as their membership follows their rules.
+	Playlists() ([]Playlist, error)
+	CreatePlaylist(name string) (Playlist, error)
+}
+
+type TrackRecord struct {
+	PersistentID string `json:"persistentId"`
+
+	// Location, Name, Artist, Album and Duration identify the track when the persistent ID changed.
+	Location string        `json:"location,omitempty"`
+	Name     string        `json:"name"`
+	Artist   string        `json:"artist,omitempty"`
+	Album    string        `json:"album,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+
+	Rating      int    `json:"rating,omitempty"`
+	PlayedCount int    `json:"playedCount,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Loved       bool   `json:"loved,omitempty"`
+}
+
+type PlaylistRecord struct {
+	PersistentID string `json:"persistentId"`
+	Name         string `json:"name"`
+	// Tracks are the persistent IDs of the tracks in the playlist order.
+	Tracks []string `json:"tracks"`
+}
+
+type Archive struct {
+	Version   int              `json:"version"`
+	Created   time.Time        `json:"created"`
+	Tracks    []TrackRecord    `json:"tracks"`
+	Playlists []PlaylistRecord `json:"playlists"`
+}
+
+// Create snapshots the user metadata of all tracks and user playlists.
+func Create(lib Library) (*Archive, error) {
+	tracks, err := lib.Tracks()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		Version:   archiveVersion,
+		Created:   time.Now(),
+		Tracks:    make([]TrackRecord, len(tracks)),
+		Playlists: make([]PlaylistRecord, 0),
+	}
+	for i, t := range tracks {
+		a.Tracks[i] = TrackRecord{
+			PersistentID: t.PersistentID(),
+
+			Location: t.Location(),
+			Name:     t.Name(),
+			Artist:   t.Artist(),
+			Album:    t.Album(),
+			Duration: t.Duration(),
+
+			Rating:      t.Rating(),
+			PlayedCount: t.PlayedCount(),
+			Comment:     t.Comment(),
+			Loved:       t.Loved(),
+		}
+	}
+
+	playlists, err := lib.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range playlists {
+		ids, err := p.TrackIDs()
+		if err != nil {
+			return nil, err
+		}
+
+		a.Playlists = append(a.Playlists, PlaylistRecord{
+			PersistentID: p.PersistentID(),
+			Name:         p.Name(),
+			Tracks:       ids,
+		})
+	}
+
+	return a, nil
+}
+
+func (a *Archive) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(a)
+}
+
+func (a *Archive) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = a.Write(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func Read(r io.Reader) (*Archive, error) {
+	var a Archive
+	err := json.NewDecoder(r).Decode(&a)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Version != archiveVersion {
+		return nil, errors.New(fmt.Sprintf("unsupported backup archive version:%v", a.Version))
+	}
+
+	return &a, nil
+}
+
+func ReadFile(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+type Report struct {
+	// Matched is the number of archived tracks found in the library.
+	Matched int
+	// Updated is the number of tracks whose metadata was changed.
+	Updated int
+	// Unmatched are the archived tracks that are no longer in the library.
+	Unmatched []TrackRecord
+
+	PlaylistsCreated int
+	// PlaylistTracksAdded is the number of tracks added back to playlists.
+	PlaylistTracksAdded int
+}
+
+// Restore reapplies the archived metadata to the library.
+// Tracks are matched by persistent ID, then by file path, then by name, artist and duration.
+// Play counts are never decreased, as the tracks may have been played since the backup.
+// Playlists are matched by persistent ID, then by name, and created if missing.
+// The tracks missing from a playlist are appended to it, tracks are not removed nor reordered.
+func Restore(lib Library, a *Archive) (*Report, error) {
+	tracks, err := lib.Tracks()
+	if err != nil {
+		return nil, err
+	}
+
+	library := make(map[string]Track, len(tracks))
+	candidates := make([]playlistfile.Candidate, len(tracks))
+	for i, t := range tracks {
+		library[t.PersistentID()] = t
+		candidates[i] = playlistfile.Candidate{
+			ID:       t.PersistentID(),
+			Location: t.Location(),
+			Title:    t.Name(),
+			Artist:   t.Artist(),
+			Duration: t.Duration(),
+		}
+	}
+	resolver := playlistfile.NewResolver(candidates)
+
+	report := &Report{}
+	// archived persistent ID -> library persistent ID
+	matches := make(map[string]string, len(a.Tracks))
+	for _, r := range a.Tracks {
+		t, ok := library[r.PersistentID]
+		if !ok {
+			id, resolved := resolver.Resolve(playlistfile.Entry{
+				Location: r.Location,
+				Title:    r.Name,
+				Artist:   r.Artist,
+				Album:    r.Album,
+				Duration: r.Duration,
+			})
+			if !resolved {
+				report.Unmatched = append(report.Unmatched, r)
+				continue
+			}
+			t = library[id]
+		}
+
+		report.Matched++
+		matches[r.PersistentID] = t.PersistentID()
+
+		updated, err := restoreTrack(t, r)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			report.Updated++
+		}
+	}
+
+	playlists, err := lib.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range a.Playlists {
+		p := findPlaylist(playlists, r)
+		if p == nil {
+			p, err = lib.CreatePlaylist(r.Name)
+			if err != nil {
+				return nil, err
+			}
+			playlists = append(playlists, p)
+			report.PlaylistsCreated++
+		}
+
+		err = restorePlaylist(p, r, matches, report)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func restoreTrack(t Track, r TrackRecord) (bool, error) {
+	updated := false
+	if t.Rating() != r.Rating {
+		err := t.SetRating(r.Rating)
+		if err != nil {
+			return false, err
+		}
+		updated = true
+	}
+
+	if t.PlayedCount() < r.PlayedCount {
+		err := t.SetPlayedCount(r.PlayedCount)
+		if err != nil {
+			return false, err
+		}
+		updated = true
+	}
+
+	if t.Comment() != r.Comment {
+		err := t.SetComment(r.Comment)
+		if err != nil {
+			return false, err
+		}
+		updated = true
+	}
+
+	if t.Loved() != r.Loved {
+		err := t.SetLoved(r.Loved)
+		// the loved flags are lost on the platforms without loved tracks.
+		if err != nil && !isNotSupported(err) {
+			return false, err
+		}
+		updated = updated || err == nil
+	}
+
+	return updated, nil
+}
+
+// isNotSupported reports whether err is the error of a platform without the feature, of a local or a remote iTunes.
+func isNotSupported(err error) bool {
+	var notSupported *itunes.NotSupportedError
+	return errors.As(err, &notSupported) || errors.Is(err, remote.ErrNotSupported)
+}
+
+func findPlaylist(playlists []Playlist, r PlaylistRecord) Playlist {
+	for _, p := range playlists {
+		if p.PersistentID() == r.PersistentID {
+			return p
+		}
+	}
+
+	for _, p := range playlists {
+		if p.Name() == r.Name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func restorePlaylist(p Playlist, r PlaylistRecord, matches map[string]string, report *Report) error {
+	ids, err := p.TrackIDs()
+	if err != nil {
+		return err
+	}
+
+	contained := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		contained[id] = true
+	}
+
+	for _, archived := range r.Tracks {
+		id, ok := matches[archived]
+		if !ok || contained[id] {
+			continue
+		}
+
+		err = p.AddTrack(id)
+		if err != nil {
+			return err
+		}
+
+		contained[id] = true
+		report.PlaylistTracksAdded++
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type track struct {
+	id, location, name, artist, album string
+	duration                          time.Duration
+	rating, count                     int
+	comment                           string
+	loved                             bool
+	// noLoved is a track of a platform without loved tracks.
+	noLoved bool
+}
+
+func (t *track) PersistentID() string    { return t.id }
+func (t *track) Location() string        { return t.location }
+func (t *track) Name() string            { return t.name }
+func (t *track) Artist() string          { return t.artist }
+func (t *track) Album() string           { return t.album }
+func (t *track) Duration() time.Duration { return t.duration }
+func (t *track) Rating() int             { return t.rating }
+func (t *track) PlayedCount() int        { return t.count }
+func (t *track) Comment() string         { return t.comment }
+func (t *track) Loved() bool             { return t.loved }
+
+func (t *track) SetRating(rating int) error      { t.rating = rating; return nil }
+func (t *track) SetPlayedCount(count int) error  { t.count = count; return nil }
+func (t *track) SetComment(comment string) error { t.comment = comment; return nil }
+
+func (t *track) SetLoved(loved bool) error {
+	if t.noLoved {
+		return &itunes.NotSupportedError{Feature: "SetLoved", Platform: "test"}
+	}
+	t.loved = loved
+	return nil
+}
+
+type playlist struct {
+	id      string
+	name    string
+	members []string
+}
+
+func (p *playlist) PersistentID() string        { return p.id }
+func (p *playlist) Name() string                { return p.name }
+func (p *playlist) TrackIDs() ([]string, error) { return append([]string(nil), p.members...), nil }
+
+func (p *playlist) AddTrack(id string) error {
+	p.members = append(p.members, id)
+	return nil
+}
+
+type fakeLibrary struct {
+	tracks    []*track
+	playlists []*playlist
+}
+
+func (l *fakeLibrary) Tracks() ([]Track, error) {
+	tracks := make([]Track, len(l.tracks))
+	for i, t := range l.tracks {
+		tracks[i] = t
+	}
+	return tracks, nil
+}
+
+func (l *fakeLibrary) Playlists() ([]Playlist, error) {
+	playlists := make([]Playlist, len(l.playlists))
+	for i, p := range l.playlists {
+		playlists[i] = p
+	}
+	return playlists, nil
+}
+
+func (l *fakeLibrary) CreatePlaylist(name string) (Playlist, error) {
+	p := &playlist{id: fmt.Sprintf("NP%d", len(l.playlists)+1), name: name}
+	l.playlists = append(l.playlists, p)
+	return p, nil
+}
+
+func (l *fakeLibrary) track(id string) *track {
+	for _, t := range l.tracks {
+		if t.id == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func TestCreate(t *testing.T) {
+	lib := &fakeLibrary{
+		tracks: []*track{
+			{id: "T1", location: "/m/1.mp3", name: "One", artist: "A", album: "X", duration: time.Minute, rating: 80, count: 3, comment: "live", loved: true},
+			{id: "T2", location: "/m/2.mp3", name: "Two", artist: "A", duration: 2 * time.Minute},
+		},
+		playlists: []*playlist{{id: "P1", name: "Mix", members: []string{"T2", "T1"}}},
+	}
+
+	a, err := Create(lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = a.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []TrackRecord{
+		{PersistentID: "T1", Location: "/m/1.mp3", Name: "One", Artist: "A", Album: "X", Duration: time.Minute, Rating: 80, PlayedCount: 3, Comment: "live", Loved: true},
+		{PersistentID: "T2", Location: "/m/2.mp3", Name: "Two", Artist: "A", Duration: 2 * time.Minute},
+	}
+	if !reflect.DeepEqual(read.Tracks, expected) {
+		t.Errorf("unexpected tracks: %+v", read.Tracks)
+	}
+	if !reflect.DeepEqual(read.Playlists, []PlaylistRecord{{PersistentID: "P1", Name: "Mix", Tracks: []string{"T2", "T1"}}}) {
+		t.Errorf("unexpected playlists: %+v", read.Playlists)
+	}
+
+	_, err = Read(strings.NewReader(`{"version":2}`))
+	if err == nil {
+		t.Error("expect an unknown version to fail")
+	}
+}
+
+func TestRestoreMatching(t *testing.T) {
+	// the library was rebuilt, most tracks have new persistent IDs.
+	lib := &fakeLibrary{
+		tracks: []*track{
+			{id: "T1", location: "/new/1.mp3", name: "One", artist: "A", duration: time.Minute},
+			{id: "N2", location: "/m/A/X/2.mp3", name: "Two (remaster)", artist: "A", duration: time.Minute},
+			{id: "N3", location: "/new/3.mp3", name: "Three", artist: "A", duration: time.Minute + time.Second},
+			{id: "N5", location: "/m/5.mp3", name: "Five", artist: "A", duration: time.Minute},
+			{id: "N6", location: "/new/6.mp3", name: "Six", artist: "A", duration: time.Minute, noLoved: true},
+			{id: "N7", location: "/new/7.mp3", name: "Seven", artist: "A", duration: time.Minute, count: 9},
+		},
+	}
+
+	a := &Archive{
+		Version: archiveVersion,
+		Tracks: []TrackRecord{
+			// the persistent ID wins over the path of another track.
+			{PersistentID: "T1", Location: "/m/5.mp3", Name: "One", Artist: "A", Duration: time.Minute, Rating: 20},
+			// the path wins over the metadata, which changed.
+			{PersistentID: "O2", Location: "/m/A/X/2.mp3", Name: "Two", Artist: "A", Duration: time.Minute, Rating: 40},
+			// the file moved, the metadata matches within the tolerance.
+			{PersistentID: "O3", Location: "/old/3.mp3", Name: "three", Artist: "a", Duration: time.Minute, Rating: 60},
+			{PersistentID: "O4", Location: "/old/4.mp3", Name: "Four", Artist: "A", Duration: time.Minute, Rating: 80},
+			// the path matches another track than the metadata, which cannot be loved.
+			{PersistentID: "O6", Location: "/new/6.mp3", Name: "Five", Artist: "A", Duration: time.Minute, Comment: "six", Loved: true},
+			// play counts are not decreased, loved is restored.
+			{PersistentID: "O7", Location: "/new/7.mp3", Name: "Seven", Artist: "A", Duration: time.Minute, PlayedCount: 5, Loved: true},
+		},
+	}
+
+	report, err := Restore(lib, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Matched != 5 || report.Updated != 5 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Unmatched) != 1 || report.Unmatched[0].PersistentID != "O4" {
+		t.Errorf("unexpected unmatched tracks: %+v", report.Unmatched)
+	}
+
+	ratings := map[string]int{"T1": 20, "N2": 40, "N3": 60, "N5": 0, "N6": 0, "N7": 0}
+	for id, rating := range ratings {
+		if r := lib.track(id).rating; r != rating {
+			t.Errorf("expect rating %v of %v, but %v", rating, id, r)
+		}
+	}
+	if n6 := lib.track("N6"); n6.comment != "six" || n6.loved {
+		t.Errorf("unexpected N6: comment %q loved %v", n6.comment, n6.loved)
+	}
+	if n7 := lib.track("N7"); n7.count != 9 || !n7.loved {
+		t.Errorf("unexpected N7: count %v loved %v", n7.count, n7.loved)
+	}
+}
+
+func TestRestorePlaylists(t *testing.T) {
+	lib := &fakeLibrary{
+		tracks: []*track{
+			{id: "T1", location: "/m/1.mp3", name: "One", artist: "A", duration: time.Minute},
+			{id: "N2", location: "/m/2.mp3", name: "Two", artist: "A", duration: time.Minute},
+			{id: "T3", location: "/m/3.mp3", name: "Three", artist: "A", duration: time.Minute},
+		},
+		playlists: []*playlist{
+			{id: "P1", name: "Renamed", members: []string{"T3"}},
+			{id: "P9", name: "Road", members: []string{"T1"}},
+		},
+	}
+
+	a := &Archive{
+		Version: archiveVersion,
+		Tracks: []TrackRecord{
+			{PersistentID: "T1", Location: "/m/1.mp3", Name: "One", Artist: "A", Duration: time.Minute},
+			{PersistentID: "O2", Location: "/m/2.mp3", Name: "Two", Artist: "A", Duration: time.Minute},
+			{PersistentID: "T3", Location: "/m/3.mp3", Name: "Three", Artist: "A", Duration: time.Minute},
+		},
+		Playlists: []PlaylistRecord{
+			// matched by persistent ID despite its new name.
+			{PersistentID: "P1", Name: "Mix", Tracks: []string{"T1", "O2", "T3"}},
+			// matched by name.
+			{PersistentID: "P2", Name: "Road", Tracks: []string{"O2", "O4"}},
+			{PersistentID: "P3", Name: "New", Tracks: []string{"T3"}},
+		},
+	}
+
+	report, err := Restore(lib, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.PlaylistsCreated != 1 || report.PlaylistTracksAdded != 4 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	members := map[string][]string{}
+	for _, p := range lib.playlists {
+		members[p.name] = p.members
+	}
+	expected := map[string][]string{
+		// tracks are appended, the order of the existing ones is kept.
+		"Renamed": {"T3", "T1", "N2"},
+		"Road":    {"T1", "N2"},
+		"New":     {"T3"},
+	}
+	if !reflect.DeepEqual(members, expected) {
+		t.Errorf("unexpected playlists: %v", members)
+	}
+}
@@ -0,0 +1,122 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package backup
+
+import "github.com/yaegaki/itunes-app-interface"
+
+// Itunes is the Library of an iTunes application.
+// Close it after the backup or the restore to release the tracks and playlists.
+type Itunes struct {
+	it        *itunes.Itunes
+	tracks    []*itunes.Track
+	playlists []*itunes.Playlist
+}
+
+func NewItunes(it *itunes.Itunes) *Itunes {
+	return &Itunes{it: it}
+}
+
+func (l *Itunes) Close() {
+	for _, t := range l.tracks {
+		t.Close()
+	}
+	for _, p := range l.playlists {
+		p.Close()
+	}
+	l.tracks = nil
+	l.playlists = nil
+}
+
+func (l *Itunes) Tracks() ([]Track, error) {
+	tracks, err := l.it.GetAllTracks()
+	if err != nil {
+		return nil, err
+	}
+	l.tracks = append(l.tracks, tracks...)
+
+	result := make([]Track, len(tracks))
+	for i, t := range tracks {
+		result[i] = t
+	}
+
+	return result, nil
+}
+
+func (l *Itunes) Playlists() ([]Playlist, error) {
+	count, err := l.it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		p, err := l.it.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.IsUserPlaylist() {
+			p.Close()
+			continue
+		}
+		l.playlists = append(l.playlists, p)
+		result = append(result, &itunesPlaylist{lib: l, p: p})
+	}
+
+	return result, nil
+}
+
+func (l *Itunes) CreatePlaylist(name string) (Playlist, error) {
+	p, err := l.it.CreatePlaylist(name)
+	if err != nil {
+		return nil, err
+	}
+	l.playlists = append(l.playlists, p)
+
+	return &itunesPlaylist{lib: l, p: p}, nil
+}
+
+type itunesPlaylist struct {
+	lib *Itunes
+	p   *itunes.Playlist
+}
+
+func (p *itunesPlaylist) PersistentID() string {
+	return p.p.PersistentID()
+}
+
+func (p *itunesPlaylist) Name() string {
+	return p.p.Name()
+}
+
+func (p *itunesPlaylist) TrackIDs() ([]string, error) {
+	output, err := p.p.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, 100)
+	for t := range output {
+		ids = append(ids, t.PersistentID())
+		t.Close()
+	}
+
+	return ids, nil
+}
+
+func (p *itunesPlaylist) AddTrack(trackID string) error {
+	t, err := p.lib.it.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	added, err := p.p.AddTrack(t)
+	if err != nil {
+		return err
+	}
+	added.Close()
+
+	return nil
+}
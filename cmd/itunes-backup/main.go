@@ -0,0 +1,120 @@
+// Command itunes-backup saves the ratings, play counts, loved flags, comments
+// and playlists of the library, and reapplies them after the library is rebuilt.
+//
+// Usage:
+//
+//	itunes-backup [-remote mac.local:7700 [-tls] [-ca cert.pem]] backup library.json
+//	itunes-backup [-remote mac.local:7700 [-tls] [-ca cert.pem]] restore library.json
+//
+// With -remote, the library is the one of a Mac running itunes-remote, with the token of $ITUNES_REMOTE_TOKEN.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/backup"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %v [flags] backup|restore <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	remoteAddr := flag.String("remote", "", "address of an itunes-remote server to use instead of the local iTunes")
+	useTLS := flag.Bool("tls", false, "connect to the remote server over TLS")
+	ca := flag.String("ca", "", "certificate file trusted for TLS, such as the self-signed certificate of the server, implies -tls")
+	flag.Parse()
+
+	if flag.NArg() != 2 || (flag.Arg(0) != "backup" && flag.Arg(0) != "restore") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *remoteAddr != "" {
+		opts, err := remote.NewOptions(os.Getenv("ITUNES_REMOTE_TOKEN"), *useTLS, *ca)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c, err := remote.Dial(*remoteAddr, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+
+		run(backup.NewRemote(c))
+		return
+	}
+
+	err := itunes.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itunes.UnInit()
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer it.Close()
+
+	lib := backup.NewItunes(it)
+	defer lib.Close()
+
+	run(lib)
+}
+
+func run(lib backup.Library) {
+	var err error
+	if flag.Arg(0) == "backup" {
+		err = save(lib, flag.Arg(1))
+	} else {
+		err = restore(lib, flag.Arg(1))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func save(lib backup.Library, path string) error {
+	a, err := backup.Create(lib)
+	if err != nil {
+		return err
+	}
+
+	err = a.WriteFile(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("saved %v tracks and %v playlists\n", len(a.Tracks), len(a.Playlists))
+	return nil
+}
+
+func restore(lib backup.Library, path string) error {
+	a, err := backup.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	report, err := backup.Restore(lib, a)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("matched %v tracks, updated %v\n", report.Matched, report.Updated)
+	fmt.Printf("created %v playlists, added %v tracks to playlists\n", report.PlaylistsCreated, report.PlaylistTracksAdded)
+	if len(report.Unmatched) > 0 {
+		fmt.Printf("%v tracks not found:\n", len(report.Unmatched))
+	}
+	for _, t := range report.Unmatched {
+		fmt.Printf("%v\t%v\t%v\t%v\n", t.PersistentID, t.Name, t.Artist, t.Location)
+	}
+
+	return nil
+}
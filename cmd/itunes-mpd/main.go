@@ -0,0 +1,67 @@
+// Command itunes-mpd lets MPD clients control iTunes.
+//
+// Usage:
+//
+//	itunes-mpd [-addr 127.0.0.1:6600] [-password secret] [-remote mac.local:7700 [-tls] [-ca cert.pem]]
+//
+// With a password, which defaults to $ITUNES_MPD_PASSWORD, the clients must log in with the password command.
+// MPD sends it in clear text, listen on other interfaces only in a trusted network.
+// With -remote, the clients control the iTunes of a Mac running itunes-remote, with the token of $ITUNES_REMOTE_TOKEN.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/mpd"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6600", "address to listen on")
+	password := flag.String("password", os.Getenv("ITUNES_MPD_PASSWORD"), "password of the clients, defaults to $ITUNES_MPD_PASSWORD, none if empty")
+	remoteAddr := flag.String("remote", "", "address of an itunes-remote server to use instead of the local iTunes")
+	useTLS := flag.Bool("tls", false, "connect to the remote server over TLS")
+	ca := flag.String("ca", "", "certificate file trusted for TLS, such as the self-signed certificate of the server, implies -tls")
+	flag.Parse()
+
+	if *remoteAddr != "" {
+		opts, err := remote.NewOptions(os.Getenv("ITUNES_REMOTE_TOKEN"), *useTLS, *ca)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c, err := remote.Dial(*remoteAddr, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+
+		serve(*addr, mpd.NewRemotePlayer(c), *password)
+		return
+	}
+
+	err := itunes.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itunes.UnInit()
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer it.Close()
+
+	serve(*addr, mpd.NewItunesPlayer(it), *password)
+}
+
+func serve(addr string, p mpd.Player, password string) {
+	log.Printf("listening on %v", addr)
+	err := mpd.New(p, password).ListenAndServe(addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,59 @@
+// Command itunes-mpris shows the iTunes of another machine as a media player of the Linux desktop.
+//
+// Usage:
+//
+//	ITUNES_REMOTE_TOKEN=secret itunes-mpris -addr mac.local:7700 [-tls] [-ca cert.pem]
+//
+// The Mac runs itunes-remote, the player is exported on the session bus as org.mpris.MediaPlayer2.itunes
+// until the command is interrupted.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/yaegaki/itunes-app-interface/mpris"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7700", "address of the itunes-remote server")
+	token := flag.String("token", os.Getenv("ITUNES_REMOTE_TOKEN"), "token of the server, defaults to $ITUNES_REMOTE_TOKEN")
+	useTLS := flag.Bool("tls", false, "connect over TLS")
+	ca := flag.String("ca", "", "certificate file trusted for TLS, such as the self-signed certificate of the server, implies -tls")
+	name := flag.String("name", "itunes", "name of the player on the bus, after org.mpris.MediaPlayer2.")
+	flag.Parse()
+
+	opts, err := remote.NewOptions(*token, *useTLS, *ca)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := remote.Dial(*addr, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	b, err := mpris.Export(conn, *name, mpris.NewRemotePlayer(c))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("exported %v on the session bus", *addr)
+	<-ctx.Done()
+}
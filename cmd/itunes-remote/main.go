@@ -0,0 +1,59 @@
+// Command itunes-remote serves the player to the clients of the remote package on other machines.
+//
+// Usage:
+//
+//	ITUNES_REMOTE_TOKEN=secret itunes-remote [-addr :7700] -cert cert.pem -key key.pem
+//
+// Clients connect with remote.Dial("host:7700", &remote.Options{TLSConfig: ..., Token: "secret"}).
+// Without -cert and -key the connection is not encrypted, listen on other interfaces only in a trusted network.
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7700", "address to listen on")
+	cert := flag.String("cert", "", "certificate file of TLS")
+	key := flag.String("key", "", "private key file of TLS")
+	token := flag.String("token", os.Getenv("ITUNES_REMOTE_TOKEN"), "token of the clients, defaults to $ITUNES_REMOTE_TOKEN")
+	noAuth := flag.Bool("no-auth", false, "accept clients without a token")
+	flag.Parse()
+
+	if *token == "" && !*noAuth {
+		log.Fatal("a token is required, set ITUNES_REMOTE_TOKEN or pass -no-auth")
+	}
+
+	var config *tls.Config
+	if *cert != "" || *key != "" {
+		pair, err := tls.LoadX509KeyPair(*cert, *key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config = &tls.Config{Certificates: []tls.Certificate{pair}}
+	}
+
+	err := itunes.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itunes.UnInit()
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer it.Close()
+
+	log.Printf("listening on %v", *addr)
+	err = remote.NewServer(remote.NewItunesPlayer(it), *token).ListenAndServe(*addr, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
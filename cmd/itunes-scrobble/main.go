@@ -0,0 +1,115 @@
+// Command itunes-scrobble scrobbles the tracks played in iTunes to Last.fm.
+//
+// Usage:
+//
+//	LASTFM_API_KEY=key LASTFM_SECRET=secret LASTFM_SESSION_KEY=sk itunes-scrobble [-queue queue.json] [-remote mac.local:7700 [-tls] [-ca cert.pem]]
+//
+// Without a session key, LASTFM_USERNAME and LASTFM_PASSWORD get one, which is printed to keep instead of the password.
+// Scrobbles wait in the queue file while Last.fm is unreachable. Set -url for a compatible service such as Libre.fm.
+// With -remote, the tracks played on a Mac running itunes-remote are scrobbled, with the token of $ITUNES_REMOTE_TOKEN.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/remote"
+	"github.com/yaegaki/itunes-app-interface/scrobble"
+)
+
+func main() {
+	queuePath := flag.String("queue", "", "file of the scrobbles not submitted yet, defaults to itunes-scrobble/queue.json in the user config directory")
+	apiURL := flag.String("url", scrobble.LastFMURL, "endpoint of the Last.fm compatible API")
+	remoteAddr := flag.String("remote", "", "address of an itunes-remote server to use instead of the local iTunes")
+	useTLS := flag.Bool("tls", false, "connect to the remote server over TLS")
+	ca := flag.String("ca", "", "certificate file trusted for TLS, such as the self-signed certificate of the server, implies -tls")
+	flag.Parse()
+
+	apiKey := os.Getenv("LASTFM_API_KEY")
+	secret := os.Getenv("LASTFM_SECRET")
+	if apiKey == "" || secret == "" {
+		log.Fatal("an API account is required, set LASTFM_API_KEY and LASTFM_SECRET")
+	}
+
+	lastfm := scrobble.NewLastFM(apiKey, secret, os.Getenv("LASTFM_SESSION_KEY"))
+	lastfm.URL = *apiURL
+	if lastfm.SessionKey == "" {
+		username := os.Getenv("LASTFM_USERNAME")
+		password := os.Getenv("LASTFM_PASSWORD")
+		if username == "" || password == "" {
+			log.Fatal("a session is required, set LASTFM_SESSION_KEY or LASTFM_USERNAME and LASTFM_PASSWORD")
+		}
+
+		key, err := lastfm.MobileSession(username, password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("session key: %v, set it to LASTFM_SESSION_KEY", key)
+		lastfm.SessionKey = key
+	}
+
+	if *queuePath == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		dir = filepath.Join(dir, "itunes-scrobble")
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*queuePath = filepath.Join(dir, "queue.json")
+	}
+
+	queue, err := scrobble.OpenQueue(*queuePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var player scrobble.Player
+	if *remoteAddr != "" {
+		opts, err := remote.NewOptions(os.Getenv("ITUNES_REMOTE_TOKEN"), *useTLS, *ca)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c, err := remote.Dial(*remoteAddr, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+
+		player = scrobble.NewRemotePlayer(c)
+	} else {
+		err = itunes.Init()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer itunes.UnInit()
+
+		it, err := itunes.CreateItunes()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer it.Close()
+
+		player = scrobble.NewItunesPlayer(it)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s := scrobble.New(player, queue, lastfm)
+	log.Printf("scrobbling, %v queued", queue.Len())
+	s.Run(ctx)
+
+	err = s.Flush()
+	if err != nil {
+		log.Printf("%v scrobbles left in the queue: %v", queue.Len(), err)
+	}
+}
@@ -0,0 +1,53 @@
+// Command itunes-server serves the HTTP REST API of the server package.
+//
+// Usage:
+//
+//	ITUNES_SERVER_TOKEN=secret itunes-server [-addr 127.0.0.1:8080]
+//
+// Clients send the token as "Authorization: Bearer secret".
+// Player changes are pushed to ws://127.0.0.1:8080/events?access_token=secret.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/server"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	token := flag.String("token", os.Getenv("ITUNES_SERVER_TOKEN"), "bearer token, defaults to $ITUNES_SERVER_TOKEN")
+	noAuth := flag.Bool("no-auth", false, "accept requests without a token")
+	flag.Parse()
+
+	if *token == "" && !*noAuth {
+		log.Fatal("a token is required, set ITUNES_SERVER_TOKEN or pass -no-auth")
+	}
+
+	err := itunes.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itunes.UnInit()
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer it.Close()
+
+	var handler http.Handler = server.New(server.NewItunesPlayer(it))
+	if !*noAuth {
+		handler = server.TokenAuth(*token, handler)
+	}
+
+	log.Printf("listening on %v", *addr)
+	err = http.ListenAndServe(*addr, handler)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+// Command itunes-subsonic serves the iTunes library to Subsonic clients.
+//
+// Usage:
+//
+//	ITUNES_SUBSONIC_PASSWORD=secret itunes-subsonic [-addr 127.0.0.1:4040] [-user admin] [-remote mac.local:7700 [-tls] [-ca cert.pem]]
+//
+// Point the client at http://127.0.0.1:4040 and log in with the user and the password.
+// With -remote, the library is the one of a Mac running itunes-remote, with the token of $ITUNES_REMOTE_TOKEN.
+// Its music folder must be mounted at the same path to stream the tracks.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/remote"
+	"github.com/yaegaki/itunes-app-interface/subsonic"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:4040", "address to listen on")
+	user := flag.String("user", "admin", "user name of the clients")
+	password := flag.String("password", os.Getenv("ITUNES_SUBSONIC_PASSWORD"), "password of the clients, defaults to $ITUNES_SUBSONIC_PASSWORD")
+	remoteAddr := flag.String("remote", "", "address of an itunes-remote server to use instead of the local iTunes")
+	useTLS := flag.Bool("tls", false, "connect to the remote server over TLS")
+	ca := flag.String("ca", "", "certificate file trusted for TLS, such as the self-signed certificate of the server, implies -tls")
+	flag.Parse()
+
+	if *password == "" {
+		log.Fatal("a password is required, set ITUNES_SUBSONIC_PASSWORD")
+	}
+
+	if *remoteAddr != "" {
+		opts, err := remote.NewOptions(os.Getenv("ITUNES_REMOTE_TOKEN"), *useTLS, *ca)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c, err := remote.Dial(*remoteAddr, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+
+		serve(*addr, subsonic.NewRemoteLibrary(c), *user, *password)
+		return
+	}
+
+	err := itunes.Init()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer itunes.UnInit()
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer it.Close()
+
+	serve(*addr, subsonic.NewItunesLibrary(it), *user, *password)
+}
+
+func serve(addr string, l subsonic.Library, user, password string) {
+	log.Printf("listening on %v", addr)
+	err := http.ListenAndServe(addr, subsonic.New(l, user, password))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
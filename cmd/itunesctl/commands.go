@@ -0,0 +1,295 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+)
+
+func init() {
+	register(&command{name: "play", args: "[track-id]", usage: "start playing, or play a track", setup: noFlags(play)})
+	register(&command{name: "pause", usage: "pause", setup: noFlags(pause)})
+	register(&command{name: "next", usage: "play the next track", setup: noFlags(next)})
+	register(&command{name: "prev", usage: "play the previous track", setup: noFlags(prev)})
+	register(&command{name: "volume", args: "[0-100]", usage: "show or set the volume", setup: noFlags(volume)})
+	register(&command{name: "now", usage: "show the current track", setup: noFlags(now)})
+	register(&command{name: "search", args: "[-limit n] <words>...", usage: "search tracks by name, artist and album", setup: search})
+	register(&command{name: "playlists", usage: "list the playlists", setup: noFlags(playlists)})
+	register(&command{name: "tracks", args: "[-limit n] [playlist-id]", usage: "list the tracks of the library or a playlist", setup: tracks})
+	register(&command{name: "export", args: "[-relative] [-slash] <playlist-id> <file>", usage: "export a playlist to M3U/M3U8, PLS or XSPF", setup: export})
+	register(&command{name: "artwork", args: "[-o dir] [track-id]", usage: "save the artwork of the current track or a track", setup: artwork})
+	register(&command{name: "completion", args: "bash|zsh|fish", usage: "print a shell completion script", setup: noFlags(completion), offline: true})
+}
+
+type trackInfo struct {
+	PersistentID string `json:"persistentId"`
+	Name         string `json:"name"`
+	Artist       string `json:"artist"`
+	Album        string `json:"album"`
+	// Duration is in seconds.
+	Duration float64 `json:"duration"`
+	Location string  `json:"location,omitempty"`
+}
+
+// track is a track of the local or the remote iTunes.
+type track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Album() string
+	Duration() time.Duration
+	Location() string
+}
+
+func newTrackInfo(t track) trackInfo {
+	return trackInfo{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Duration:     t.Duration().Seconds(),
+		Location:     t.Location(),
+	}
+}
+
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func (e *env) printTracks(tracks []trackInfo) error {
+	return e.print(tracks, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, t := range tracks {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", t.PersistentID, t.Name, t.Artist, t.Album, formatDuration(t.Duration))
+		}
+		return tw.Flush()
+	})
+}
+
+func play(e *env, args []string) error {
+	if len(args) > 1 {
+		return usagef("play takes a track id at most")
+	}
+
+	if len(args) == 0 {
+		return e.p.Play()
+	}
+
+	return e.p.PlayTrack(args[0])
+}
+
+func pause(e *env, _ []string) error {
+	return e.p.Pause()
+}
+
+func next(e *env, _ []string) error {
+	return e.p.NextTrack()
+}
+
+func prev(e *env, _ []string) error {
+	return e.p.PreviousTrack()
+}
+
+func volume(e *env, args []string) error {
+	if len(args) > 1 {
+		return usagef("volume takes a volume at most")
+	}
+
+	if len(args) == 1 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil {
+			return usagef("invalid volume:%v", args[0])
+		}
+
+		err = e.p.SetSoundVolume(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err := e.p.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	return e.print(map[string]int{"volume": v}, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	})
+}
+
+type nowPlaying struct {
+	State string `json:"state"`
+	// Position is in seconds.
+	Position float64   `json:"position"`
+	Track    trackInfo `json:"track"`
+}
+
+func now(e *env, _ []string) error {
+	n, err := e.p.NowPlaying()
+	if err != nil {
+		return err
+	}
+
+	return e.print(n, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%v - %v (%v)\n%v %v/%v\n", n.Track.Artist, n.Track.Name, n.Track.Album,
+			n.State, formatDuration(n.Position), formatDuration(n.Track.Duration))
+		return err
+	})
+}
+
+// collectTracks returns up to limit tracks of the playlist, or of the library if playlistID is empty, matching match.
+func (e *env) collectTracks(playlistID string, limit int, match func(t trackInfo) bool) ([]trackInfo, error) {
+	tracks := make([]trackInfo, 0)
+	// the tracks are read to the end, the local producer does not stop by itself.
+	err := e.p.EachTrack(playlistID, func(t trackInfo) {
+		if (limit <= 0 || len(tracks) < limit) && match(t) {
+			tracks = append(tracks, t)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func search(fs *flag.FlagSet) runner {
+	limit := fs.Int("limit", 0, "")
+	return func(e *env, args []string) error {
+		if len(args) == 0 {
+			return usagef("search takes words to search")
+		}
+
+		words := make([]string, len(args))
+		for i, arg := range args {
+			words[i] = strings.ToLower(arg)
+		}
+
+		tracks, err := e.collectTracks("", *limit, func(t trackInfo) bool {
+			s := strings.ToLower(t.Name + "\n" + t.Artist + "\n" + t.Album)
+			for _, word := range words {
+				if !strings.Contains(s, word) {
+					return false
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+
+		return e.printTracks(tracks)
+	}
+}
+
+type playlistInfo struct {
+	PersistentID string `json:"persistentId"`
+	Name         string `json:"name"`
+	User         bool   `json:"user"`
+}
+
+func playlists(e *env, _ []string) error {
+	result, err := e.p.Playlists()
+	if err != nil {
+		return err
+	}
+
+	return e.print(result, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, p := range result {
+			fmt.Fprintf(tw, "%v\t%v\n", p.PersistentID, p.Name)
+		}
+		return tw.Flush()
+	})
+}
+
+func tracks(fs *flag.FlagSet) runner {
+	limit := fs.Int("limit", 0, "")
+	return func(e *env, args []string) error {
+		if len(args) > 1 {
+			return usagef("tracks takes a playlist id at most")
+		}
+
+		var playlistID string
+		if len(args) == 1 {
+			playlistID = args[0]
+		}
+
+		tracks, err := e.collectTracks(playlistID, *limit, func(_ trackInfo) bool { return true })
+		if err != nil {
+			return err
+		}
+
+		return e.printTracks(tracks)
+	}
+}
+
+func export(fs *flag.FlagSet) runner {
+	relative := fs.Bool("relative", false, "")
+	slash := fs.Bool("slash", false, "")
+	return func(e *env, args []string) error {
+		if len(args) != 2 {
+			return usagef("export takes a playlist id and a file")
+		}
+
+		format, err := playlistfile.FormatOf(args[1])
+		if err != nil {
+			return usagef("%v", err)
+		}
+
+		opts := itunes.ExportOptions{Slash: *slash}
+		if *relative {
+			opts.RelativeTo = filepath.Dir(args[1])
+		}
+
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+
+		err = e.p.ExportPlaylist(args[0], f, format, opts)
+		if err != nil {
+			// the playlist is looked up once the file is created, a missing one leaves no file.
+			f.Close()
+			os.Remove(args[1])
+			return err
+		}
+
+		return f.Close()
+	}
+}
+
+func artwork(fs *flag.FlagSet) runner {
+	dir := fs.String("o", ".", "")
+	return func(e *env, args []string) error {
+		if len(args) > 1 {
+			return usagef("artwork takes a track id at most")
+		}
+
+		var trackID string
+		if len(args) == 1 {
+			trackID = args[0]
+		}
+
+		path, err := e.p.SaveArtwork(trackID, *dir)
+		if err != nil {
+			return err
+		}
+
+		return e.print(map[string]string{"path": path}, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, path)
+			return err
+		})
+	}
+}
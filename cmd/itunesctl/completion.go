@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the completion scripts complete commands, and playlist ids by calling "itunesctl playlists".
+const bashCompletion = `_itunesctl() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local i cmd=""
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		-*) ;;
+		*) cmd="${COMP_WORDS[i]}"; break ;;
+		esac
+	done
+
+	case "$cmd" in
+	"")
+		COMPREPLY=($(compgen -W "-json help %[1]v" -- "$cur")) ;;
+	tracks|export)
+		COMPREPLY=($(compgen -W "$(itunesctl playlists 2>/dev/null | cut -d' ' -f1)" -- "$cur")) ;;
+	completion)
+		COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+	*)
+		COMPREPLY=($(compgen -f -- "$cur")) ;;
+	esac
+}
+complete -F _itunesctl itunesctl
+`
+
+const zshCompletion = `#compdef itunesctl
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+const fishCompletion = `complete -c itunesctl -f
+complete -c itunesctl -l json -o json -d "JSON output"
+complete -c itunesctl -n "__fish_use_subcommand" -a "help %[1]v"
+complete -c itunesctl -n "__fish_seen_subcommand_from tracks export" -a "(itunesctl playlists 2>/dev/null | cut -d' ' -f1)"
+complete -c itunesctl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c itunesctl -n "__fish_seen_subcommand_from export" -F
+`
+
+func completion(e *env, args []string) error {
+	if len(args) != 1 {
+		return usagef("completion takes a shell: bash, zsh or fish")
+	}
+
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return usagef("unknown shell:%v", args[0])
+	}
+
+	_, err := fmt.Fprintf(e.out, script, strings.Join(commandNames(), " "))
+	return err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"io"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+)
+
+// localPlayer is the iTunes of this machine.
+type localPlayer struct {
+	it *itunes.Itunes
+}
+
+func newLocalPlayer() (player, error) {
+	err := itunes.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := itunes.CreateItunes()
+	if err != nil {
+		itunes.UnInit()
+		return nil, err
+	}
+
+	return &localPlayer{it: it}, nil
+}
+
+func (p *localPlayer) Close() {
+	p.it.Close()
+	itunes.UnInit()
+}
+
+func (p *localPlayer) Play() error          { return p.it.Play() }
+func (p *localPlayer) Pause() error         { return p.it.Pause() }
+func (p *localPlayer) NextTrack() error     { return p.it.NextTrack() }
+func (p *localPlayer) PreviousTrack() error { return p.it.PreviousTrack() }
+
+func (p *localPlayer) PlayTrack(trackID string) error {
+	t, err := p.it.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	return t.Play()
+}
+
+func (p *localPlayer) SoundVolume() (int, error) {
+	return p.it.SoundVolume()
+}
+
+func (p *localPlayer) SetSoundVolume(volume int) error {
+	return p.it.SetSoundVolume(volume)
+}
+
+func (p *localPlayer) NowPlaying() (*nowPlaying, error) {
+	t, err := p.it.CurrentTrack()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	pos, err := p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	return &nowPlaying{
+		State:    state.String(),
+		Position: pos.Seconds(),
+		Track:    newTrackInfo(t),
+	}, nil
+}
+
+func (p *localPlayer) EachTrack(playlistID string, fn func(t trackInfo)) error {
+	var output chan *itunes.Track
+	var err error
+	if playlistID == "" {
+		output, err = p.it.GetTracks()
+	} else {
+		var pl *itunes.Playlist
+		pl, err = p.it.FindPlaylistByPersistentID(playlistID)
+		if err != nil {
+			return err
+		}
+		defer pl.Close()
+
+		output, err = pl.GetTracks()
+	}
+	if err != nil {
+		return err
+	}
+
+	for t := range output {
+		fn(newTrackInfo(t))
+		t.Close()
+	}
+
+	return nil
+}
+
+func (p *localPlayer) Playlists() ([]playlistInfo, error) {
+	count, err := p.it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]playlistInfo, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := p.it.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, playlistInfo{
+			PersistentID: pl.PersistentID(),
+			Name:         pl.Name(),
+			User:         pl.IsUserPlaylist(),
+		})
+		pl.Close()
+	}
+
+	return result, nil
+}
+
+func (p *localPlayer) ExportPlaylist(playlistID string, w io.Writer, format playlistfile.Format, opts itunes.ExportOptions) error {
+	pl, err := p.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return err
+	}
+	defer pl.Close()
+
+	return pl.ExportWithOptions(w, format, opts)
+}
+
+func (p *localPlayer) SaveArtwork(trackID, dir string) (string, error) {
+	var t *itunes.Track
+	var err error
+	if trackID == "" {
+		t, err = p.it.CurrentTrack()
+	} else {
+		t, err = p.it.FindTrackByPersistentID(trackID)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+
+	artworks, err := t.GetArtworks()
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	for a := range artworks {
+		if path == "" && err == nil {
+			path, err = a.SaveToFile(dir, t.PersistentID())
+		}
+		a.Close()
+	}
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", &itunes.NotFoundError{Kind: "artwork", ID: t.PersistentID()}
+	}
+
+	return path, nil
+}
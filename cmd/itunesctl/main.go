@@ -0,0 +1,225 @@
+// Command itunesctl controls and queries iTunes from the command line.
+//
+// Usage:
+//
+//	itunesctl [-json] [-remote host:port [-tls] [-ca cert.pem]] <command> [arguments]
+//
+// Run "itunesctl help" for the list of commands and
+// "itunesctl completion bash|zsh|fish" for a shell completion script.
+//
+// With -remote, or $ITUNES_REMOTE_ADDR, the commands control the iTunes of another machine running itunes-remote,
+// with the token of $ITUNES_REMOTE_TOKEN.
+//
+// Exit codes:
+//
+//	0 success
+//	1 failure
+//	2 invalid usage or value out of range
+//	3 track or playlist not found
+//	4 no current track
+//	5 not supported on this platform
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitNotFound
+	exitNoCurrentTrack
+	exitNotSupported
+)
+
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	var notFound *itunes.NotFoundError
+	var notSupported *itunes.NotSupportedError
+	var outOfRange *itunes.OutOfRangeError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usage), errors.As(err, &outOfRange), errors.Is(err, remote.ErrOutOfRange):
+		return exitUsage
+	case errors.As(err, &notFound), errors.Is(err, remote.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, itunes.ErrNoCurrentTrack), errors.Is(err, remote.ErrNoCurrentTrack):
+		return exitNoCurrentTrack
+	case errors.As(err, &notSupported), errors.Is(err, remote.ErrNotSupported):
+		return exitNotSupported
+	}
+
+	return exitFailure
+}
+
+// player is what the commands control, the local iTunes or the iTunes of another machine.
+type player interface {
+	Play() error
+	PlayTrack(trackID string) error
+	Pause() error
+	NextTrack() error
+	PreviousTrack() error
+	SoundVolume() (int, error)
+	SetSoundVolume(volume int) error
+	// NowPlaying fails with a no current track error if the player has no track.
+	NowPlaying() (*nowPlaying, error)
+	// EachTrack calls fn with the tracks of the playlist, or of the library if playlistID is empty.
+	EachTrack(playlistID string, fn func(t trackInfo)) error
+	Playlists() ([]playlistInfo, error)
+	ExportPlaylist(playlistID string, w io.Writer, format playlistfile.Format, opts itunes.ExportOptions) error
+	// SaveArtwork saves the first artwork of the track, or of the current track if trackID is empty,
+	// to the directory and returns its path.
+	SaveArtwork(trackID, dir string) (string, error)
+	Close()
+}
+
+type env struct {
+	p    player
+	json bool
+	out  io.Writer
+}
+
+// print writes v as JSON with -json, and calls text otherwise.
+func (e *env) print(v interface{}, text func(w io.Writer) error) error {
+	if e.json {
+		encoder := json.NewEncoder(e.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	return text(e.out)
+}
+
+type runner func(e *env, args []string) error
+
+type command struct {
+	name  string
+	args  string
+	usage string
+	// setup defines the flags of the command and returns the function running it.
+	setup func(fs *flag.FlagSet) runner
+	// offline commands do not connect to iTunes.
+	offline bool
+}
+
+// noFlags is the setup of a command without flags.
+func noFlags(run runner) func(fs *flag.FlagSet) runner {
+	return func(_ *flag.FlagSet) runner {
+		return run
+	}
+}
+
+var commands = map[string]*command{}
+
+func register(c *command) {
+	commands[c.name] = c
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: itunesctl [-json] [-remote host:port [-tls] [-ca cert.pem]] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range commandNames() {
+		c := commands[name]
+		fmt.Fprintf(w, "  %-28v %v\n", c.name+" "+c.args, c.usage)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("itunesctl", flag.ContinueOnError)
+	global.SetOutput(ioutil.Discard)
+	jsonOutput := global.Bool("json", false, "")
+	remoteAddr := global.String("remote", os.Getenv("ITUNES_REMOTE_ADDR"), "")
+	useTLS := global.Bool("tls", false, "")
+	ca := global.String("ca", "", "")
+	err := global.Parse(args)
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	if global.NArg() == 0 {
+		printUsage(os.Stderr)
+		return usagef("no command")
+	}
+
+	name := global.Arg(0)
+	if name == "help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	c, ok := commands[name]
+	if !ok {
+		return usagef("unknown command:%v", name)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	// -json is also accepted after the command.
+	fs.BoolVar(jsonOutput, "json", *jsonOutput, "")
+	cmd := c.setup(fs)
+	err = fs.Parse(global.Args()[1:])
+	if err != nil {
+		return usagef("%v: %v\nusage: itunesctl %v %v", name, err, c.name, c.args)
+	}
+
+	e := &env{json: *jsonOutput, out: os.Stdout}
+	if c.offline {
+		return cmd(e, fs.Args())
+	}
+
+	if *remoteAddr != "" {
+		e.p, err = newRemotePlayer(*remoteAddr, os.Getenv("ITUNES_REMOTE_TOKEN"), *useTLS, *ca)
+	} else {
+		e.p, err = newLocalPlayer()
+	}
+	if err != nil {
+		return err
+	}
+	defer e.p.Close()
+
+	return cmd(e, fs.Args())
+}
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "itunesctl: %v\n", err)
+	}
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"io"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+// remotePlayer is the iTunes of another machine, served by itunes-remote.
+// The locations of its tracks are paths on that machine.
+type remotePlayer struct {
+	c *remote.Client
+}
+
+func newRemotePlayer(addr, token string, useTLS bool, ca string) (player, error) {
+	opts, err := remote.NewOptions(token, useTLS, ca)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := remote.Dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remotePlayer{c: c}, nil
+}
+
+func (p *remotePlayer) Close() {
+	p.c.Close()
+}
+
+func (p *remotePlayer) Play() error          { return p.c.Play() }
+func (p *remotePlayer) Pause() error         { return p.c.Pause() }
+func (p *remotePlayer) NextTrack() error     { return p.c.NextTrack() }
+func (p *remotePlayer) PreviousTrack() error { return p.c.PreviousTrack() }
+
+func (p *remotePlayer) PlayTrack(trackID string) error {
+	t, err := p.c.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return err
+	}
+
+	return t.Play()
+}
+
+func (p *remotePlayer) SoundVolume() (int, error) {
+	return p.c.SoundVolume()
+}
+
+func (p *remotePlayer) SetSoundVolume(volume int) error {
+	return p.c.SetSoundVolume(volume)
+}
+
+func (p *remotePlayer) NowPlaying() (*nowPlaying, error) {
+	t, err := p.c.CurrentTrack()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := p.c.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	pos, err := p.c.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	return &nowPlaying{
+		State:    state.String(),
+		Position: pos.Seconds(),
+		Track:    newTrackInfo(t),
+	}, nil
+}
+
+func (p *remotePlayer) EachTrack(playlistID string, fn func(t trackInfo)) error {
+	visit := func(t *remote.Track) error {
+		fn(newTrackInfo(t))
+		return nil
+	}
+
+	if playlistID == "" {
+		return p.c.EachTrack(visit)
+	}
+
+	pl, err := p.c.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return err
+	}
+
+	return pl.EachTrack(visit)
+}
+
+func (p *remotePlayer) Playlists() ([]playlistInfo, error) {
+	count, err := p.c.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]playlistInfo, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := p.c.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, playlistInfo{
+			PersistentID: pl.PersistentID(),
+			Name:         pl.Name(),
+			User:         pl.IsUserPlaylist(),
+		})
+	}
+
+	return result, nil
+}
+
+func (p *remotePlayer) ExportPlaylist(playlistID string, w io.Writer, format playlistfile.Format, opts itunes.ExportOptions) error {
+	pl, err := p.c.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return err
+	}
+
+	f := &playlistfile.Playlist{
+		Title:   pl.Name(),
+		Entries: make([]playlistfile.Entry, 0, 100),
+	}
+	err = pl.EachTrack(func(t *remote.Track) error {
+		location := t.Location()
+		if t.IsFileTrack() && location != "" {
+			location = opts.FileLocation(location)
+		}
+
+		f.Entries = append(f.Entries, playlistfile.Entry{
+			Location: location,
+			Title:    t.Name(),
+			Artist:   t.Artist(),
+			Album:    t.Album(),
+			Duration: t.Duration(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return playlistfile.Write(w, f, format)
+}
+
+func (p *remotePlayer) SaveArtwork(trackID, dir string) (string, error) {
+	var t *remote.Track
+	var err error
+	if trackID == "" {
+		t, err = p.c.CurrentTrack()
+	} else {
+		t, err = p.c.FindTrackByPersistentID(trackID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	artworks, err := t.GetArtworks()
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	for a := range artworks {
+		if path == "" && err == nil {
+			path, err = a.SaveToFile(dir, t.PersistentID())
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", &itunes.NotFoundError{Kind: "artwork", ID: t.PersistentID()}
+	}
+
+	return path, nil
+}
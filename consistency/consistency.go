@@ -0,0 +1,127 @@
+// Package consistency cross-references the files of library tracks with the file system.
+//
+// *itunes.Track implements Track:
+//
+//	all, err := it.GetAllTracks()
+//	tracks := make([]consistency.Track, len(all))
+//	for i, t := range all {
+//		tracks[i] = t
+//	}
+//	report, err := consistency.Check(tracks, mediaFolder)
+package consistency
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type Track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Location() string
+	// IsDead reports whether the track is a file track whose file is missing.
+	IsDead() bool
+
+	Delete() error
+}
+
+type Report struct {
+	MediaFolder string
+
+	// Dead are the file tracks whose files are missing.
+	Dead []Track
+	// Orphans are the media files under MediaFolder that are not in the library.
+	Orphans []string
+}
+
+// Check finds the dead tracks of the library and orphaned files under mediaFolder.
+func Check(tracks []Track, mediaFolder string) (*Report, error) {
+	mediaFolder, err := filepath.Abs(mediaFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		MediaFolder: mediaFolder,
+		Dead:        make([]Track, 0),
+		Orphans:     make([]string, 0),
+	}
+
+	foldCase := itunes.CaseInsensitive(mediaFolder)
+	locations := map[string]bool{}
+	for _, t := range tracks {
+		if t.Location() != "" {
+			locations[itunes.NormalizePath(t.Location(), foldCase)] = true
+		}
+
+		if t.IsDead() {
+			report.Dead = append(report.Dead, t)
+		}
+	}
+
+	files, err := itunes.MediaFiles([]string{mediaFolder})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !locations[itunes.NormalizePath(file, foldCase)] {
+			report.Orphans = append(report.Orphans, file)
+		}
+	}
+
+	return report, nil
+}
+
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+
+	for _, t := range r.Dead {
+		err := write("dead\t%v\t%v - %v\t%v\n", t.PersistentID(), t.Artist(), t.Name(), t.Location())
+		if err != nil {
+			return written, err
+		}
+	}
+
+	for _, file := range r.Orphans {
+		err := write("orphan\t%v\n", file)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	err := write("dead:%d orphans:%d media folder:%v\n", len(r.Dead), len(r.Orphans), r.MediaFolder)
+	return written, err
+}
+
+// RemoveDead deletes the dead tracks from the library.
+func (r *Report) RemoveDead() error {
+	for len(r.Dead) > 0 {
+		t := r.Dead[0]
+		err := t.Delete()
+		if err != nil {
+			return err
+		}
+
+		r.Dead = r.Dead[1:]
+	}
+
+	return nil
+}
+
+// ImportOrphans adds the orphaned files to the library.
+func (r *Report) ImportOrphans(it *itunes.Itunes, progress itunes.AddProgress) ([]*itunes.Track, error) {
+	tracks, err := it.AddFiles(r.Orphans, progress)
+	r.Orphans = r.Orphans[len(tracks):]
+
+	return tracks, err
+}
@@ -0,0 +1,77 @@
+package consistency
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type track struct {
+	id, location string
+	deleted      bool
+}
+
+func (t *track) PersistentID() string { return t.id }
+func (t *track) Name() string         { return "Song " + t.id }
+func (t *track) Artist() string       { return "Artist" }
+func (t *track) Location() string     { return t.location }
+func (t *track) Delete() error        { t.deleted = true; return nil }
+
+func (t *track) IsDead() bool {
+	_, err := os.Stat(t.location)
+	return err != nil
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/One.mp3", "a/Two.m4a", "b/Three.mp3", "b/cover.jpg", ".hidden/Four.mp3"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(path, []byte("audio"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	one := &track{id: "1", location: filepath.Join(dir, "a", "One.mp3")}
+	// the location is not clean, it is the same file as Two.m4a.
+	two := &track{id: "2", location: filepath.Join(dir, "b", "..", "a", "Two.m4a")}
+	dead := &track{id: "3", location: filepath.Join(dir, "a", "Missing.mp3")}
+	tracks := []Track{one, two, dead}
+
+	report, err := Check(tracks, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Dead) != 1 || report.Dead[0] != Track(dead) {
+		t.Errorf("expect track 3 to be dead, but %v", report.Dead)
+	}
+
+	orphans := []string{filepath.Join(dir, "b", "Three.mp3")}
+	if !reflect.DeepEqual(report.Orphans, orphans) {
+		t.Errorf("expect %v to be orphans, but %v", orphans, report.Orphans)
+	}
+
+	var buf bytes.Buffer
+	_, err = report.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "dead:1 orphans:1 media folder:"+dir+"\n") {
+		t.Errorf("unexpected report: %v", buf.String())
+	}
+
+	err = report.RemoveDead()
+	if err != nil || !dead.deleted || one.deleted || two.deleted || len(report.Dead) != 0 {
+		t.Errorf("expect only track 3 to be deleted: %v", err)
+	}
+}
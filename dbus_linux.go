@@ -0,0 +1,159 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mprisPrefix    = "org.mpris.MediaPlayer2."
+	mprisPath      = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface      = "org.mpris.MediaPlayer2"
+	playerIface    = "org.mpris.MediaPlayer2.Player"
+	trackListIface = "org.mpris.MediaPlayer2.TrackList"
+	playlistsIface = "org.mpris.MediaPlayer2.Playlists"
+
+	noTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+
+	// playerEnv names the player to control when several are running.
+	playerEnv = "ITUNES_MPRIS_PLAYER"
+)
+
+// findPlayer returns the bus name of the player named by ITUNES_MPRIS_PLAYER,
+// either the full name or the part after "org.mpris.MediaPlayer2." such as "rhythmbox",
+// otherwise the first player by name.
+func findPlayer(conn *dbus.Conn) (string, error) {
+	var names []string
+	err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names)
+	if err != nil {
+		return "", err
+	}
+
+	players := make([]string, 0, 4)
+	for _, name := range names {
+		if strings.HasPrefix(name, mprisPrefix) {
+			players = append(players, name)
+		}
+	}
+	sort.Strings(players)
+
+	want := os.Getenv(playerEnv)
+	for _, name := range players {
+		if want == "" {
+			return name, nil
+		}
+
+		short := strings.TrimPrefix(name, mprisPrefix)
+		// a player running several instances appends ".instance<pid>" to its name.
+		if name == want || short == want || strings.HasPrefix(short, want+".") {
+			return name, nil
+		}
+	}
+
+	if want != "" {
+		return "", errors.New(fmt.Sprintf("MPRIS player is not running:%v", want))
+	}
+
+	return "", errors.New("no MPRIS player is running.")
+}
+
+// makePersistentID derives a persistent ID in the iTunes format from an object path,
+// MPRIS only identifies tracks and playlists by their paths.
+func makePersistentID(path dbus.ObjectPath) string {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	return fmt.Sprintf("%016X", h.Sum64())
+}
+
+func (it *Itunes) callMethod(method string, args ...interface{}) error {
+	return it.obj.Call(method, 0, args...).Err
+}
+
+func (it *Itunes) getProperty(property string) (interface{}, error) {
+	v, err := it.obj.GetProperty(property)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Value(), nil
+}
+
+func (it *Itunes) putProperty(property string, v interface{}) error {
+	return it.obj.SetProperty(property, dbus.MakeVariant(v))
+}
+
+func (it *Itunes) getBool(property string) (bool, error) {
+	v, err := it.getProperty(property)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.New(fmt.Sprintf("%v is not a boolean.", property))
+	}
+
+	return b, nil
+}
+
+func (it *Itunes) getFloat(property string) (float64, error) {
+	v, err := it.getProperty(property)
+	if err != nil {
+		return 0, err
+	}
+
+	f, ok := variantFloat(v)
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("%v is not a number.", property))
+	}
+
+	return f, nil
+}
+
+func variantString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case dbus.ObjectPath:
+		return string(v)
+	case []string:
+		return strings.Join(v, ", ")
+	}
+
+	return ""
+}
+
+// variantFloat accepts any numeric type, players disagree on the types of lengths and counts.
+func variantFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case byte:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+func variantInt(v interface{}) int64 {
+	f, _ := variantFloat(v)
+	return int64(f)
+}
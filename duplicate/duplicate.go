@@ -0,0 +1,193 @@
+// Package duplicate finds duplicate tracks in a library and resolves them.
+//
+// *itunes.Track implements Track:
+//
+//	all, err := it.GetAllTracks()
+//	tracks := make([]duplicate.Track, len(all))
+//	for i, t := range all {
+//		tracks[i] = t
+//	}
+//	groups := duplicate.Find(tracks, duplicate.Criteria{SameAlbum: true})
+package duplicate
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type Track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Album() string
+	Duration() time.Duration
+	Location() string
+	BitRate() int
+	PlayedCount() int
+	DateAdded() time.Time
+
+	SetPlayedCount(count int) error
+	Delete() error
+}
+
+type Criteria struct {
+	// SameFile groups the tracks pointing to the same file instead of comparing the metadata.
+	SameFile bool
+	// FoldCase ignores the case of the locations for SameFile, set it by itunes.CaseInsensitive of the media folder.
+	FoldCase bool
+
+	// tracks with the same name and artist are duplicates by default.
+	SameAlbum bool
+	// CompareDuration also requires durations to differ by DurationTolerance at most.
+	CompareDuration   bool
+	DurationTolerance time.Duration
+}
+
+type Group struct {
+	// Tracks are ranked by Better, the first track is the one to keep.
+	Tracks []Track
+}
+
+func (g *Group) Keep() Track {
+	return g.Tracks[0]
+}
+
+// Rest returns the tracks to remove.
+func (g *Group) Rest() []Track {
+	return g.Tracks[1:]
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func (c Criteria) key(t Track) string {
+	if c.SameFile {
+		if t.Location() == "" {
+			return ""
+		}
+		return itunes.NormalizePath(t.Location(), c.FoldCase)
+	}
+
+	key := normalize(t.Name()) + "\x00" + normalize(t.Artist())
+	if c.SameAlbum {
+		key += "\x00" + normalize(t.Album())
+	}
+
+	return key
+}
+
+// Better reports whether a should be kept rather than b:
+// higher bit rate first, then more plays, then added earlier.
+func Better(a, b Track) bool {
+	if a.BitRate() != b.BitRate() {
+		return a.BitRate() > b.BitRate()
+	}
+
+	if a.PlayedCount() != b.PlayedCount() {
+		return a.PlayedCount() > b.PlayedCount()
+	}
+
+	return a.DateAdded().Before(b.DateAdded())
+}
+
+func newGroup(tracks []Track) *Group {
+	g := &Group{Tracks: append([]Track{}, tracks...)}
+	sort.SliceStable(g.Tracks, func(i, j int) bool {
+		return Better(g.Tracks[i], g.Tracks[j])
+	})
+
+	return g
+}
+
+// splitByDuration splits tracks into runs whose neighbouring durations differ by tolerance at most.
+func splitByDuration(tracks []Track, tolerance time.Duration) [][]Track {
+	sorted := append([]Track{}, tracks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration() < sorted[j].Duration()
+	})
+
+	runs := make([][]Track, 0, 1)
+	start := 0
+	for i := 1; i <= len(sorted); i++ {
+		if i == len(sorted) || sorted[i].Duration()-sorted[i-1].Duration() > tolerance {
+			runs = append(runs, sorted[start:i])
+			start = i
+		}
+	}
+
+	return runs
+}
+
+// Find groups the duplicate tracks, in the order their first track appears in tracks.
+func Find(tracks []Track, c Criteria) []*Group {
+	keys := make([]string, 0, len(tracks))
+	buckets := map[string][]Track{}
+	for _, t := range tracks {
+		key := c.key(t)
+		if key == "" {
+			continue
+		}
+
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], t)
+	}
+
+	groups := make([]*Group, 0)
+	for _, key := range keys {
+		bucket := buckets[key]
+		if len(bucket) < 2 {
+			continue
+		}
+
+		if c.SameFile || !c.CompareDuration {
+			groups = append(groups, newGroup(bucket))
+			continue
+		}
+
+		for _, run := range splitByDuration(bucket, c.DurationTolerance) {
+			if len(run) >= 2 {
+				groups = append(groups, newGroup(run))
+			}
+		}
+	}
+
+	return groups
+}
+
+type ResolveOptions struct {
+	// MergePlayCounts adds the play counts of the other tracks to the kept track.
+	MergePlayCounts bool
+	// Delete removes the other tracks from the library.
+	Delete bool
+}
+
+func (g *Group) Resolve(opts ResolveOptions) error {
+	if opts.MergePlayCounts {
+		count := 0
+		for _, t := range g.Tracks {
+			count += t.PlayedCount()
+		}
+
+		err := g.Keep().SetPlayedCount(count)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Delete {
+		for _, t := range g.Rest() {
+			err := t.Delete()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
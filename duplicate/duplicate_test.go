@@ -0,0 +1,114 @@
+package duplicate
+
+import (
+	"testing"
+	"time"
+)
+
+type track struct {
+	id, name, artist, album, location string
+	duration                          time.Duration
+	bitRate, playedCount              int
+	dateAdded                         time.Time
+	deleted                           bool
+}
+
+func (t *track) PersistentID() string           { return t.id }
+func (t *track) Name() string                   { return t.name }
+func (t *track) Artist() string                 { return t.artist }
+func (t *track) Album() string                  { return t.album }
+func (t *track) Duration() time.Duration        { return t.duration }
+func (t *track) Location() string               { return t.location }
+func (t *track) BitRate() int                   { return t.bitRate }
+func (t *track) PlayedCount() int               { return t.playedCount }
+func (t *track) DateAdded() time.Time           { return t.dateAdded }
+func (t *track) SetPlayedCount(count int) error { t.playedCount = count; return nil }
+func (t *track) Delete() error                  { t.deleted = true; return nil }
+
+func ids(tracks []Track) []string {
+	result := make([]string, len(tracks))
+	for i, t := range tracks {
+		result[i] = t.PersistentID()
+	}
+
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func library() []Track {
+	day := func(d int) time.Time {
+		return time.Date(2017, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	return []Track{
+		&track{id: "1", name: "Song", artist: "Artist", album: "A", duration: 200 * time.Second, bitRate: 128, playedCount: 3, dateAdded: day(1), location: "/m/1.mp3"},
+		&track{id: "2", name: "song ", artist: "ARTIST", album: "B", duration: 201 * time.Second, bitRate: 256, playedCount: 1, dateAdded: day(2), location: "/m/2.m4a"},
+		&track{id: "3", name: "Song", artist: "Artist", album: "A", duration: 300 * time.Second, bitRate: 256, playedCount: 5, dateAdded: day(3), location: "/M/1.mp3"},
+		&track{id: "4", name: "Other", artist: "Artist", album: "A", duration: 200 * time.Second, bitRate: 128, dateAdded: day(4), location: "/m/4.mp3"},
+		&track{id: "5", name: "Another", artist: "Artist", album: "A", duration: 200 * time.Second, bitRate: 96, dateAdded: day(5), location: "/m/a/../4.mp3"},
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		criteria Criteria
+		expect   [][]string
+	}{
+		{Criteria{}, [][]string{{"3", "2", "1"}}},
+		{Criteria{SameAlbum: true}, [][]string{{"3", "1"}}},
+		{Criteria{CompareDuration: true, DurationTolerance: 2 * time.Second}, [][]string{{"2", "1"}}},
+		{Criteria{SameAlbum: true, CompareDuration: true, DurationTolerance: 2 * time.Second}, [][]string{}},
+		// 1 and 3 differ in case only, they are the same file on case insensitive file systems.
+		{Criteria{SameFile: true}, [][]string{{"4", "5"}}},
+		{Criteria{SameFile: true, FoldCase: true}, [][]string{{"3", "1"}, {"4", "5"}}},
+	}
+
+	for _, test := range tests {
+		groups := Find(library(), test.criteria)
+		if len(groups) != len(test.expect) {
+			t.Errorf("%+v: expect %v groups, but %v", test.criteria, len(test.expect), len(groups))
+			continue
+		}
+
+		for i, g := range groups {
+			if !equal(ids(g.Tracks), test.expect[i]) {
+				t.Errorf("%+v: expect %v, but %v", test.criteria, test.expect[i], ids(g.Tracks))
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tracks := library()
+	groups := Find(tracks, Criteria{SameAlbum: true})
+	if len(groups) != 1 {
+		t.Fatalf("expect 1 group, but %v", len(groups))
+	}
+
+	err := groups[0].Resolve(ResolveOptions{MergePlayCounts: true, Delete: true})
+	if err != nil {
+		t.Fatalf("Resolve failed.\n%v", err)
+	}
+
+	kept, removed := tracks[2].(*track), tracks[0].(*track)
+	if kept.playedCount != 8 {
+		t.Errorf("expect merged play count 8, but %v", kept.playedCount)
+	}
+
+	if kept.deleted || !removed.deleted {
+		t.Errorf("expect only track 1 to be deleted")
+	}
+}
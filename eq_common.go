@@ -0,0 +1,63 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+)
+
+const EQBandCount = 10
+
+// The band values and the preamp are in dB, iTunes accepts them from EQMinValue to EQMaxValue.
+const (
+	EQMinValue = -12.0
+	EQMaxValue = 12.0
+)
+
+func (e *EQPreset) Name() string {
+	return e.name
+}
+
+func (e *EQPreset) Modifiable() bool {
+	return e.modifiable
+}
+
+// validateEQBand checks that band is 1-origin like the band properties of iTunes.
+func validateEQBand(band int) error {
+	if band < 1 || EQBandCount < band {
+		return &OutOfRangeError{Name: "band"}
+	}
+
+	return nil
+}
+
+// validateEQValue checks a band value or the preamp, iTunes would clamp it without an error.
+func validateEQValue(name string, v float64) error {
+	if !(EQMinValue <= v && v <= EQMaxValue) {
+		return &OutOfRangeError{Name: name}
+	}
+
+	return nil
+}
+
+func (e *EQPreset) SetBands(values []float64) error {
+	if len(values) != EQBandCount {
+		return errors.New(fmt.Sprintf("expect %v bands, but %v", EQBandCount, len(values)))
+	}
+
+	// all the values are checked first, so that the preset is not left half set.
+	for i, v := range values {
+		err := validateEQValue(fmt.Sprintf("band%d", i+1), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, v := range values {
+		err := e.SetBand(i+1, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package itunes
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestValidateEQBand(t *testing.T) {
+	for _, band := range []int{1, EQBandCount} {
+		err := validateEQBand(band)
+		if err != nil {
+			t.Errorf("%v: %v", band, err)
+		}
+	}
+
+	for _, band := range []int{0, EQBandCount + 1} {
+		var outOfRange *OutOfRangeError
+		err := validateEQBand(band)
+		if !errors.As(err, &outOfRange) {
+			t.Errorf("%v: expect out of range, but %v", band, err)
+		}
+	}
+}
+
+func TestValidateEQValue(t *testing.T) {
+	for _, v := range []float64{EQMinValue, 0, 3.5, EQMaxValue} {
+		err := validateEQValue("preamp", v)
+		if err != nil {
+			t.Errorf("%v: %v", v, err)
+		}
+	}
+
+	for _, v := range []float64{EQMinValue - 0.1, EQMaxValue + 0.1, math.NaN(), math.Inf(1)} {
+		var outOfRange *OutOfRangeError
+		err := validateEQValue("preamp", v)
+		if !errors.As(err, &outOfRange) || outOfRange.Name != "preamp" {
+			t.Errorf("%v: expect preamp to be out of range, but %v", v, err)
+		}
+	}
+}
+
+func TestSetBandsOutOfRange(t *testing.T) {
+	values := make([]float64, EQBandCount)
+	values[3] = 20
+
+	// the values are checked before any band is set, so the backend is not reached.
+	var outOfRange *OutOfRangeError
+	err := (&EQPreset{modifiable: true}).SetBands(values)
+	if !errors.As(err, &outOfRange) || outOfRange.Name != "band4" {
+		t.Errorf("expect band4 to be out of range, but %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type EQPreset struct {
+	name       string
+	modifiable bool
+}
+
+func createEQPreset(values []string) (*EQPreset, error) {
+	if len(values) < 2 {
+		return nil, errors.New("values is empty.")
+	}
+
+	e := &EQPreset{
+		name:       values[0],
+		modifiable: values[1] == "true",
+	}
+
+	return e, nil
+}
+
+// for compatibility
+func (_ *EQPreset) Close() {
+}
+
+func (_ *Itunes) EQPresets() ([]*EQPreset, error) {
+	o, err := execJS(`app.eqPresets().forEach(logEQPreset);`)
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make([]*EQPreset, 0, 30)
+	for line := range o {
+		columns, err := validateResult(line)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := createEQPreset(columns)
+		if err != nil {
+			return nil, err
+		}
+
+		presets = append(presets, e)
+	}
+
+	return presets, nil
+}
+
+func (_ *Itunes) CurrentEQPreset() (*EQPreset, error) {
+	columns, err := getColumnsByJS(`logEQPreset(app.currentEQPreset());`)
+	if err != nil {
+		return nil, err
+	}
+
+	return createEQPreset(columns)
+}
+
+func (_ *Itunes) SetCurrentEQPreset(e *EQPreset) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`app.currentEQPreset = app.eqPresets.byName(%s);`, jsString(e.name)))
+	return err
+}
+
+func (_ *Itunes) CreateEQPreset(name string) (*EQPreset, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`logEQPreset(app.EQPreset({name: %s}).make());`, jsString(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	return createEQPreset(columns)
+}
+
+func (_ *Itunes) EQEnabled() (bool, error) {
+	v, err := getProperty("eqEnabled")
+	if err != nil {
+		return false, err
+	}
+
+	return v == "true", nil
+}
+
+func (_ *Itunes) SetEQEnabled(isEnabled bool) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`app.eqEnabled = %v;`, isEnabled))
+	return err
+}
+
+func (e *EQPreset) getFloat(property string) (float64, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`p(app.eqPresets.byName(%s).%v());`, jsString(e.name), property))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(columns) == 0 {
+		return 0, errors.New(fmt.Sprintf("%v is nil.", property))
+	}
+
+	return strconv.ParseFloat(columns[0], 64)
+}
+
+func (e *EQPreset) putFloat(property string, v float64) error {
+	if !e.modifiable {
+		return errors.New(fmt.Sprintf("EQ preset is not modifiable:%v", e.name))
+	}
+
+	_, err := getColumnsByJS(fmt.Sprintf(`app.eqPresets.byName(%s).%v = %v;`, jsString(e.name), property, v))
+	return err
+}
+
+func (e *EQPreset) Preamp() (float64, error) {
+	return e.getFloat("preamp")
+}
+
+func (e *EQPreset) SetPreamp(v float64) error {
+	err := validateEQValue("preamp", v)
+	if err != nil {
+		return err
+	}
+
+	return e.putFloat("preamp", v)
+}
+
+func (e *EQPreset) Band(band int) (float64, error) {
+	err := validateEQBand(band)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.getFloat(fmt.Sprintf("band%d", band))
+}
+
+func (e *EQPreset) SetBand(band int, v float64) error {
+	err := validateEQBand(band)
+	if err != nil {
+		return err
+	}
+
+	err = validateEQValue(fmt.Sprintf("band%d", band), v)
+	if err != nil {
+		return err
+	}
+
+	return e.putFloat(fmt.Sprintf("band%d", band), v)
+}
+
+func (e *EQPreset) Bands() ([]float64, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`var e = app.eqPresets.byName(%s);
+p(e.band1(), e.band2(), e.band3(), e.band4(), e.band5(), e.band6(), e.band7(), e.band8(), e.band9(), e.band10());`, jsString(e.name)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) != EQBandCount {
+		return nil, errors.New("Bands is nil.")
+	}
+
+	bands := make([]float64, EQBandCount)
+	for i, column := range columns {
+		bands[i], err = strconv.ParseFloat(column, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bands, nil
+}
+
+func (e *EQPreset) Delete() error {
+	if !e.modifiable {
+		return errors.New(fmt.Sprintf("EQ preset is not modifiable:%v", e.name))
+	}
+
+	_, err := getColumnsByJS(fmt.Sprintf(`app.eqPresets.byName(%s).delete();`, jsString(e.name)))
+	return err
+}
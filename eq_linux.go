@@ -0,0 +1,58 @@
+package itunes
+
+type EQPreset struct {
+	name       string
+	modifiable bool
+}
+
+// for compatibility
+func (_ *EQPreset) Close() {
+}
+
+func (_ *Itunes) EQPresets() ([]*EQPreset, error) {
+	return nil, &NotSupportedError{Feature: "EQPresets", Platform: "Linux"}
+}
+
+func (_ *Itunes) CurrentEQPreset() (*EQPreset, error) {
+	return nil, &NotSupportedError{Feature: "CurrentEQPreset", Platform: "Linux"}
+}
+
+func (_ *Itunes) SetCurrentEQPreset(e *EQPreset) error {
+	return &NotSupportedError{Feature: "SetCurrentEQPreset", Platform: "Linux"}
+}
+
+func (_ *Itunes) CreateEQPreset(name string) (*EQPreset, error) {
+	return nil, &NotSupportedError{Feature: "CreateEQPreset", Platform: "Linux"}
+}
+
+func (_ *Itunes) EQEnabled() (bool, error) {
+	return false, &NotSupportedError{Feature: "EQEnabled", Platform: "Linux"}
+}
+
+func (_ *Itunes) SetEQEnabled(isEnabled bool) error {
+	return &NotSupportedError{Feature: "SetEQEnabled", Platform: "Linux"}
+}
+
+func (_ *EQPreset) Preamp() (float64, error) {
+	return 0, &NotSupportedError{Feature: "Preamp", Platform: "Linux"}
+}
+
+func (_ *EQPreset) SetPreamp(v float64) error {
+	return &NotSupportedError{Feature: "SetPreamp", Platform: "Linux"}
+}
+
+func (_ *EQPreset) Band(band int) (float64, error) {
+	return 0, &NotSupportedError{Feature: "Band", Platform: "Linux"}
+}
+
+func (_ *EQPreset) SetBand(band int, v float64) error {
+	return &NotSupportedError{Feature: "SetBand", Platform: "Linux"}
+}
+
+func (_ *EQPreset) Bands() ([]float64, error) {
+	return nil, &NotSupportedError{Feature: "Bands", Platform: "Linux"}
+}
+
+func (_ *EQPreset) Delete() error {
+	return &NotSupportedError{Feature: "EQPreset.Delete", Platform: "Linux"}
+}
@@ -0,0 +1,185 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yaegaki/go-ole-handler"
+)
+
+type EQPreset struct {
+	handler *olehandler.OleHandler
+
+	name       string
+	modifiable bool
+}
+
+func createEQPreset(handler *olehandler.OleHandler) (*EQPreset, error) {
+	name, err := handler.GetStringProperty("Name")
+	if err != nil {
+		return nil, err
+	}
+
+	modifiable, err := handler.GetBoolProperty("Modifiable")
+	if err != nil {
+		return nil, err
+	}
+
+	e := &EQPreset{
+		handler: handler,
+
+		name:       name,
+		modifiable: modifiable,
+	}
+
+	return e, nil
+}
+
+func (e *EQPreset) Close() {
+	e.handler.Close()
+}
+
+func (it *Itunes) EQPresets() ([]*EQPreset, error) {
+	collection, err := it.handler.GetOleHandler("EQPresets")
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Close()
+
+	count, err := collection.GetIntProperty("Count")
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make([]*EQPreset, 0, count)
+	for i := 1; i <= count; i++ {
+		var e *EQPreset
+		err = collection.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+			e, err = createEQPreset(handler)
+			return err
+		}, i)
+
+		if err != nil {
+			for _, e := range presets {
+				e.Close()
+			}
+			return nil, err
+		}
+
+		presets = append(presets, e)
+	}
+
+	return presets, nil
+}
+
+func (it *Itunes) CurrentEQPreset() (e *EQPreset, err error) {
+	err = it.handler.GetOleHandlerWithCallback("CurrentEQPreset", func(handler *olehandler.OleHandler) error {
+		e, err = createEQPreset(handler)
+		return err
+	})
+
+	return e, err
+}
+
+func (it *Itunes) SetCurrentEQPreset(e *EQPreset) error {
+	return it.handler.PutProperty("CurrentEQPreset", e.handler.Handle)
+}
+
+func (it *Itunes) CreateEQPreset(name string) (e *EQPreset, err error) {
+	err = it.handler.GetOleHandlerWithCallbackAndArgsByMethod("CreateEQPreset", func(handler *olehandler.OleHandler) error {
+		e, err = createEQPreset(handler)
+		return err
+	}, name)
+
+	return e, err
+}
+
+func (it *Itunes) EQEnabled() (bool, error) {
+	return it.handler.GetBoolProperty("EQEnabled")
+}
+
+func (it *Itunes) SetEQEnabled(isEnabled bool) error {
+	return it.handler.PutProperty("EQEnabled", isEnabled)
+}
+
+func (e *EQPreset) getFloat(property string) (float64, error) {
+	v, err := e.handler.GetProperty(property)
+	if err != nil {
+		return 0, err
+	}
+
+	switch f := v.Value().(type) {
+	case float64:
+		return f, nil
+	case float32:
+		return float64(f), nil
+	}
+
+	return 0, errors.New(fmt.Sprintf("%v is not a number.", property))
+}
+
+func (e *EQPreset) putFloat(property string, v float64) error {
+	if !e.modifiable {
+		return errors.New(fmt.Sprintf("EQ preset is not modifiable:%v", e.name))
+	}
+
+	return e.handler.PutProperty(property, v)
+}
+
+func (e *EQPreset) Preamp() (float64, error) {
+	return e.getFloat("Preamp")
+}
+
+func (e *EQPreset) SetPreamp(v float64) error {
+	err := validateEQValue("preamp", v)
+	if err != nil {
+		return err
+	}
+
+	return e.putFloat("Preamp", v)
+}
+
+func (e *EQPreset) Band(band int) (float64, error) {
+	err := validateEQBand(band)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.getFloat(fmt.Sprintf("Band%d", band))
+}
+
+func (e *EQPreset) SetBand(band int, v float64) error {
+	err := validateEQBand(band)
+	if err != nil {
+		return err
+	}
+
+	err = validateEQValue(fmt.Sprintf("band%d", band), v)
+	if err != nil {
+		return err
+	}
+
+	return e.putFloat(fmt.Sprintf("Band%d", band), v)
+}
+
+func (e *EQPreset) Bands() ([]float64, error) {
+	bands := make([]float64, EQBandCount)
+	for i := range bands {
+		v, err := e.Band(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		bands[i] = v
+	}
+
+	return bands, nil
+}
+
+func (e *EQPreset) Delete() error {
+	if !e.modifiable {
+		return errors.New(fmt.Sprintf("EQ preset is not modifiable:%v", e.name))
+	}
+
+	// false: tracks using this preset are not updated.
+	return e.handler.CallMethod("Delete", false)
+}
@@ -0,0 +1,39 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoCurrentTrack is returned by CurrentTrack when the player has no track.
+var ErrNoCurrentTrack = errors.New("no current track")
+
+// NotFoundError is returned when a track, playlist or artwork does not exist.
+type NotFoundError struct {
+	// Kind is "track", "playlist" or "artwork".
+	Kind string
+	ID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("not found %v:%v", e.Kind, e.ID)
+}
+
+// NotSupportedError is returned for a feature the platform does not provide.
+type NotSupportedError struct {
+	Feature  string
+	Platform string
+}
+
+func (e *NotSupportedError) Error() string {
+	return fmt.Sprintf("%v is not support on %v.", e.Feature, e.Platform)
+}
+
+// OutOfRangeError is returned when a value such as the volume is out of range.
+type OutOfRangeError struct {
+	Name string
+}
+
+func (e *OutOfRangeError) Error() string {
+	return fmt.Sprintf("%v is out of range", e.Name)
+}
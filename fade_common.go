@@ -0,0 +1,175 @@
+package itunes
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type FadeCurve int
+
+const (
+	Linear FadeCurve = iota
+	Logarithmic
+)
+
+func (c FadeCurve) String() string {
+	switch c {
+	case Linear:
+		return "Linear"
+	case Logarithmic:
+		return "Logarithmic"
+	}
+
+	return ""
+}
+
+// apply maps the elapsed fraction of a fade to the fraction of the volume change.
+// A logarithmic fade-out mirrors the fade-in, so it sounds like the fade-in played backwards.
+func (c FadeCurve) apply(x float64, fadeOut bool) float64 {
+	switch c {
+	case Logarithmic:
+		if fadeOut {
+			return 1 - math.Log10(1+9*(1-x))
+		}
+		return math.Log10(1 + 9*x)
+	}
+
+	return x
+}
+
+// a fade never needs more than one step per volume unit.
+const maxFadeSteps = 100
+
+// FadeVolume changes the sound volume to target over duration.
+// Steps are scheduled by elapsed time, so a slow backend (each osascript call takes tens of milliseconds)
+// makes fewer and larger steps instead of making the fade longer.
+func (it *Itunes) FadeVolume(ctx context.Context, target int, duration time.Duration, curve FadeCurve) error {
+	if target < 0 || 100 < target {
+		return &OutOfRangeError{Name: "volume"}
+	}
+
+	from, err := it.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	return fade(ctx, realClock{}, from, target, duration, curve, it.SetSoundVolume)
+}
+
+// fadeClock is the time of a fade, the tests step a fake one.
+type fadeClock interface {
+	now() time.Time
+	// sleep waits for d, it fails if ctx is done first.
+	sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) now() time.Time {
+	return time.Now()
+}
+
+func (realClock) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fade changes the volume from from to target with setVolume, which is called only when the volume changes.
+func fade(ctx context.Context, c fadeClock, from, target int, duration time.Duration, curve FadeCurve, setVolume func(int) error) error {
+	fadeOut := target < from
+	interval := duration / maxFadeSteps
+	current := from
+	start := c.now()
+	for {
+		elapsed := c.now().Sub(start)
+		if elapsed >= duration {
+			break
+		}
+
+		v := from + int(math.Round(float64(target-from)*curve.apply(float64(elapsed)/float64(duration), fadeOut)))
+		if v != current {
+			err := setVolume(v)
+			if err != nil {
+				return err
+			}
+			current = v
+		}
+
+		wait := interval - (c.now().Sub(start) - elapsed)
+		if wait <= 0 {
+			// the call took longer than a step, check the context and go on with a larger step.
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := c.sleep(ctx, wait)
+		if err != nil {
+			return err
+		}
+	}
+
+	if current == target {
+		return nil
+	}
+
+	return setVolume(target)
+}
+
+// PlayWithFadeIn starts playing from silence and fades the volume in to the current sound volume.
+func (it *Itunes) PlayWithFadeIn(ctx context.Context, duration time.Duration, curve FadeCurve) error {
+	volume, err := it.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	err = it.SetSoundVolume(0)
+	if err != nil {
+		return err
+	}
+
+	err = it.Play()
+	if err != nil {
+		it.SetSoundVolume(volume)
+		return err
+	}
+
+	err = it.FadeVolume(ctx, volume, duration, curve)
+	if err != nil {
+		it.SetSoundVolume(volume)
+		return err
+	}
+
+	return nil
+}
+
+// PauseWithFadeOut fades the volume out, pauses and then restores the sound volume.
+func (it *Itunes) PauseWithFadeOut(ctx context.Context, duration time.Duration, curve FadeCurve) error {
+	volume, err := it.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	err = it.FadeVolume(ctx, 0, duration, curve)
+	if err != nil {
+		it.SetSoundVolume(volume)
+		return err
+	}
+
+	err = it.Pause()
+	if err != nil {
+		it.SetSoundVolume(volume)
+		return err
+	}
+
+	return it.SetSoundVolume(volume)
+}
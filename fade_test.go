@@ -0,0 +1,144 @@
+package itunes
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFadeCurve(t *testing.T) {
+	for _, fadeOut := range []bool{false, true} {
+		for _, c := range []FadeCurve{Linear, Logarithmic} {
+			if v := c.apply(0, fadeOut); v != 0 {
+				t.Errorf("expect %v fade-out:%v to start at 0, but %v", c, fadeOut, v)
+			}
+			if v := c.apply(1, fadeOut); math.Abs(v-1) > 1e-9 {
+				t.Errorf("expect %v fade-out:%v to end at 1, but %v", c, fadeOut, v)
+			}
+
+			prev := 0.0
+			for i := 1; i <= 10; i++ {
+				v := c.apply(float64(i)/10, fadeOut)
+				if v < prev {
+					t.Errorf("expect %v fade-out:%v to be monotonic at %v", c, fadeOut, float64(i)/10)
+				}
+				prev = v
+			}
+		}
+	}
+
+	if v := Linear.apply(0.25, true); v != 0.25 {
+		t.Errorf("unexpected linear fade-out: %v", v)
+	}
+
+	// the fade-in rises quickly and the fade-out, its mirror, falls slowly at first.
+	if v := Logarithmic.apply(0.5, false); v <= 0.5 {
+		t.Errorf("unexpected logarithmic fade-in: %v", v)
+	}
+	if v := Logarithmic.apply(0.5, true); v >= 0.5 {
+		t.Errorf("unexpected logarithmic fade-out: %v", v)
+	}
+	for _, x := range []float64{0.1, 0.3, 0.7, 0.9} {
+		in := Logarithmic.apply(1-x, false)
+		out := Logarithmic.apply(x, true)
+		if math.Abs(out-(1-in)) > 1e-9 {
+			t.Errorf("expect the fade-out to mirror the fade-in at %v: %v %v", x, out, in)
+		}
+	}
+}
+
+// fakeClock moves only when the fade sleeps or the backend takes time.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	c.t = c.t.Add(d)
+	return nil
+}
+
+type volumeStep struct {
+	volume int
+	at     time.Duration
+}
+
+// recordFade fades with a backend taking delay to set the volume.
+func recordFade(t *testing.T, from, target int, duration time.Duration, curve FadeCurve, delay time.Duration) []volumeStep {
+	c := &fakeClock{t: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := c.t
+	steps := []volumeStep{}
+	err := fade(context.Background(), c, from, target, duration, curve, func(v int) error {
+		steps = append(steps, volumeStep{volume: v, at: c.t.Sub(start)})
+		c.t = c.t.Add(delay)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return steps
+}
+
+func TestFadeSteps(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		from, target int
+		curve        FadeCurve
+		expect       []volumeStep
+	}{
+		{4, 0, Linear, []volumeStep{{3, 13 * ms}, {2, 38 * ms}, {1, 63 * ms}, {0, 88 * ms}}},
+		// the fade-in rises quickly and the fade-out, its mirror, falls slowly at first.
+		{0, 4, Logarithmic, []volumeStep{{1, 4 * ms}, {2, 16 * ms}, {3, 36 * ms}, {4, 73 * ms}}},
+		{4, 0, Logarithmic, []volumeStep{{3, 28 * ms}, {2, 65 * ms}, {1, 85 * ms}, {0, 97 * ms}}},
+	}
+
+	for _, test := range tests {
+		steps := recordFade(t, test.from, test.target, 100*ms, test.curve, 0)
+		if !reflect.DeepEqual(steps, test.expect) {
+			t.Errorf("%v from %v to %v: expect %v, but %v", test.curve, test.from, test.target, test.expect, steps)
+		}
+	}
+}
+
+func TestFadeSlowBackend(t *testing.T) {
+	ms := time.Millisecond
+	steps := recordFade(t, 0, 100, 100*ms, Linear, 25*ms)
+
+	// a slow backend makes larger steps instead of making the fade longer, the target is set once the time is up.
+	expect := []volumeStep{{1, 1 * ms}, {26, 26 * ms}, {51, 51 * ms}, {76, 76 * ms}, {100, 101 * ms}}
+	if !reflect.DeepEqual(steps, expect) {
+		t.Errorf("expect %v, but %v", expect, steps)
+	}
+}
+
+func TestFadeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeClock{}
+	volumes := []int{}
+	err := fade(ctx, c, 100, 0, time.Second, Linear, func(v int) error {
+		volumes = append(volumes, v)
+		if v == 50 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("expect the fade to be canceled, but %v", err)
+	}
+	if len(volumes) != 50 || volumes[len(volumes)-1] != 50 {
+		t.Errorf("expect the fade to stop at 50, but %v", volumes)
+	}
+}
@@ -0,0 +1,139 @@
+package itunes
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var mediaExtensions = map[string]bool{
+	".aac":  true,
+	".aif":  true,
+	".aifc": true,
+	".aiff": true,
+	".m4a":  true,
+	".m4b":  true,
+	".m4p":  true,
+	".m4v":  true,
+	".mov":  true,
+	".mp3":  true,
+	".mp4":  true,
+	".wav":  true,
+}
+
+// IsMediaFile reports whether iTunes can import the file judging from its extension.
+func IsMediaFile(path string) bool {
+	return mediaExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// NormalizePath cleans path and folds its case if foldCase is true, which it is on case insensitive file systems only,
+// two files of a case sensitive one may differ in case alone.
+func NormalizePath(path string, foldCase bool) string {
+	path = filepath.Clean(path)
+	if foldCase {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+func flipCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// CaseInsensitive reports whether the file system of dir ignores the case of names
+// by looking dir up with the case of its name flipped.
+func CaseInsensitive(dir string) bool {
+	name := filepath.Base(dir)
+	flipped := flipCase(name)
+	if flipped == name {
+		// the file systems of OSX and Windows are case insensitive by default.
+		return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+
+	other, err := os.Stat(filepath.Join(filepath.Dir(dir), flipped))
+	return err == nil && os.SameFile(info, other)
+}
+
+// MediaFiles expands folders in paths to the media files under them.
+func MediaFiles(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		found := make([]string, 0, 100)
+		err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if strings.HasPrefix(info.Name(), ".") && p != path {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !info.IsDir() && IsMediaFile(p) {
+				found = append(found, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+
+	return files, nil
+}
+
+// AddProgress is called after each file is added by AddFiles.
+type AddProgress func(done, total int, path string)
+
+// AddFiles adds files and the media files under folders to the library.
+// On error, the tracks added so far are returned with the error.
+func (it *Itunes) AddFiles(paths []string, progress AddProgress) ([]*Track, error) {
+	files, err := MediaFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]*Track, 0, len(files))
+	for i, file := range files {
+		t, err := it.AddFile(file)
+		if err != nil {
+			return tracks, err
+		}
+
+		tracks = append(tracks, t)
+		if progress != nil {
+			progress(i+1, len(files), file)
+		}
+	}
+
+	return tracks, nil
+}
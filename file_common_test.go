@@ -0,0 +1,53 @@
+package itunes
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	path := filepath.Join("Music", "A", "..", "B", "Song.mp3")
+
+	if p := NormalizePath(path, false); p != filepath.Join("Music", "B", "Song.mp3") {
+		t.Errorf("unexpected path: %v", p)
+	}
+	if p := NormalizePath(path, true); p != filepath.Join("music", "b", "song.mp3") {
+		t.Errorf("unexpected folded path: %v", p)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Music")
+	err := os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file system is probed the other way round, a folder differing in case only tells it is case sensitive.
+	other := filepath.Join(filepath.Dir(dir), "mUSIC")
+	err = os.Mkdir(other, 0755)
+	sensitive := err == nil
+	if err != nil && !os.IsExist(err) {
+		t.Fatal(err)
+	}
+
+	if CaseInsensitive(dir) == sensitive {
+		t.Errorf("expect case insensitive %v", !sensitive)
+	}
+
+	if CaseInsensitive(filepath.Join(filepath.Dir(dir), "missing")) {
+		t.Error("expect a missing folder to be case sensitive")
+	}
+
+	// a name without letters falls back to the default of the platform.
+	digits := filepath.Join(filepath.Dir(dir), "2024")
+	err = os.Mkdir(digits, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CaseInsensitive(digits) != (runtime.GOOS == "darwin" || runtime.GOOS == "windows") {
+		t.Error("unexpected default")
+	}
+}
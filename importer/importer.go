@@ -0,0 +1,316 @@
+// Package importer adds the audio files under a folder to the iTunes library,
+// skipping the files that are already in the library and reporting those iTunes cannot import.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/tag"
+)
+
+type Status int
+
+const (
+	// New is a file that is not in the library. it is not added in a dry run.
+	New Status = iota
+	Added
+	Duplicate
+	Failed
+	// Unsupported is an audio file iTunes cannot import, like FLAC. it is never added.
+	Unsupported
+)
+
+func (s Status) String() string {
+	switch s {
+	case New:
+		return "New"
+	case Added:
+		return "Added"
+	case Duplicate:
+		return "Duplicate"
+	case Failed:
+		return "Failed"
+	case Unsupported:
+		return "Unsupported"
+	}
+
+	return ""
+}
+
+const DefaultDurationTolerance = 2 * time.Second
+
+type Options struct {
+	// DryRun reports what would be imported without adding anything.
+	DryRun bool
+	// tracks whose durations differ by more than DurationTolerance are not duplicates.
+	// DefaultDurationTolerance is used if zero.
+	DurationTolerance time.Duration
+	Progress          itunes.AddProgress
+}
+
+type Entry struct {
+	Path   string
+	Status Status
+
+	// Tag is nil if the file has no tag this package can read.
+	Tag *tag.Tag
+	// DuplicateOf is the persistent ID of the library track, or the path of the file imported earlier.
+	DuplicateOf string
+	Err         error
+}
+
+type Report struct {
+	DryRun  bool
+	Entries []*Entry
+}
+
+func (r *Report) Count(s Status) int {
+	count := 0
+	for _, e := range r.Entries {
+		if e.Status == s {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+
+	for _, e := range r.Entries {
+		var err error
+		switch e.Status {
+		case Duplicate:
+			err = write("%v\t%v\t%v\n", e.Status, e.Path, e.DuplicateOf)
+		case Failed:
+			err = write("%v\t%v\t%v\n", e.Status, e.Path, e.Err)
+		default:
+			err = write("%v\t%v\n", e.Status, e.Path)
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	err := write("new:%d added:%d duplicate:%d failed:%d unsupported:%d dry-run:%v\n",
+		r.Count(New), r.Count(Added), r.Count(Duplicate), r.Count(Failed), r.Count(Unsupported), r.DryRun)
+	return written, err
+}
+
+// Key identifies a song regardless of the case and spacing of its metadata.
+type Key struct {
+	Artist string
+	Album  string
+	Name   string
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func NewKey(artist, album, name string) Key {
+	return Key{
+		Artist: normalize(artist),
+		Album:  normalize(album),
+		Name:   normalize(name),
+	}
+}
+
+type candidate struct {
+	id       string
+	duration time.Duration
+}
+
+// Index finds songs by their metadata and duration.
+type Index struct {
+	tolerance time.Duration
+	songs     map[Key][]candidate
+}
+
+func NewIndex(tolerance time.Duration) *Index {
+	return &Index{
+		tolerance: tolerance,
+		songs:     map[Key][]candidate{},
+	}
+}
+
+func (idx *Index) Add(key Key, duration time.Duration, id string) {
+	idx.songs[key] = append(idx.songs[key], candidate{id: id, duration: duration})
+}
+
+// Find returns the id of the song with the same key and duration.
+// unknown durations (zero) match any duration.
+func (idx *Index) Find(key Key, duration time.Duration) (string, bool) {
+	for _, c := range idx.songs[key] {
+		diff := c.duration - duration
+		if diff < 0 {
+			diff = -diff
+		}
+
+		if c.duration == 0 || duration == 0 || diff <= idx.tolerance {
+			return c.id, true
+		}
+	}
+
+	return "", false
+}
+
+// LibraryIndex indexes all tracks in the library by their persistent IDs.
+func LibraryIndex(it *itunes.Itunes, tolerance time.Duration) (*Index, error) {
+	output, err := it.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	idx := NewIndex(tolerance)
+	for t := range output {
+		idx.Add(NewKey(t.Artist(), t.Album(), t.Name()), t.Duration(), t.PersistentID())
+		t.Close()
+	}
+
+	return idx, nil
+}
+
+// unsupportedExtensions are the audio formats iTunes cannot import.
+var unsupportedExtensions = map[string]bool{
+	".ape":  true,
+	".flac": true,
+	".oga":  true,
+	".ogg":  true,
+	".opus": true,
+	".wv":   true,
+}
+
+func isAudioFile(path string) bool {
+	return itunes.IsMediaFile(path) || unsupportedExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Files lists the audio files under dir, with those iTunes cannot import, skipping hidden files like iTunes.
+func Files(dir string) ([]string, error) {
+	files := make([]string, 0, 100)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && p != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() && isAudioFile(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// songKey uses the file name as the name of a song without title like iTunes does.
+func songKey(path string, t *tag.Tag) (Key, time.Duration) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if t == nil {
+		return NewKey("", "", name), 0
+	}
+
+	if t.Title != "" {
+		name = t.Title
+	}
+
+	return NewKey(t.Artist, t.Album, name), t.Duration
+}
+
+func Import(it *itunes.Itunes, dir string, opts Options) (*Report, error) {
+	if opts.DurationTolerance == 0 {
+		opts.DurationTolerance = DefaultDurationTolerance
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := LibraryIndex(it, opts.DurationTolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	add := func(path string) (string, error) {
+		track, err := it.AddFile(path)
+		if err != nil {
+			return "", err
+		}
+		defer track.Close()
+
+		return track.PersistentID(), nil
+	}
+
+	return importFiles(files, idx, add, opts), nil
+}
+
+// importFiles adds the files which are not in idx with add, which returns the persistent ID of the new track.
+func importFiles(files []string, idx *Index, add func(path string) (string, error), opts Options) *Report {
+	report := &Report{
+		DryRun:  opts.DryRun,
+		Entries: make([]*Entry, 0, len(files)),
+	}
+
+	for i, path := range files {
+		e := &Entry{Path: path}
+		report.Entries = append(report.Entries, e)
+
+		t, err := tag.ReadFile(path)
+		if err == nil {
+			e.Tag = t
+		} else if err != tag.ErrUnsupported {
+			// the file may still be importable, iTunes decides.
+			e.Err = err
+		}
+
+		key, duration := songKey(path, e.Tag)
+		if !itunes.IsMediaFile(path) {
+			e.Status = Unsupported
+		} else if id, ok := idx.Find(key, duration); ok {
+			e.Status = Duplicate
+			e.DuplicateOf = id
+		} else if opts.DryRun {
+			e.Status = New
+			idx.Add(key, duration, path)
+		} else {
+			id, err := add(path)
+			if err != nil {
+				e.Status = Failed
+				e.Err = err
+			} else {
+				e.Status = Added
+				idx.Add(key, duration, id)
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(files), path)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,221 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeFiles creates files without a readable tag, their names are the names of the songs.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(path, []byte("not a tag"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// id3File returns an MP3 file made of an ID3v2.3 tag of the title, artist and album.
+func id3File(title, artist, album string) []byte {
+	var body []byte
+	for _, f := range [][2]string{{"TIT2", title}, {"TPE1", artist}, {"TALB", album}} {
+		frame := make([]byte, 10)
+		copy(frame, f[0])
+		binary.BigEndian.PutUint32(frame[4:], uint32(len(f[1])+1))
+		frame = append(frame, 3)
+		body = append(body, append(frame, f[1]...)...)
+	}
+
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+// flacFile returns a FLAC file made of a stream info block and a Vorbis comment of the title, artist and album.
+func flacFile(title, artist, album string) []byte {
+	vorbis := []byte{}
+	vorbis = binary.LittleEndian.AppendUint32(vorbis, 6)
+	vorbis = append(vorbis, "vendor"...)
+	comments := []string{"TITLE=" + title, "ARTIST=" + artist, "ALBUM=" + album}
+	vorbis = binary.LittleEndian.AppendUint32(vorbis, uint32(len(comments)))
+	for _, c := range comments {
+		vorbis = binary.LittleEndian.AppendUint32(vorbis, uint32(len(c)))
+		vorbis = append(vorbis, c...)
+	}
+
+	block := func(typ byte, b []byte) []byte {
+		return append([]byte{typ, byte(len(b) >> 16), byte(len(b) >> 8), byte(len(b))}, b...)
+	}
+
+	return bytes.Join([][]byte{[]byte("fLaC"), block(0, make([]byte, 34)), block(0x80|4, vorbis)}, nil)
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "b/Two.m4a", "a/One.mp3", "a/cover.jpg", "Three.flac", ".hidden/Four.mp3", "a/.Five.mp3")
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(dir, "Three.flac"), filepath.Join(dir, "a", "One.mp3"), filepath.Join(dir, "b", "Two.m4a")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func importTestFiles(t *testing.T, dryRun bool) (*Report, []string) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a/Song.mp3", "a/In Library.mp3", "b/song.mp3", "b/Other.mp3", "b/Broken.mp3")
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndex(DefaultDurationTolerance)
+	idx.Add(NewKey("", "", "in  library"), time.Minute, "L1")
+
+	added := []string{}
+	add := func(path string) (string, error) {
+		if filepath.Base(path) == "Broken.mp3" {
+			return "", errors.New("broken")
+		}
+		added = append(added, filepath.Base(path))
+		return fmt.Sprintf("N%d", len(added)), nil
+	}
+
+	return importFiles(files, idx, add, Options{DryRun: dryRun}), added
+}
+
+func statuses(r *Report) map[string]string {
+	result := map[string]string{}
+	for _, e := range r.Entries {
+		s := e.Status.String()
+		if e.DuplicateOf != "" {
+			s += " " + filepath.Base(e.DuplicateOf)
+		}
+		result[filepath.Base(filepath.Dir(e.Path))+"/"+filepath.Base(e.Path)] = s
+	}
+	return result
+}
+
+func TestImport(t *testing.T) {
+	report, added := importTestFiles(t, false)
+
+	expected := map[string]string{
+		"a/In Library.mp3": "Duplicate L1",
+		"a/Song.mp3":       "Added",
+		"b/Broken.mp3":     "Failed",
+		"b/Other.mp3":      "Added",
+		// duplicates within the folder are detected as well.
+		"b/song.mp3": "Duplicate N1",
+	}
+	if s := statuses(report); !reflect.DeepEqual(s, expected) {
+		t.Errorf("unexpected report: %v", s)
+	}
+	if !reflect.DeepEqual(added, []string{"Song.mp3", "Other.mp3"}) {
+		t.Errorf("unexpected added files: %v", added)
+	}
+	if report.DryRun || report.Count(Added) != 2 || report.Count(Duplicate) != 2 || report.Count(Failed) != 1 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	report, added := importTestFiles(t, true)
+
+	expected := map[string]string{
+		"a/In Library.mp3": "Duplicate L1",
+		"a/Song.mp3":       "New",
+		// nothing is added, the duplicate refers to the new file.
+		"b/song.mp3":   "Duplicate Song.mp3",
+		"b/Broken.mp3": "New",
+		"b/Other.mp3":  "New",
+	}
+	if s := statuses(report); !reflect.DeepEqual(s, expected) {
+		t.Errorf("unexpected report: %v", s)
+	}
+	if len(added) != 0 {
+		t.Errorf("expect no file added in a dry run, but %v", added)
+	}
+	if !report.DryRun || report.Count(New) != 3 {
+		t.Errorf("unexpected counts: %+v", report)
+	}
+}
+
+func TestImportTags(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := map[string][]byte{
+		// the file names differ from the titles, only the tags tell the songs apart.
+		"a/01.mp3":      id3File("Help!", "The Beatles", "Help!"),
+		"a/02.mp3":      id3File("Yesterday", "The Beatles", "Help!"),
+		"b/help.mp3":    id3File("HELP! ", "the beatles", "Help!"),
+		"b/Help.flac":   flacFile("Help!", "The Beatles", "Help!"),
+		"c/Unknown.mp3": id3File("Yesterday", "The Beatles", "Love"),
+	}
+	for name, data := range fixtures {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(path, data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := NewIndex(DefaultDurationTolerance)
+	idx.Add(NewKey("The Beatles", "Help!", "Yesterday"), 125*time.Second, "L1")
+
+	add := func(path string) (string, error) {
+		return "N" + filepath.Base(path), nil
+	}
+	report := importFiles(files, idx, add, Options{})
+
+	expected := map[string]string{
+		"a/01.mp3":      "Added",
+		"a/02.mp3":      "Duplicate L1",
+		"b/help.mp3":    "Duplicate N01.mp3",
+		"b/Help.flac":   "Unsupported",
+		"c/Unknown.mp3": "Added",
+	}
+	if s := statuses(report); !reflect.DeepEqual(s, expected) {
+		t.Errorf("unexpected report: %v", s)
+	}
+
+	for _, e := range report.Entries {
+		if e.Tag == nil || e.Err != nil {
+			t.Errorf("%v: expect the tag to be read, but %v", e.Path, e.Err)
+		}
+	}
+
+	var buf bytes.Buffer
+	_, err = report.WriteTo(&buf)
+	if err != nil || !bytes.Contains(buf.Bytes(), []byte("Unsupported\t"+filepath.Join(dir, "b", "Help.flac")+"\n")) ||
+		!bytes.HasSuffix(buf.Bytes(), []byte("unsupported:1 dry-run:false\n")) {
+		t.Errorf("unexpected report: %v %v", buf.String(), err)
+	}
+}
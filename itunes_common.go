@@ -1,5 +1,7 @@
 package itunes
 
+import "time"
+
 type PlayerState int
 
 const (
@@ -37,3 +39,21 @@ func (it *Itunes) GetAllTracks() ([]*Track, error) {
 
 	return tracks, nil
 }
+
+// Seek moves the player position by delta within the current track.
+func (it *Itunes) Seek(delta time.Duration) error {
+	pos, duration, err := it.Progress()
+	if err != nil {
+		return err
+	}
+
+	pos += delta
+	if pos < 0 {
+		pos = 0
+	}
+	if duration > 0 && pos > duration {
+		pos = duration
+	}
+
+	return it.SetPosition(pos)
+}
@@ -3,7 +3,9 @@ package itunes
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"time"
 )
 
 type Itunes struct {
@@ -29,11 +31,16 @@ func (_ *Itunes) Close() {
 }
 
 func (it *Itunes) CurrentTrack() (*Track, error) {
-	columns, err := getColumnsByJS(`logTrack(app.currentTrack());`)
+	// currentTrack throws if the player has no track.
+	columns, err := getColumnsByJS(`try { logTrack(app.currentTrack()); } catch (e) {}`)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(columns) == 0 {
+		return nil, ErrNoCurrentTrack
+	}
+
 	return createTrack(columns)
 }
 
@@ -69,14 +76,34 @@ func (it *Itunes) GetTracks() (chan *Track, error) {
 	return p.GetTracks()
 }
 
+// isPersistentID reports whether id has the form of a persistent ID, 16 hexadecimal digits.
+// The IDs given to the finders are checked before they are written into a script.
+func isPersistentID(id string) bool {
+	if len(id) != 16 {
+		return false
+	}
+
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func findTrackByPersistentID(persistentID string) (*Track, error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`logTrack(findTrackByPersistentId("%v"))`, persistentID))
+	if !isPersistentID(persistentID) {
+		return nil, &NotFoundError{Kind: "track", ID: persistentID}
+	}
+
+	columns, err := getColumnsByJS(fmt.Sprintf(`logTrack(findTrackByPersistentId(%s))`, jsString(persistentID)))
 	if err != nil {
 		return nil, err
 	}
 
 	if len(columns) == 0 {
-		return nil, errors.New(fmt.Sprintf("not found track:%v", persistentID))
+		return nil, &NotFoundError{Kind: "track", ID: persistentID}
 	}
 
 	return createTrack(columns)
@@ -96,7 +123,9 @@ func (_ *Itunes) CurrentPlaylist() (p *Playlist, err error) {
 }
 
 func (_ *Itunes) PlaylistCount() (int, error) {
-	columns, err := getColumnsByJS(`p(app.playlists.length);`)
+	columns, err := getColumnsByJS(fmt.Sprintf(`
+var names = app.playlists.name();
+p(names.length - (names.indexOf(%s) >= 0 ? 1 : 0));`, jsString(queuePlaylistName)))
 	if err != nil {
 		return 0, err
 	}
@@ -110,22 +139,30 @@ func (_ *Itunes) PlaylistCount() (int, error) {
 }
 
 func (_ *Itunes) GetPlaylist(index int) (*Playlist, error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(app.playlists[%d]())`, index))
+	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(getVisiblePlaylist(%d, %s))`, index, jsString(queuePlaylistName)))
 	if err != nil {
 		return nil, err
 	}
 
+	if len(columns) == 0 {
+		return nil, &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+	}
+
 	return createPlaylist(columns)
 }
 
 func findPlaylistByPersistentID(persistentID string) (*Playlist, error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(findPlaylistByPersistentId("%v"))`, persistentID))
+	if !isPersistentID(persistentID) {
+		return nil, &NotFoundError{Kind: "playlist", ID: persistentID}
+	}
+
+	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(findPlaylistByPersistentId(%s))`, jsString(persistentID)))
 	if err != nil {
 		return nil, err
 	}
 
 	if len(columns) == 0 {
-		return nil, errors.New(fmt.Sprintf("not found playlist:%v", persistentID))
+		return nil, &NotFoundError{Kind: "playlist", ID: persistentID}
 	}
 
 	return createPlaylist(columns)
@@ -135,8 +172,22 @@ func (_ *Itunes) FindPlaylistByPersistentID(persistentID string) (*Playlist, err
 	return findPlaylistByPersistentID(persistentID)
 }
 
+// findPlaylistByName returns the first playlist of the library named name, or nil if there is none.
+func (_ *Itunes) findPlaylistByName(name string) (*Playlist, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(findPlaylistByName(%s))`, jsString(name)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	return createPlaylist(columns)
+}
+
 func (_ *Itunes) CreatePlaylist(name string) (*Playlist, error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(createPlaylist("%v"));`, name))
+	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(createPlaylist(%s));`, jsString(name)))
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +268,37 @@ func (it *Itunes) PlayerPosition() (int, error) {
 	return int(result), nil
 }
 
+func (it *Itunes) SetPosition(pos time.Duration) error {
+	return putProperty("playerPosition", pos.Seconds())
+}
+
+func (it *Itunes) Position() (time.Duration, error) {
+	v, err := getProperty("playerPosition")
+	if err != nil {
+		return 0, err
+	}
+
+	if v == "null" {
+		return 0, errors.New("PlayerPosition is nil")
+	}
+
+	return parseSeconds(v), nil
+}
+
+// Progress returns the player position and the duration of the current track.
+func (it *Itunes) Progress() (pos, duration time.Duration, err error) {
+	columns, err := getColumnsByJS(`p(app.playerPosition(), app.currentTrack().duration());`)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(columns) < 2 || columns[0] == "null" {
+		return 0, 0, errors.New("PlayerPosition is nil")
+	}
+
+	return parseSeconds(columns[0]), parseSeconds(columns[1]), nil
+}
+
 func (it *Itunes) PlayerState() (PlayerState, error) {
 	v, err := getProperty("playerState")
 	if err != nil {
@@ -262,7 +344,7 @@ func (it *Itunes) Rewind() error {
 
 func (it *Itunes) SetSoundVolume(volume int) error {
 	if volume < 0 || 100 < volume {
-		return errors.New("volume is out of range")
+		return &OutOfRangeError{Name: "volume"}
 	}
 
 	return putProperty("soundVolume", volume)
@@ -293,3 +375,22 @@ func (it *Itunes) Mute() (bool, error) {
 
 	return v == "true", nil
 }
+
+// AddFile adds a media file to the library.
+func (_ *Itunes) AddFile(path string) (*Track, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := getColumnsByJS(fmt.Sprintf(`logTrack(app.add(Path(%s), {to: app.libraryPlaylists[0]}));`, jsString(path)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New(fmt.Sprintf("failed to add file:%v", path))
+	}
+
+	return createTrack(columns)
+}
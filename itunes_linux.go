@@ -0,0 +1,463 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Itunes controls an MPRIS2 player such as Rhythmbox or Strawberry over the D-Bus session bus.
+type Itunes struct {
+	conn *dbus.Conn
+	obj  dbus.BusObject
+}
+
+// for compatibility
+func Init() error {
+	return nil
+}
+
+// for compatibility
+func UnInit() {
+	return
+}
+
+// CreateItunes connects to an MPRIS2 player on the session bus.
+// Set ITUNES_MPRIS_PLAYER to the name of the player, such as "rhythmbox", to choose one of several.
+func CreateItunes() (*Itunes, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := findPlayer(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Itunes{conn: conn, obj: conn.Object(name, mprisPath)}, nil
+}
+
+// Close closes the connection, it does nothing on nil so that it may be deferred before checking the error of CreateItunes.
+func (it *Itunes) Close() {
+	if it == nil {
+		return
+	}
+
+	it.conn.Close()
+}
+
+func (it *Itunes) metadata() (map[string]dbus.Variant, error) {
+	v, err := it.getProperty(playerIface + ".Metadata")
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[string]dbus.Variant)
+	if !ok {
+		return nil, errors.New("Metadata is nil.")
+	}
+
+	return m, nil
+}
+
+func (it *Itunes) CurrentTrack() (*Track, error) {
+	m, err := it.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := m["mpris:trackid"].Value().(dbus.ObjectPath)
+	if id == "" || id == noTrack {
+		return nil, ErrNoCurrentTrack
+	}
+
+	return createTrack(it, m)
+}
+
+// trackIDs returns the tracks of the TrackList, the MPRIS counterpart of the library.
+func (it *Itunes) trackIDs() ([]dbus.ObjectPath, error) {
+	hasTrackList, err := it.getBool(rootIface + ".HasTrackList")
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasTrackList {
+		return nil, &NotSupportedError{Feature: "TrackList", Platform: "Linux"}
+	}
+
+	v, err := it.getProperty(trackListIface + ".Tracks")
+	if err != nil {
+		return nil, err
+	}
+
+	ids, _ := v.([]dbus.ObjectPath)
+	return ids, nil
+}
+
+func (it *Itunes) tracksMetadata(ids []dbus.ObjectPath) ([]map[string]dbus.Variant, error) {
+	var result []map[string]dbus.Variant
+	err := it.obj.Call(trackListIface+".GetTracksMetadata", 0, ids).Store(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (it *Itunes) getTrack(id dbus.ObjectPath) (*Track, error) {
+	result, err := it.tracksMetadata([]dbus.ObjectPath{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, &NotFoundError{Kind: "track", ID: makePersistentID(id)}
+	}
+
+	return createTrack(it, result[0])
+}
+
+func (it *Itunes) TrackCount() (int, error) {
+	ids, err := it.trackIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+func (it *Itunes) GetTrack(index int) (*Track, error) {
+	ids, err := it.trackIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	if index < 0 || len(ids) <= index {
+		return nil, &NotFoundError{Kind: "track", ID: strconv.Itoa(index)}
+	}
+
+	return it.getTrack(ids[index])
+}
+
+// the metadata is requested in batches so that a large TrackList starts streaming at once.
+const tracksBatchSize = 100
+
+func (it *Itunes) GetTracks() (chan *Track, error) {
+	ids, err := it.trackIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan *Track)
+	go func() {
+		defer close(result)
+		for start := 0; start < len(ids); start += tracksBatchSize {
+			end := start + tracksBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+
+			batch, err := it.tracksMetadata(ids[start:end])
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			for _, m := range batch {
+				track, err := createTrack(it, m)
+				if err != nil {
+					log.Println(err)
+					return
+				}
+
+				result <- track
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+func (it *Itunes) FindTrackByPersistentID(persistentID string) (*Track, error) {
+	ids, err := it.trackIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if makePersistentID(id) == persistentID {
+			return it.getTrack(id)
+		}
+	}
+
+	return nil, &NotFoundError{Kind: "track", ID: persistentID}
+}
+
+type mprisPlaylist struct {
+	Path dbus.ObjectPath
+	Name string
+	Icon string
+}
+
+func (it *Itunes) playlistOrder() string {
+	v, err := it.getProperty(playlistsIface + ".Orderings")
+	if err == nil {
+		orderings, _ := v.([]string)
+		if len(orderings) > 0 {
+			return orderings[0]
+		}
+	}
+
+	return "Alphabetical"
+}
+
+func (it *Itunes) playlists(index, count int) ([]*Playlist, error) {
+	var result []mprisPlaylist
+	err := it.obj.Call(playlistsIface+".GetPlaylists", 0, uint32(index), uint32(count), it.playlistOrder(), false).Store(&result)
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]*Playlist, 0, len(result))
+	for _, p := range result {
+		playlists = append(playlists, createPlaylist(it, p))
+	}
+
+	return playlists, nil
+}
+
+func (it *Itunes) CurrentPlaylist() (p *Playlist, err error) {
+	v, err := it.obj.GetProperty(playlistsIface + ".ActivePlaylist")
+	if err != nil {
+		return nil, err
+	}
+
+	var active struct {
+		Valid    bool
+		Playlist mprisPlaylist
+	}
+	err = v.Store(&active)
+	if err != nil {
+		return nil, err
+	}
+
+	if !active.Valid {
+		return nil, errors.New("CurrentPlaylist is nil.")
+	}
+
+	return createPlaylist(it, active.Playlist), nil
+}
+
+func (it *Itunes) PlaylistCount() (int, error) {
+	v, err := it.getProperty(playlistsIface + ".PlaylistCount")
+	if err != nil {
+		return 0, err
+	}
+
+	return int(variantInt(v)), nil
+}
+
+func (it *Itunes) GetPlaylist(index int) (*Playlist, error) {
+	if index < 0 {
+		return nil, &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+	}
+
+	playlists, err := it.playlists(index, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(playlists) == 0 {
+		return nil, &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+	}
+
+	return playlists[0], nil
+}
+
+func (it *Itunes) FindPlaylistByPersistentID(persistentID string) (*Playlist, error) {
+	count, err := it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists, err := it.playlists(0, count)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range playlists {
+		if p.persistentID == persistentID {
+			return p, nil
+		}
+	}
+
+	return nil, &NotFoundError{Kind: "playlist", ID: persistentID}
+}
+
+// findPlaylistByName finds no playlist, the queue cannot create its playlist through MPRIS.
+func (_ *Itunes) findPlaylistByName(name string) (*Playlist, error) {
+	return nil, nil
+}
+
+func (_ *Itunes) CreatePlaylist(name string) (*Playlist, error) {
+	return nil, &NotSupportedError{Feature: "CreatePlaylist", Platform: "Linux"}
+}
+
+func (it *Itunes) Play() error {
+	return it.callMethod(playerIface + ".Play")
+}
+
+func (it *Itunes) Stop() error {
+	return it.callMethod(playerIface + ".Stop")
+}
+
+// BackTrack restarts the current track like iTunes, or goes to the previous track near its start.
+func (it *Itunes) BackTrack() error {
+	pos, err := it.Position()
+	if err != nil {
+		return err
+	}
+
+	if pos < 2*time.Second {
+		return it.PreviousTrack()
+	}
+
+	return it.SetPosition(0)
+}
+
+func (it *Itunes) PreviousTrack() error {
+	return it.callMethod(playerIface + ".Previous")
+}
+
+func (it *Itunes) NextTrack() error {
+	return it.callMethod(playerIface + ".Next")
+}
+
+func (it *Itunes) SetPlayerPosition(pos int) error {
+	return it.SetPosition(time.Duration(pos) * time.Second)
+}
+
+func (it *Itunes) PlayerPosition() (int, error) {
+	pos, err := it.Position()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(pos.Seconds()), nil
+}
+
+// SetPosition moves the player position within the current track.
+func (it *Itunes) SetPosition(pos time.Duration) error {
+	m, err := it.metadata()
+	if err != nil {
+		return err
+	}
+
+	id, _ := m["mpris:trackid"].Value().(dbus.ObjectPath)
+	if id == "" || id == noTrack {
+		return ErrNoCurrentTrack
+	}
+
+	return it.callMethod(playerIface+".SetPosition", id, pos.Microseconds())
+}
+
+func (it *Itunes) Position() (time.Duration, error) {
+	v, err := it.getProperty(playerIface + ".Position")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(variantInt(v)) * time.Microsecond, nil
+}
+
+// Progress returns the player position and the duration of the current track.
+func (it *Itunes) Progress() (pos, duration time.Duration, err error) {
+	pos, err = it.Position()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	m, err := it.metadata()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return pos, time.Duration(variantInt(m["mpris:length"].Value())) * time.Microsecond, nil
+}
+
+func (it *Itunes) PlayerState() (PlayerState, error) {
+	v, err := it.getProperty(playerIface + ".PlaybackStatus")
+	if err != nil {
+		return PlayerState(0), err
+	}
+
+	var ps PlayerState
+	switch v {
+	case "Playing":
+		ps = Playing
+	case "Paused", "Stopped":
+		ps = Stopped
+	default:
+		return PlayerState(0), errors.New(fmt.Sprintf("unknown player state:%v", v))
+	}
+
+	return ps, nil
+}
+
+func (it *Itunes) PlayPause() error {
+	return it.callMethod(playerIface + ".PlayPause")
+}
+
+func (it *Itunes) Pause() error {
+	return it.callMethod(playerIface + ".Pause")
+}
+
+func (it *Itunes) Resume() error {
+	return it.callMethod(playerIface + ".Play")
+}
+
+func (_ *Itunes) FastForward() error {
+	return &NotSupportedError{Feature: "FastForward", Platform: "Linux"}
+}
+
+func (_ *Itunes) Rewind() error {
+	return &NotSupportedError{Feature: "Rewind", Platform: "Linux"}
+}
+
+// SetSoundVolume sets the volume from 0 to 100, MPRIS scales it from 0.0 to 1.0.
+func (it *Itunes) SetSoundVolume(volume int) error {
+	if volume < 0 || 100 < volume {
+		return &OutOfRangeError{Name: "volume"}
+	}
+
+	return it.putProperty(playerIface+".Volume", float64(volume)/100)
+}
+
+func (it *Itunes) SoundVolume() (int, error) {
+	v, err := it.getFloat(playerIface + ".Volume")
+	if err != nil {
+		return 0, err
+	}
+
+	// players may amplify above 1.0.
+	return int(math.Min(math.Round(v*100), 100)), nil
+}
+
+func (_ *Itunes) SetMute(isMuted bool) error {
+	return &NotSupportedError{Feature: "SetMute", Platform: "Linux"}
+}
+
+func (_ *Itunes) Mute() (bool, error) {
+	return false, &NotSupportedError{Feature: "Mute", Platform: "Linux"}
+}
+
+func (_ *Itunes) AddFile(path string) (*Track, error) {
+	return nil, &NotSupportedError{Feature: "AddFile", Platform: "Linux"}
+}
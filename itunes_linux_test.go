@@ -0,0 +1,413 @@
+package itunes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// standIn is a local MPRIS2 player with a TrackList and Playlists.
+type standIn struct {
+	mu     sync.Mutex
+	calls  []string
+	props  *prop.Properties
+	tracks map[dbus.ObjectPath]map[string]dbus.Variant
+}
+
+func (s *standIn) record(format string, args ...interface{}) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (s *standIn) takeCalls() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := strings.Join(s.calls, ",")
+	s.calls = nil
+	return calls
+}
+
+func (s *standIn) Play() *dbus.Error      { return s.record("play") }
+func (s *standIn) Pause() *dbus.Error     { return s.record("pause") }
+func (s *standIn) PlayPause() *dbus.Error { return s.record("playpause") }
+func (s *standIn) Stop() *dbus.Error      { return s.record("stop") }
+func (s *standIn) Next() *dbus.Error      { return s.record("next") }
+func (s *standIn) Previous() *dbus.Error  { return s.record("previous") }
+
+func (s *standIn) SetPosition(id dbus.ObjectPath, pos int64) *dbus.Error {
+	return s.record("position %v %v", id, pos)
+}
+
+func (s *standIn) GetTracksMetadata(ids []dbus.ObjectPath) ([]map[string]dbus.Variant, *dbus.Error) {
+	result := make([]map[string]dbus.Variant, 0, len(ids))
+	for _, id := range ids {
+		if m, ok := s.tracks[id]; ok {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func (s *standIn) GoTo(id dbus.ObjectPath) *dbus.Error        { return s.record("goto %v", id) }
+func (s *standIn) RemoveTrack(id dbus.ObjectPath) *dbus.Error { return s.record("remove %v", id) }
+
+func (s *standIn) GetPlaylists(index, count uint32, order string, reverse bool) ([]mprisPlaylist, *dbus.Error) {
+	playlists := []mprisPlaylist{
+		{Path: "/org/mpris/MediaPlayer2/Playlist/1", Name: "Mix"},
+		{Path: "/org/mpris/MediaPlayer2/Playlist/2", Name: "Rock"},
+	}
+	s.record("playlists %v %v %v", index, count, order)
+	if int(index) >= len(playlists) {
+		return nil, nil
+	}
+	end := int(index + count)
+	if end > len(playlists) {
+		end = len(playlists)
+	}
+	return playlists[index:end], nil
+}
+
+func (s *standIn) ActivatePlaylist(path dbus.ObjectPath) *dbus.Error {
+	return s.record("activate %v", path)
+}
+
+// privateBus starts a session bus for the test and makes it the session bus of the process.
+func privateBus(t *testing.T) string {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	cmd := exec.Command(path, "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	address = strings.TrimSpace(address)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+	return address
+}
+
+func fileURL(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func startStandIn(t *testing.T) *standIn {
+	address := privateBus(t)
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	dir := t.TempDir()
+	song := filepath.Join(dir, "help.mp3")
+	art := filepath.Join(dir, "0a1b2c")
+	err = os.WriteFile(song, []byte("0123456789"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(art, []byte("\x89PNG\r\n\x1a\nIHDR"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	help := map[string]dbus.Variant{
+		"mpris:trackid":        dbus.MakeVariant(dbus.ObjectPath("/org/mpris/MediaPlayer2/Track/1")),
+		"mpris:length":         dbus.MakeVariant(int64(138e6)),
+		"mpris:artUrl":         dbus.MakeVariant(fileURL(art)),
+		"xesam:title":          dbus.MakeVariant("Help!"),
+		"xesam:artist":         dbus.MakeVariant([]string{"The Beatles"}),
+		"xesam:album":          dbus.MakeVariant("Help!"),
+		"xesam:genre":          dbus.MakeVariant([]string{"Rock"}),
+		"xesam:url":            dbus.MakeVariant(fileURL(song)),
+		"xesam:contentCreated": dbus.MakeVariant("1965-08-06T00:00:00Z"),
+		"xesam:useCount":       dbus.MakeVariant(int32(3)),
+		"xesam:userRating":     dbus.MakeVariant(0.8),
+		"xesam:comment":        dbus.MakeVariant([]string{"remastered"}),
+	}
+	yesterday := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(dbus.ObjectPath("/org/mpris/MediaPlayer2/Track/2")),
+		"mpris:length":  dbus.MakeVariant(uint64(125e6)),
+		"xesam:title":   dbus.MakeVariant("Yesterday"),
+		"xesam:url":     dbus.MakeVariant("http://example.com/yesterday.mp3"),
+	}
+
+	s := &standIn{tracks: map[dbus.ObjectPath]map[string]dbus.Variant{
+		"/org/mpris/MediaPlayer2/Track/1": help,
+		"/org/mpris/MediaPlayer2/Track/2": yesterday,
+	}}
+
+	record := func(c *prop.Change) *dbus.Error {
+		return s.record("%v %v", c.Name, c.Value)
+	}
+	s.props, err = prop.Export(conn, mprisPath, prop.Map{
+		rootIface: {
+			"Identity":     {Value: "Stand-in"},
+			"HasTrackList": {Value: true},
+		},
+		playerIface: {
+			"PlaybackStatus": {Value: "Playing"},
+			"Volume":         {Value: 0.3, Writable: true, Callback: record},
+			"Shuffle":        {Value: false, Writable: true, Callback: record},
+			"Position":       {Value: int64(12e6)},
+			"Metadata":       {Value: help},
+		},
+		trackListIface: {
+			"Tracks": {Value: []dbus.ObjectPath{"/org/mpris/MediaPlayer2/Track/1", "/org/mpris/MediaPlayer2/Track/2"}},
+		},
+		playlistsIface: {
+			"PlaylistCount": {Value: uint32(2)},
+			"Orderings":     {Value: []string{"UserDefined", "Alphabetical"}},
+			"ActivePlaylist": {Value: struct {
+				Valid    bool
+				Playlist mprisPlaylist
+			}{true, mprisPlaylist{Path: "/org/mpris/MediaPlayer2/Playlist/2", Name: "Rock"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iface := range []string{playerIface, trackListIface, playlistsIface} {
+		err = conn.Export(s, mprisPath, iface)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// players running several instances own names such as this one.
+	_, err = conn.RequestName(mprisPrefix+"standin.instance42", dbus.NameFlagDoNotQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func createStandInItunes(t *testing.T) (*Itunes, *standIn) {
+	s := startStandIn(t)
+	t.Setenv(playerEnv, "standin")
+
+	it, err := CreateItunes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(it.Close)
+
+	return it, s
+}
+
+func TestMPRISPlayer(t *testing.T) {
+	it, s := createStandInItunes(t)
+
+	state, err := it.PlayerState()
+	if err != nil || state != Playing {
+		t.Errorf("unexpected state: %v %v", state, err)
+	}
+
+	volume, err := it.SoundVolume()
+	if err != nil || volume != 30 {
+		t.Errorf("unexpected volume: %v %v", volume, err)
+	}
+
+	pos, duration, err := it.Progress()
+	if err != nil || pos != 12*time.Second || duration != 138*time.Second {
+		t.Errorf("unexpected progress: %v %v %v", pos, duration, err)
+	}
+
+	for _, f := range []func() error{it.Play, it.Pause, it.PlayPause, it.Stop, it.NextTrack, it.PreviousTrack, it.Resume} {
+		err = f()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	err = it.SetSoundVolume(50)
+	if err != nil {
+		t.Error(err)
+	}
+	err = it.SetPosition(90 * time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+	// restarts the track as the position is past its start.
+	err = it.BackTrack()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := "play,pause,playpause,stop,next,previous,play,Volume 0.5,position /org/mpris/MediaPlayer2/Track/1 90000000,position /org/mpris/MediaPlayer2/Track/1 0"
+	if calls := s.takeCalls(); calls != expect {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	err = it.SetSoundVolume(101)
+	var rangeErr *OutOfRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Errorf("expect OutOfRangeError, but %v", err)
+	}
+
+	var notSupported *NotSupportedError
+	if err := it.FastForward(); !errors.As(err, &notSupported) || notSupported.Platform != "Linux" {
+		t.Errorf("expect NotSupportedError, but %v", err)
+	}
+
+	track, err := it.CurrentTrack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Name() != "Help!" || track.Artist() != "The Beatles" || track.Genre() != "Rock" || track.Year() != 1965 || track.Duration() != 138*time.Second {
+		t.Errorf("unexpected track: %+v", track)
+	}
+	if !track.IsFileTrack() || track.IsDead() || track.Size() != 10 || track.PlayedCount() != 3 || track.Rating() != 80 || track.Comment() != "remastered" {
+		t.Errorf("unexpected track: %+v", track)
+	}
+
+	artworks, err := track.GetArtworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for a := range artworks {
+		count++
+		path, err := a.SaveToFile(t.TempDir(), "cover")
+		if err != nil || a.Format() != PNG || filepath.Ext(path) != ".png" {
+			t.Errorf("unexpected artwork: %v %v %v", a.Format(), path, err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("expect an artwork, but %v", count)
+	}
+
+	s.props.SetMust(playerIface, "PlaybackStatus", "Stopped")
+	s.props.SetMust(playerIface, "Metadata", map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)})
+
+	state, err = it.PlayerState()
+	if err != nil || state != Stopped {
+		t.Errorf("unexpected state: %v %v", state, err)
+	}
+	_, err = it.CurrentTrack()
+	if err != ErrNoCurrentTrack {
+		t.Errorf("expect ErrNoCurrentTrack, but %v", err)
+	}
+	err = it.SetPosition(0)
+	if err != ErrNoCurrentTrack {
+		t.Errorf("expect ErrNoCurrentTrack, but %v", err)
+	}
+}
+
+func TestMPRISLibrary(t *testing.T) {
+	it, s := createStandInItunes(t)
+
+	count, err := it.TrackCount()
+	if err != nil || count != 2 {
+		t.Errorf("unexpected track count: %v %v", count, err)
+	}
+
+	tracks, err := it.GetAllTracks()
+	if err != nil || len(tracks) != 2 {
+		t.Fatalf("unexpected tracks: %v %v", tracks, err)
+	}
+	if tracks[1].Name() != "Yesterday" || tracks[1].IsFileTrack() || tracks[1].Location() != "http://example.com/yesterday.mp3" || tracks[1].Duration() != 125*time.Second {
+		t.Errorf("unexpected track: %+v", tracks[1])
+	}
+
+	track, err := it.FindTrackByPersistentID(tracks[1].PersistentID())
+	if err != nil || track.Name() != "Yesterday" {
+		t.Errorf("unexpected track: %v %v", track, err)
+	}
+
+	var notFound *NotFoundError
+	_, err = it.FindTrackByPersistentID("0000000000000000")
+	if !errors.As(err, &notFound) || notFound.Kind != "track" {
+		t.Errorf("expect NotFoundError, but %v", err)
+	}
+	_, err = it.GetTrack(2)
+	if !errors.As(err, &notFound) {
+		t.Errorf("expect NotFoundError, but %v", err)
+	}
+
+	track, err = it.GetTrack(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = track.Play()
+	if err != nil {
+		t.Error(err)
+	}
+	err = track.Delete()
+	if err != nil {
+		t.Error(err)
+	}
+	if calls := s.takeCalls(); calls != "goto /org/mpris/MediaPlayer2/Track/1,remove /org/mpris/MediaPlayer2/Track/1" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	count, err = it.PlaylistCount()
+	if err != nil || count != 2 {
+		t.Errorf("unexpected playlist count: %v %v", count, err)
+	}
+
+	p, err := it.GetPlaylist(1)
+	if err != nil || p.Name() != "Rock" || !p.IsUserPlaylist() {
+		t.Fatalf("unexpected playlist: %v %v", p, err)
+	}
+	_, err = it.GetPlaylist(2)
+	if !errors.As(err, &notFound) || notFound.Kind != "playlist" {
+		t.Errorf("expect NotFoundError, but %v", err)
+	}
+
+	current, err := it.CurrentPlaylist()
+	if err != nil || current.PersistentID() != p.PersistentID() {
+		t.Errorf("unexpected current playlist: %v %v", current, err)
+	}
+
+	found, err := it.FindPlaylistByPersistentID(p.PersistentID())
+	if err != nil || found.Name() != "Rock" {
+		t.Errorf("unexpected playlist: %v %v", found, err)
+	}
+
+	err = p.PlayFirstTrack()
+	if err != nil {
+		t.Error(err)
+	}
+	err = p.SetShuffle(true)
+	if err != nil {
+		t.Error(err)
+	}
+	shuffle, err := p.Shuffle()
+	if err != nil || !shuffle {
+		t.Errorf("unexpected shuffle: %v %v", shuffle, err)
+	}
+
+	expect := "playlists 1 1 UserDefined,playlists 2 1 UserDefined,playlists 0 2 UserDefined,activate /org/mpris/MediaPlayer2/Playlist/2,Shuffle true"
+	if calls := s.takeCalls(); calls != expect {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
@@ -1,6 +1,14 @@
+//go:build darwin || windows
+// +build darwin windows
+
+// These tests control the running iTunes, on linux itunes_linux_test.go tests against a stand-in player.
+
 package itunes
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestItunes(t *testing.T) {
 	err := Init()
@@ -84,6 +92,7 @@ func TestItunesControls(t *testing.T) {
 	}
 
 	testPlayerPosition(t, it, 10)
+	testSeek(t, it)
 	testBackTrack(t, it)
 
 	baseTrack, err := it.CurrentTrack()
@@ -153,6 +162,31 @@ func testPlayerPosition(t *testing.T, it *Itunes, expect int) {
 	}
 }
 
+func testSeek(t *testing.T, it *Itunes) {
+	err := it.SetPosition(10500 * time.Millisecond)
+	if err != nil {
+		t.Errorf("SetPosition failed.\n%v", err)
+	}
+
+	err = it.Seek(-5 * time.Second)
+	if err != nil {
+		t.Errorf("Seek failed.\n%v", err)
+	}
+
+	pos, duration, err := it.Progress()
+	if err != nil {
+		t.Errorf("Progress failed.\n%v", err)
+	}
+
+	if pos < 5*time.Second || 6500*time.Millisecond < pos {
+		t.Errorf("Expect %v <= Position < %v, but %v", 5*time.Second, 6500*time.Millisecond, pos)
+	}
+
+	if duration < pos {
+		t.Errorf("Expect Position <= Duration, but %v > %v", pos, duration)
+	}
+}
+
 func testBackTrack(t *testing.T, it *Itunes) {
 	err := it.BackTrack()
 	if err != nil {
@@ -3,7 +3,9 @@ package itunes
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/go-ole/go-ole"
 	"github.com/yaegaki/go-ole-handler"
@@ -83,6 +85,10 @@ func (it *Itunes) Close() {
 
 func (it *Itunes) CurrentTrack() (t *Track, err error) {
 	err = it.handler.GetOleHandlerWithCallback("CurrentTrack", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return ErrNoCurrentTrack
+		}
+
 		t, err = createTrack(it, handler)
 		return err
 	})
@@ -102,12 +108,20 @@ func (it *Itunes) GetTracks() (chan *Track, error) {
 	return it.libraryPlaylist.GetTracks()
 }
 
+// isNull reports whether a property or a method returned no object,
+// such as CurrentTrack while the player has no track or Item out of range.
+func isNull(handler *olehandler.OleHandler) bool {
+	return handler == nil || handler.Handle == nil
+}
+
 const PersistentIDSize = 16
 
-func (it *Itunes) findItemByPersistentID(collection *olehandler.OleHandler, persistentID string, fn func(*olehandler.OleHandler) error) error {
+// findItemByPersistentID calls fn with the item of the collection, kind is the Kind of the NotFoundError if it does not exist.
+func (it *Itunes) findItemByPersistentID(collection *olehandler.OleHandler, kind, persistentID string, fn func(*olehandler.OleHandler) error) error {
 	length := len(persistentID)
-	if length > PersistentIDSize || length < 0 {
-		return errors.New(fmt.Sprintf("invalid persistentID:%v", persistentID))
+	if length > PersistentIDSize || length == 0 {
+		// no item has such an ID.
+		return &NotFoundError{Kind: kind, ID: persistentID}
 	}
 
 	var highID, lowID uint32
@@ -115,31 +129,35 @@ func (it *Itunes) findItemByPersistentID(collection *olehandler.OleHandler, pers
 		highID = 0
 		v, err := strconv.ParseUint(persistentID, 16, 32)
 		if err != nil {
-			return err
+			return &NotFoundError{Kind: kind, ID: persistentID}
 		}
 		lowID = uint32(v)
 	} else {
 		highIndex := length - (PersistentIDSize / 2)
 		v, err := strconv.ParseUint(persistentID[:highIndex], 16, 32)
 		if err != nil {
-			return err
+			return &NotFoundError{Kind: kind, ID: persistentID}
 		}
 		highID = uint32(v)
 
 		v, err = strconv.ParseUint(persistentID[highIndex:], 16, 32)
 		if err != nil {
-			return err
+			return &NotFoundError{Kind: kind, ID: persistentID}
 		}
 		lowID = uint32(v)
 	}
 
 	return collection.GetOleHandlerWithCallbackAndArgs("ItemByPersistentID", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return &NotFoundError{Kind: kind, ID: persistentID}
+		}
+
 		return fn(handler)
 	}, highID, lowID)
 }
 
 func (it *Itunes) FindTrackByPersistentID(persistentID string) (t *Track, err error) {
-	err = it.findItemByPersistentID(it.libraryPlaylist.tracks, persistentID, func(handler *olehandler.OleHandler) error {
+	err = it.findItemByPersistentID(it.libraryPlaylist.tracks, "track", persistentID, func(handler *olehandler.OleHandler) error {
 		t, err = createTrack(it, handler)
 		return err
 	})
@@ -156,21 +174,66 @@ func (it *Itunes) CurrentPlaylist() (p *Playlist, err error) {
 	return p, err
 }
 
+// queueIndex returns the index of the playlist of the queue in the playlists, the first is at 1, or 0 if there is none.
+func (it *Itunes) queueIndex() (index int, err error) {
+	err = it.playlists.GetOleHandlerWithCallbackAndArgs("ItemByName", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return nil
+		}
+		defer handler.Close()
+
+		index, err = handler.GetIntProperty("Index")
+		return err
+	}, queuePlaylistName)
+
+	return index, err
+}
+
+// PlaylistCount counts the playlists except the playlist of the queue.
 func (it *Itunes) PlaylistCount() (int, error) {
-	return it.playlists.GetIntProperty("Count")
+	count, err := it.playlists.GetIntProperty("Count")
+	if err != nil {
+		return 0, err
+	}
+
+	queue, err := it.queueIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	if queue > 0 {
+		count--
+	}
+
+	return count, nil
 }
 
+// GetPlaylist skips the playlist of the queue.
 func (it *Itunes) GetPlaylist(index int) (p *Playlist, err error) {
+	queue, err := it.queueIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	item := index + 1
+	if queue > 0 && item >= queue {
+		item++
+	}
+
 	err = it.playlists.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+		}
+
 		p, err = createPlaylist(it, handler)
 		return err
-	}, index+1)
+	}, item)
 
 	return p, err
 }
 
 func (it *Itunes) FindPlaylistByPersistentID(persistentID string) (p *Playlist, err error) {
-	err = it.findItemByPersistentID(it.playlists, persistentID, func(handler *olehandler.OleHandler) error {
+	err = it.findItemByPersistentID(it.playlists, "playlist", persistentID, func(handler *olehandler.OleHandler) error {
 		p, err = createPlaylist(it, handler)
 		return err
 	})
@@ -178,6 +241,20 @@ func (it *Itunes) FindPlaylistByPersistentID(persistentID string) (p *Playlist,
 	return p, err
 }
 
+// findPlaylistByName returns the first playlist of the library named name, or nil if there is none.
+func (it *Itunes) findPlaylistByName(name string) (p *Playlist, err error) {
+	err = it.playlists.GetOleHandlerWithCallbackAndArgs("ItemByName", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return nil
+		}
+
+		p, err = createPlaylist(it, handler)
+		return err
+	}, name)
+
+	return p, err
+}
+
 func (it *Itunes) CreatePlaylist(playlistName string) (p *Playlist, err error) {
 	err = it.handler.GetOleHandlerWithCallbackAndArgsByMethod("CreatePlaylist", func(handler *olehandler.OleHandler) error {
 		p, err = createPlaylist(it, handler)
@@ -215,6 +292,42 @@ func (it *Itunes) PlayerPosition() (int, error) {
 	return it.handler.GetIntProperty("PlayerPosition")
 }
 
+func (it *Itunes) SetPosition(pos time.Duration) error {
+	return it.handler.PutProperty("PlayerPositionMS", int(pos/time.Millisecond))
+}
+
+func (it *Itunes) Position() (time.Duration, error) {
+	v, err := it.handler.GetIntProperty("PlayerPositionMS")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(v) * time.Millisecond, nil
+}
+
+// Progress returns the player position and the duration of the current track.
+func (it *Itunes) Progress() (pos, duration time.Duration, err error) {
+	pos, err = it.Position()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = it.handler.GetOleHandlerWithCallback("CurrentTrack", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return ErrNoCurrentTrack
+		}
+
+		v, err := handler.GetIntProperty("Duration")
+		duration = time.Duration(v) * time.Second
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return pos, duration, nil
+}
+
 func (it *Itunes) PlayerState() (PlayerState, error) {
 	v, err := it.handler.GetIntProperty("PlayerState")
 	if err != nil {
@@ -246,7 +359,7 @@ func (it *Itunes) Rewind() error {
 
 func (it *Itunes) SetSoundVolume(volume int) error {
 	if volume < 0 || 100 < volume {
-		return errors.New("volume is out of range")
+		return &OutOfRangeError{Name: "volume"}
 	}
 
 	return it.handler.PutProperty("SoundVolume", volume)
@@ -263,3 +376,48 @@ func (it *Itunes) SetMute(isMuted bool) error {
 func (it *Itunes) Mute() (bool, error) {
 	return it.handler.GetBoolProperty("Mute")
 }
+
+// AddFile adds a media file to the library.
+func (it *Itunes) AddFile(path string) (t *Track, err error) {
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = it.libraryPlaylist.handler.GetOleHandlerWithCallbackAndArgsByMethod("AddFile", func(status *olehandler.OleHandler) error {
+		defer status.Close()
+
+		// AddFile returns before iTunes finishes to import the file.
+		for {
+			inProgress, err := status.GetBoolProperty("InProgress")
+			if err != nil {
+				return err
+			}
+
+			if !inProgress {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		return status.GetOleHandlerWithCallback("Tracks", func(tracks *olehandler.OleHandler) error {
+			defer tracks.Close()
+
+			count, err := tracks.GetIntProperty("Count")
+			if err != nil {
+				return err
+			}
+
+			if count == 0 {
+				return errors.New(fmt.Sprintf("failed to add file:%v", path))
+			}
+
+			return tracks.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+				t, err = createTrack(it, handler)
+				return err
+			}, 1)
+		})
+	}, path)
+
+	return t, err
+}
@@ -0,0 +1,874 @@
+// Package librarysync keeps two libraries, such as the ones of a Mac and a Windows PC, in sync.
+//
+// Ratings, play counts, user playlists and their tracks are reconciled in both directions.
+// Persistent IDs differ between libraries, so tracks are matched by name, artist and duration,
+// and playlists by name when they are seen for the first time.
+// The pairs and the values at the last sync are kept in a journal file.
+//
+// A value changed on one side since the last sync is copied to the other side.
+// When both sides changed it, the last write wins. Libraries do not record when a value was written,
+// so the journal takes the time a change was first observed: call Observe periodically to order
+// conflicting changes by when they were made rather than when Sync ran.
+// Play counts are not overwritten but merged, the plays of both sides since the last sync add up.
+package librarysync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+)
+
+type Track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Duration() time.Duration
+	Rating() int
+	PlayedCount() int
+
+	SetRating(rating int) error
+	SetPlayedCount(count int) error
+}
+
+type Playlist interface {
+	PersistentID() string
+	Name() string
+	// TrackIDs returns the persistent IDs of the tracks in the playlist order.
+	TrackIDs() ([]string, error)
+
+	SetName(name string) error
+	AddTrack(trackID string) error
+	RemoveTrack(trackID string) error
+	Delete() error
+}
+
+type Library interface {
+	Tracks() ([]Track, error)
+	// Playlists returns the user playlists, smart playlists are not synced.
+	Playlists() ([]Playlist, error)
+	CreatePlaylist(name string) (Playlist, error)
+}
+
+type Side int
+
+const (
+	A Side = iota
+	B
+)
+
+func (s Side) String() string {
+	switch s {
+	case A:
+		return "A"
+	case B:
+		return "B"
+	}
+
+	return ""
+}
+
+func (s Side) other() Side {
+	return 1 - s
+}
+
+const journalVersion = 1
+
+// pair is the persistent IDs of the same track or playlist in both libraries.
+type pair [2]string
+
+func (p pair) key() string {
+	return p[A] + "/" + p[B]
+}
+
+type change struct {
+	Side  Side      `json:"side"`
+	Key   string    `json:"key"`
+	Value string    `json:"value"`
+	Time  time.Time `json:"time"`
+}
+
+type journalFile struct {
+	Version   int    `json:"version"`
+	Tracks    []pair `json:"tracks"`
+	Playlists []pair `json:"playlists"`
+	// Base has the values at the last sync. missing values are empty.
+	Base    map[string]string `json:"base"`
+	Changes []change          `json:"changes"`
+}
+
+type Journal struct {
+	path string
+	now  func() time.Time
+
+	tracks    []pair
+	playlists []pair
+	base      map[string]string
+	changes   []change
+	// changeIndex has the indices in changes of the changes of each key, per side.
+	changeIndex [2]map[string][]int
+}
+
+// Open loads the journal saved in path. The journal is empty if path does not exist.
+func Open(path string) (*Journal, error) {
+	j := &Journal{
+		path: path,
+		now:  time.Now,
+		base: map[string]string{},
+	}
+	j.setChanges(nil)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f journalFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Version != journalVersion {
+		return nil, errors.New(fmt.Sprintf("unsupported sync journal version:%v", f.Version))
+	}
+
+	for k := range f.Base {
+		_, err = parseKey(k)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range f.Changes {
+		if c.Side != A && c.Side != B {
+			return nil, errors.New(fmt.Sprintf("invalid sync journal side:%v", c.Side))
+		}
+		_, err = parseKey(c.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	j.tracks = f.Tracks
+	j.playlists = f.Playlists
+	j.setChanges(f.Changes)
+	if f.Base != nil {
+		j.base = f.Base
+	}
+
+	return j, nil
+}
+
+// setChanges replaces the changes and indexes them.
+func (j *Journal) setChanges(changes []change) {
+	j.changes = nil
+	j.changeIndex = [2]map[string][]int{{}, {}}
+	for _, c := range changes {
+		j.addChange(c)
+	}
+}
+
+func (j *Journal) addChange(c change) {
+	j.changeIndex[c.Side][c.Key] = append(j.changeIndex[c.Side][c.Key], len(j.changes))
+	j.changes = append(j.changes, c)
+}
+
+func (j *Journal) save() error {
+	f := journalFile{
+		Version:   journalVersion,
+		Tracks:    j.tracks,
+		Playlists: j.playlists,
+		Base:      j.base,
+		Changes:   j.changes,
+	}
+
+	data, err := json.MarshalIndent(&f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), j.path)
+}
+
+func trackKey(p pair, field string) string {
+	return "t/" + p.key() + "/" + field
+}
+
+func playlistKey(p pair, field string) string {
+	return "p/" + p.key() + "/" + field
+}
+
+func memberKey(p, t pair) string {
+	return "p/" + p.key() + "/t/" + t.key()
+}
+
+const (
+	ratingField      = "rating"
+	playedCountField = "playedCount"
+	existsField      = "exists"
+	nameField        = "name"
+)
+
+// key is a parsed journal key.
+type key struct {
+	isPlaylist bool
+	item       pair
+	field      string
+	// track is the track of a playlist membership key.
+	track pair
+}
+
+func parseKey(s string) (key, error) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "t" && (parts[3] == ratingField || parts[3] == playedCountField):
+	case len(parts) == 4 && parts[0] == "p" && (parts[3] == existsField || parts[3] == nameField):
+	case len(parts) == 6 && parts[0] == "p" && parts[3] == "t":
+	default:
+		return key{}, errors.New(fmt.Sprintf("invalid sync journal key:%v", s))
+	}
+
+	k := key{
+		isPlaylist: parts[0] == "p",
+		item:       pair{parts[1], parts[2]},
+		field:      parts[3],
+	}
+	if len(parts) == 6 {
+		k.track = pair{parts[4], parts[5]}
+	}
+
+	return k, nil
+}
+
+// library is a snapshot of one side.
+type library struct {
+	tracks    map[string]Track
+	playlists map[string]Playlist
+	// members are the track IDs of each playlist in order.
+	members map[string][]string
+}
+
+func load(lib Library) (*library, error) {
+	tracks, err := lib.Tracks()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists, err := lib.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &library{
+		tracks:    make(map[string]Track, len(tracks)),
+		playlists: make(map[string]Playlist, len(playlists)),
+		members:   make(map[string][]string, len(playlists)),
+	}
+	for _, t := range tracks {
+		l.tracks[t.PersistentID()] = t
+	}
+	for _, p := range playlists {
+		ids, err := p.TrackIDs()
+		if err != nil {
+			return nil, err
+		}
+		l.playlists[p.PersistentID()] = p
+		l.members[p.PersistentID()] = ids
+	}
+
+	return l, nil
+}
+
+// values returns the journal values of a side.
+// order is the position of each membership in its playlist.
+func (j *Journal) values(side Side, l *library) (values map[string]string, order map[string]int) {
+	values = map[string]string{}
+	order = map[string]int{}
+
+	trackPairs := make(map[string]pair, len(j.tracks))
+	for _, p := range j.tracks {
+		t, ok := l.tracks[p[side]]
+		if !ok {
+			continue
+		}
+		trackPairs[p[side]] = p
+
+		if t.Rating() != 0 {
+			values[trackKey(p, ratingField)] = strconv.Itoa(t.Rating())
+		}
+		if t.PlayedCount() != 0 {
+			values[trackKey(p, playedCountField)] = strconv.Itoa(t.PlayedCount())
+		}
+	}
+
+	for _, p := range j.playlists {
+		pl, ok := l.playlists[p[side]]
+		if !ok {
+			continue
+		}
+
+		values[playlistKey(p, existsField)] = "1"
+		values[playlistKey(p, nameField)] = pl.Name()
+		for i, id := range l.members[p[side]] {
+			if t, ok := trackPairs[id]; ok {
+				k := memberKey(p, t)
+				values[k] = "1"
+				order[k] = i
+			}
+		}
+	}
+
+	return values, order
+}
+
+// latest returns the last value of key on side, observed or synced.
+func (j *Journal) latest(side Side, k string) string {
+	if indices := j.changeIndex[side][k]; len(indices) > 0 {
+		return j.changes[indices[len(indices)-1]].Value
+	}
+
+	return j.base[k]
+}
+
+func (j *Journal) observe(side Side, values map[string]string) {
+	now := j.now()
+	keys := make(map[string]bool, len(values)+len(j.base))
+	for k := range values {
+		keys[k] = true
+	}
+	for k := range j.base {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		if j.latest(side, k) != values[k] {
+			j.addChange(change{Side: side, Key: k, Value: values[k], Time: now})
+		}
+	}
+}
+
+// Observe records the changes made to a side since the last sync or observation.
+func (j *Journal) Observe(side Side, lib Library) error {
+	l, err := load(lib)
+	if err != nil {
+		return err
+	}
+
+	values, _ := j.values(side, l)
+	j.observe(side, values)
+	return j.save()
+}
+
+type Report struct {
+	// TracksMatched is the number of tracks in both libraries.
+	TracksMatched int
+	// TracksUpdated counts the ratings and play counts written.
+	TracksUpdated    int
+	PlaylistsCreated int
+	PlaylistsDeleted int
+	PlaylistsRenamed int
+	// MembershipChanges counts the tracks added to or removed from playlists.
+	MembershipChanges int
+	// Conflicts counts the values changed differently on both sides.
+	Conflicts int
+}
+
+// Sync reconciles a and b and saves the journal.
+func (j *Journal) Sync(a, b Library) (*Report, error) {
+	var libs [2]*library
+	var err error
+	libs[A], err = load(a)
+	if err != nil {
+		return nil, err
+	}
+	libs[B], err = load(b)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	j.matchTracks(libs)
+	report.TracksMatched = len(j.tracks)
+
+	// observe before new playlists are paired, so that their memberships are changes.
+	var values [2]map[string]string
+	var order [2]map[string]int
+	for _, side := range []Side{A, B} {
+		values[side], order[side] = j.values(side, libs[side])
+		j.observe(side, values[side])
+	}
+
+	err = j.matchPlaylists(libs, [2]Library{a, b}, values, order, report)
+	if err == nil {
+		err = j.reconcile(libs, values, order, report)
+	}
+	if err != nil {
+		// the journal keeps what was written, so that the next sync does not take it for changes.
+		j.save()
+		return nil, err
+	}
+
+	return report, j.save()
+}
+
+func trackCandidates(l *library, ids []string) []playlistfile.Candidate {
+	candidates := make([]playlistfile.Candidate, len(ids))
+	for i, id := range ids {
+		t := l.tracks[id]
+		candidates[i] = playlistfile.Candidate{
+			ID:       id,
+			Title:    t.Name(),
+			Artist:   t.Artist(),
+			Duration: t.Duration(),
+		}
+	}
+
+	return candidates
+}
+
+// matchTracks drops the pairs of removed tracks and pairs new tracks by metadata.
+func (j *Journal) matchTracks(libs [2]*library) {
+	paired := [2]map[string]bool{{}, {}}
+	tracks := make([]pair, 0, len(j.tracks))
+	for _, p := range j.tracks {
+		_, okA := libs[A].tracks[p[A]]
+		_, okB := libs[B].tracks[p[B]]
+		if !okA || !okB {
+			continue
+		}
+
+		tracks = append(tracks, p)
+		paired[A][p[A]] = true
+		paired[B][p[B]] = true
+	}
+
+	var unpaired [2][]string
+	for _, side := range []Side{A, B} {
+		for id := range libs[side].tracks {
+			if !paired[side][id] {
+				unpaired[side] = append(unpaired[side], id)
+			}
+		}
+		sort.Strings(unpaired[side])
+	}
+
+	resolver := playlistfile.NewResolver(trackCandidates(libs[B], unpaired[B]))
+	matches := map[string][]string{}
+	for _, id := range unpaired[A] {
+		t := libs[A].tracks[id]
+		if match, ok := resolver.Resolve(playlistfile.Entry{Title: t.Name(), Artist: t.Artist(), Duration: t.Duration()}); ok {
+			matches[match] = append(matches[match], id)
+		}
+	}
+
+	for _, id := range unpaired[B] {
+		// tracks are paired one to one, several tracks matching the same track are ambiguous.
+		if ids := matches[id]; len(ids) == 1 {
+			tracks = append(tracks, pair{ids[0], id})
+		}
+	}
+
+	j.tracks = tracks
+}
+
+// matchPlaylists pairs new playlists by name, and copies the playlists without a pair to the other side.
+func (j *Journal) matchPlaylists(libs [2]*library, sides [2]Library, values [2]map[string]string, order [2]map[string]int, report *Report) error {
+	paired := [2]map[string]bool{{}, {}}
+	for _, p := range j.playlists {
+		paired[A][p[A]] = true
+		paired[B][p[B]] = true
+	}
+
+	var unpaired [2][]Playlist
+	for _, side := range []Side{A, B} {
+		for id, p := range libs[side].playlists {
+			if !paired[side][id] {
+				unpaired[side] = append(unpaired[side], p)
+			}
+		}
+		sort.Slice(unpaired[side], func(i, k int) bool {
+			return unpaired[side][i].Name() < unpaired[side][k].Name()
+		})
+	}
+
+	byName := map[string]Playlist{}
+	for _, p := range unpaired[B] {
+		if _, ok := byName[p.Name()]; !ok {
+			byName[p.Name()] = p
+		}
+	}
+
+	added := make([]pair, 0)
+	matched := map[string]bool{}
+	for _, p := range unpaired[A] {
+		if other, ok := byName[p.Name()]; ok && !matched[other.PersistentID()] {
+			matched[other.PersistentID()] = true
+			added = append(added, pair{p.PersistentID(), other.PersistentID()})
+			continue
+		}
+
+		created, err := sides[B].CreatePlaylist(p.Name())
+		if err != nil {
+			j.addPlaylists(libs, added)
+			return err
+		}
+		libs[B].playlists[created.PersistentID()] = created
+		added = append(added, pair{p.PersistentID(), created.PersistentID()})
+		report.PlaylistsCreated++
+	}
+
+	for _, p := range unpaired[B] {
+		if matched[p.PersistentID()] {
+			continue
+		}
+
+		created, err := sides[A].CreatePlaylist(p.Name())
+		if err != nil {
+			j.addPlaylists(libs, added)
+			return err
+		}
+		libs[A].playlists[created.PersistentID()] = created
+		added = append(added, pair{created.PersistentID(), p.PersistentID()})
+		report.PlaylistsCreated++
+	}
+
+	j.addPlaylists(libs, added)
+
+	trackPairs := [2]map[string]pair{{}, {}}
+	for _, t := range j.tracks {
+		trackPairs[A][t[A]] = t
+		trackPairs[B][t[B]] = t
+	}
+	for _, side := range []Side{A, B} {
+		for _, p := range added {
+			values[side][playlistKey(p, existsField)] = "1"
+			values[side][playlistKey(p, nameField)] = j.base[playlistKey(p, nameField)]
+			for i, id := range libs[side].members[p[side]] {
+				if t, ok := trackPairs[side][id]; ok {
+					k := memberKey(p, t)
+					values[side][k] = "1"
+					order[side][k] = i
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addPlaylists pairs the new playlists, which exist on both sides with the same name, only their tracks differ.
+func (j *Journal) addPlaylists(libs [2]*library, added []pair) {
+	j.playlists = append(j.playlists, added...)
+	for _, p := range added {
+		j.base[playlistKey(p, existsField)] = "1"
+		j.base[playlistKey(p, nameField)] = libs[A].playlists[p[A]].Name()
+	}
+}
+
+type write struct {
+	key   key
+	k     string
+	value string
+	// to is the side the value is written to.
+	to Side
+	// order is the position in the playlist the value comes from.
+	order int
+}
+
+// group is the playlist of a membership, or the key itself.
+func (w *write) group() string {
+	if w.key.field == "t" {
+		return playlistKey(w.key.item, "t")
+	}
+
+	return w.k
+}
+
+// resolve decides the value of k after the sync.
+func (j *Journal) resolve(k string, parsed key, values [2]map[string]string, report *Report) string {
+	base := j.base[k]
+	va, vb := values[A][k], values[B][k]
+	changedA, changedB := va != base, vb != base
+
+	if parsed.field == playedCountField {
+		// plays on both sides add up.
+		b := atoi(base)
+		if _, ok := j.base[k]; !ok {
+			// first sync of the track, the plays are probably the same ones.
+			b = atoi(va)
+			if atoi(vb) < b {
+				b = atoi(vb)
+			}
+		}
+		count := b
+		for _, v := range []string{va, vb} {
+			if d := atoi(v) - b; d > 0 {
+				count += d
+			}
+		}
+		if count == 0 {
+			return ""
+		}
+		return strconv.Itoa(count)
+	}
+
+	switch {
+	case !changedA && !changedB:
+		return base
+	case changedA && !changedB:
+		return va
+	case !changedA && changedB:
+		return vb
+	case va == vb:
+		return va
+	}
+
+	report.Conflicts++
+	if j.changedAt(B, k, vb).After(j.changedAt(A, k, va)) {
+		return vb
+	}
+
+	// ties go to A.
+	return va
+}
+
+// changedAt returns when value was first observed on side.
+func (j *Journal) changedAt(side Side, k, value string) time.Time {
+	t := time.Time{}
+	indices := j.changeIndex[side][k]
+	for i := len(indices) - 1; i >= 0; i-- {
+		c := j.changes[indices[i]]
+		if c.Value != value {
+			break
+		}
+		t = c.Time
+	}
+
+	return t
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// reconcile writes the resolved values to both sides and updates the journal with what was written,
+// all of it unless it returns an error.
+func (j *Journal) reconcile(libs [2]*library, values [2]map[string]string, order [2]map[string]int, report *Report) error {
+	keys := map[string]bool{}
+	for k := range j.base {
+		keys[k] = true
+	}
+	for _, side := range []Side{A, B} {
+		for k := range values[side] {
+			keys[k] = true
+		}
+	}
+
+	trackPaired := map[pair]bool{}
+	for _, t := range j.tracks {
+		trackPaired[t] = true
+	}
+
+	resolved := map[string]string{}
+	parsedKeys := map[string]key{}
+	deleted := map[pair]bool{}
+	writes := make([]write, 0)
+	for k := range keys {
+		parsed, err := parseKey(k)
+		if err != nil {
+			return err
+		}
+		if !parsed.isPlaylist && !trackPaired[parsed.item] {
+			// the track was removed from either library.
+			continue
+		}
+		if parsed.isPlaylist && parsed.field == "t" && !trackPaired[parsed.track] {
+			continue
+		}
+
+		v := j.resolve(k, parsed, values, report)
+		if parsed.isPlaylist && parsed.field == existsField && v == "" {
+			deleted[parsed.item] = true
+		}
+		resolved[k] = v
+		parsedKeys[k] = parsed
+
+		for _, side := range []Side{A, B} {
+			if values[side][k] != v {
+				writes = append(writes, write{key: parsed, k: k, value: v, to: side, order: order[side.other()][k]})
+			}
+		}
+	}
+
+	// playlists are deleted first, then the tracks of each playlist are added in the playlist order.
+	sort.Slice(writes, func(i, k int) bool {
+		wi, wk := writes[i], writes[k]
+		if (wi.key.field == existsField) != (wk.key.field == existsField) {
+			return wi.key.field == existsField
+		}
+		if gi, gk := wi.group(), wk.group(); gi != gk {
+			return gi < gk
+		}
+		if wi.order != wk.order {
+			return wi.order < wk.order
+		}
+		if wi.k != wk.k {
+			return wi.k < wk.k
+		}
+		return wi.to < wk.to
+	})
+
+	// the other writes to a deleted playlist are dropped. pending counts the writes of each key.
+	kept := writes[:0]
+	pending := map[string]int{}
+	for _, w := range writes {
+		if w.key.isPlaylist && w.key.field != existsField && deleted[w.key.item] {
+			continue
+		}
+		kept = append(kept, w)
+		pending[w.k]++
+	}
+
+	// written has the sides written so far for each key.
+	written := map[string][]Side{}
+	var err error
+	for _, w := range kept {
+		err = j.write(libs[w.to], w, report)
+		if err != nil {
+			break
+		}
+		written[w.k] = append(written[w.k], w.to)
+	}
+
+	done := func(k string) bool {
+		return len(written[k]) == pending[k]
+	}
+
+	base := j.base
+	if err == nil {
+		// the keys of the tracks removed from either library are dropped.
+		base = map[string]string{}
+	}
+
+	// a key is settled once both sides have its resolved value, its changes are then in the base.
+	settled := map[string]bool{}
+	for k, v := range resolved {
+		parsed := parsedKeys[k]
+		switch {
+		case parsed.isPlaylist && deleted[parsed.item]:
+			if !done(playlistKey(parsed.item, existsField)) {
+				continue
+			}
+			delete(base, k)
+		case done(k):
+			if v == "" {
+				delete(base, k)
+			} else {
+				base[k] = v
+			}
+		case parsed.field == playedCountField && len(written[k]) == 1:
+			// one side has the merged count, the plays of the other side are in it up to its current count.
+			if c := values[written[k][0].other()][k]; c != "" {
+				base[k] = c
+			} else {
+				delete(base, k)
+			}
+		default:
+			continue
+		}
+		settled[k] = true
+	}
+
+	playlists := make([]pair, 0, len(j.playlists))
+	for _, p := range j.playlists {
+		if !deleted[p] || !settled[playlistKey(p, existsField)] {
+			playlists = append(playlists, p)
+		}
+	}
+
+	changes := make([]change, 0)
+	if err != nil {
+		for _, c := range j.changes {
+			if !settled[c.Key] {
+				changes = append(changes, c)
+			}
+		}
+	}
+
+	j.base = base
+	j.playlists = playlists
+	j.setChanges(changes)
+	return err
+}
+
+func (j *Journal) write(l *library, w write, report *Report) error {
+	id := w.key.item[w.to]
+	if !w.key.isPlaylist {
+		t := l.tracks[id]
+		report.TracksUpdated++
+		switch w.key.field {
+		case ratingField:
+			return t.SetRating(atoi(w.value))
+		case playedCountField:
+			return t.SetPlayedCount(atoi(w.value))
+		}
+		return nil
+	}
+
+	p, ok := l.playlists[id]
+	if !ok {
+		// already deleted on this side.
+		return nil
+	}
+
+	switch w.key.field {
+	case existsField:
+		report.PlaylistsDeleted++
+		delete(l.playlists, id)
+		return p.Delete()
+	case nameField:
+		report.PlaylistsRenamed++
+		return p.SetName(w.value)
+	}
+
+	report.MembershipChanges++
+	if w.value == "" {
+		return p.RemoveTrack(w.key.track[w.to])
+	}
+
+	return p.AddTrack(w.key.track[w.to])
+}
@@ -0,0 +1,320 @@
+package librarysync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type track struct {
+	id, name, artist string
+	duration         time.Duration
+	rating, count    int
+	// err fails the writes.
+	err error
+}
+
+func (t *track) PersistentID() string    { return t.id }
+func (t *track) Name() string            { return t.name }
+func (t *track) Artist() string          { return t.artist }
+func (t *track) Duration() time.Duration { return t.duration }
+func (t *track) Rating() int             { return t.rating }
+func (t *track) PlayedCount() int        { return t.count }
+
+func (t *track) SetRating(rating int) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.rating = rating
+	return nil
+}
+
+func (t *track) SetPlayedCount(count int) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.count = count
+	return nil
+}
+
+type playlist struct {
+	lib     *fakeLibrary
+	id      string
+	name    string
+	members []string
+}
+
+func (p *playlist) PersistentID() string        { return p.id }
+func (p *playlist) Name() string                { return p.name }
+func (p *playlist) TrackIDs() ([]string, error) { return append([]string(nil), p.members...), nil }
+func (p *playlist) SetName(name string) error   { p.name = name; return nil }
+
+func (p *playlist) AddTrack(id string) error {
+	p.members = append(p.members, id)
+	return nil
+}
+
+func (p *playlist) RemoveTrack(id string) error {
+	for i, m := range p.members {
+		if m == id {
+			p.members = append(p.members[:i], p.members[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("not found track:%v", id)
+}
+
+func (p *playlist) Delete() error {
+	for i, pl := range p.lib.playlists {
+		if pl == p {
+			p.lib.playlists = append(p.lib.playlists[:i], p.lib.playlists[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("not found playlist:%v", p.id)
+}
+
+type fakeLibrary struct {
+	prefix    string
+	nextID    int
+	tracks    []*track
+	playlists []*playlist
+	// failCreate is the name of a playlist that cannot be created.
+	failCreate string
+}
+
+func (l *fakeLibrary) id() string {
+	l.nextID++
+	return fmt.Sprintf("%v%d", l.prefix, l.nextID)
+}
+
+func (l *fakeLibrary) addTrack(name, artist string, rating, count int) *track {
+	t := &track{id: l.id(), name: name, artist: artist, duration: 3 * time.Minute, rating: rating, count: count}
+	l.tracks = append(l.tracks, t)
+	return t
+}
+
+func (l *fakeLibrary) Tracks() ([]Track, error) {
+	tracks := make([]Track, len(l.tracks))
+	for i, t := range l.tracks {
+		tracks[i] = t
+	}
+	return tracks, nil
+}
+
+func (l *fakeLibrary) Playlists() ([]Playlist, error) {
+	playlists := make([]Playlist, len(l.playlists))
+	for i, p := range l.playlists {
+		playlists[i] = p
+	}
+	return playlists, nil
+}
+
+func (l *fakeLibrary) CreatePlaylist(name string) (Playlist, error) {
+	if name == l.failCreate {
+		return nil, fmt.Errorf("cannot create playlist:%v", name)
+	}
+	p := &playlist{lib: l, id: l.id(), name: name}
+	l.playlists = append(l.playlists, p)
+	return p, nil
+}
+
+func (l *fakeLibrary) playlist(name string) *playlist {
+	for _, p := range l.playlists {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// names returns the track names of a playlist.
+func (l *fakeLibrary) names(p *playlist) []string {
+	names := make([]string, 0)
+	for _, id := range p.members {
+		for _, t := range l.tracks {
+			if t.id == id {
+				names = append(names, t.name)
+			}
+		}
+	}
+	return names
+}
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.t = c.t.Add(time.Minute)
+	return c.t
+}
+
+func openJournal(t *testing.T, path string) *Journal {
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed.\n%v", err)
+	}
+	j.now = (&clock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}).now
+	return j
+}
+
+func sync(t *testing.T, j *Journal, a, b Library) *Report {
+	report, err := j.Sync(a, b)
+	if err != nil {
+		t.Fatalf("Sync failed.\n%v", err)
+	}
+	return report
+}
+
+func TestSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	a := &fakeLibrary{prefix: "A"}
+	b := &fakeLibrary{prefix: "B"}
+
+	a1 := a.addTrack("One", "Artist", 80, 5)
+	a2 := a.addTrack("Two", "Artist", 0, 2)
+	a.addTrack("Only A", "Artist", 0, 0)
+	b2 := b.addTrack("two", "ARTIST", 60, 3)
+	b1 := b.addTrack("One", "Artist", 0, 1)
+	mix := &playlist{lib: a, id: a.id(), name: "Mix", members: []string{a2.id, a1.id}}
+	a.playlists = append(a.playlists, mix)
+
+	j := openJournal(t, path)
+	report := sync(t, j, a, b)
+	if report.TracksMatched != 2 || report.PlaylistsCreated != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if b1.rating != 80 || a2.rating != 60 || a1.count != 5 || b1.count != 5 || b2.count != 3 {
+		t.Errorf("unexpected tracks: %+v %+v %+v %+v", a1, a2, b1, b2)
+	}
+	if p := b.playlist("Mix"); p == nil || !reflect.DeepEqual(b.names(p), []string{"two", "One"}) {
+		t.Errorf("unexpected playlists of B: %+v", b.playlists)
+	}
+
+	// the journal is reloaded from the file.
+	j = openJournal(t, path)
+	a1.count += 2
+	b1.count++
+	b2.rating = 100
+	bmix := b.playlist("Mix")
+	bmix.name = "Renamed"
+	bmix.RemoveTrack(b1.id)
+	report = sync(t, j, a, b)
+	if a1.count != 8 || b1.count != 8 || a2.rating != 100 {
+		t.Errorf("unexpected tracks: %+v %+v %+v", a1, b1, a2)
+	}
+	if mix.name != "Renamed" || !reflect.DeepEqual(a.names(mix), []string{"Two"}) {
+		t.Errorf("unexpected playlist of A: %+v", mix)
+	}
+	if report.Conflicts != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	mix.Delete()
+	report = sync(t, j, a, b)
+	if len(b.playlists) != 0 || report.PlaylistsDeleted != 1 {
+		t.Errorf("unexpected playlists of B: %+v", b.playlists)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	for _, observeAFirst := range []bool{true, false} {
+		a := &fakeLibrary{prefix: "A"}
+		b := &fakeLibrary{prefix: "B"}
+		ta := a.addTrack("One", "Artist", 20, 0)
+		tb := b.addTrack("One", "Artist", 20, 0)
+
+		j := openJournal(t, filepath.Join(t.TempDir(), "journal.json"))
+		sync(t, j, a, b)
+
+		first, second := Side(A), Side(B)
+		if !observeAFirst {
+			first, second = B, A
+		}
+		libs := [2]*fakeLibrary{a, b}
+		tracks := [2]*track{ta, tb}
+
+		tracks[first].rating = 40
+		err := j.Observe(first, libs[first])
+		if err != nil {
+			t.Fatalf("Observe failed.\n%v", err)
+		}
+		tracks[second].rating = 60
+
+		report := sync(t, j, a, b)
+		if ta.rating != 60 || tb.rating != 60 || report.Conflicts != 1 {
+			t.Errorf("%v changed last: expect 60, but %v %v %+v", second, ta.rating, tb.rating, report)
+		}
+	}
+}
+
+func TestSyncWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	a := &fakeLibrary{prefix: "A"}
+	b := &fakeLibrary{prefix: "B"}
+	ta := a.addTrack("One", "Artist", 0, 5)
+	tb := b.addTrack("One", "Artist", 0, 5)
+
+	j := openJournal(t, path)
+	sync(t, j, a, b)
+
+	// the merged count is written to A, then writing it to B fails.
+	ta.count = 7
+	tb.count = 6
+	tb.err = errors.New("locked")
+	_, err := j.Sync(a, b)
+	if err == nil {
+		t.Fatal("expect the error of the write")
+	}
+	if ta.count != 8 || tb.count != 6 {
+		t.Fatalf("unexpected tracks: %+v %+v", ta, tb)
+	}
+
+	// the plays written to A are not counted again.
+	tb.err = nil
+	j = openJournal(t, path)
+	sync(t, j, a, b)
+	if ta.count != 8 || tb.count != 8 {
+		t.Errorf("expect 8 plays, but %v %v", ta.count, tb.count)
+	}
+
+	// the playlists created before the error are not created again.
+	a.playlists = append(a.playlists, &playlist{lib: a, id: a.id(), name: "First"}, &playlist{lib: a, id: a.id(), name: "Second"})
+	b.failCreate = "Second"
+	_, err = j.Sync(a, b)
+	if err == nil {
+		t.Fatal("expect the error of CreatePlaylist")
+	}
+
+	b.failCreate = ""
+	j = openJournal(t, path)
+	report := sync(t, j, a, b)
+	if len(b.playlists) != 2 || report.PlaylistsCreated != 1 {
+		t.Errorf("unexpected playlists of B: %+v %+v", b.playlists, report)
+	}
+}
+
+func TestOpenInvalidJournal(t *testing.T) {
+	for _, data := range []string{
+		`{"version":1,"base":{"t/A1":"1"}}`,
+		`{"version":1,"base":{"x/A1/B1/rating":"1"}}`,
+		`{"version":1,"changes":[{"side":0,"key":"p/A1/B1/t","value":"1"}]}`,
+		`{"version":1,"changes":[{"side":2,"key":"t/A1/B1/rating","value":"1"}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "journal.json")
+		err := os.WriteFile(path, []byte(data), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = Open(path)
+		if err == nil {
+			t.Errorf("expect an error for %v", data)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package librarysync
+
+import "github.com/yaegaki/itunes-app-interface/remote"
+
+// Remote is the Library of iTunes on another machine, served by the remote package.
+type Remote struct {
+	c *remote.Client
+}
+
+func NewRemote(c *remote.Client) *Remote {
+	return &Remote{c: c}
+}
+
+func (l *Remote) Tracks() ([]Track, error) {
+	tracks, err := l.c.GetAllTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Track, len(tracks))
+	for i, t := range tracks {
+		result[i] = t
+	}
+
+	return result, nil
+}
+
+func (l *Remote) Playlists() ([]Playlist, error) {
+	count, err := l.c.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		p, err := l.c.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.IsUserPlaylist() {
+			continue
+		}
+		result = append(result, &remotePlaylist{c: l.c, p: p})
+	}
+
+	return result, nil
+}
+
+func (l *Remote) CreatePlaylist(name string) (Playlist, error) {
+	p, err := l.c.CreatePlaylist(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remotePlaylist{c: l.c, p: p}, nil
+}
+
+type remotePlaylist struct {
+	c *remote.Client
+	p *remote.Playlist
+}
+
+func (p *remotePlaylist) PersistentID() string {
+	return p.p.PersistentID()
+}
+
+func (p *remotePlaylist) Name() string {
+	return p.p.Name()
+}
+
+func (p *remotePlaylist) TrackIDs() ([]string, error) {
+	ids := make([]string, 0, 100)
+	err := p.p.EachTrack(func(t *remote.Track) error {
+		ids = append(ids, t.PersistentID())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (p *remotePlaylist) SetName(name string) error {
+	return p.p.SetName(name)
+}
+
+func (p *remotePlaylist) AddTrack(trackID string) error {
+	t, err := p.c.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.p.AddTrack(t)
+	return err
+}
+
+func (p *remotePlaylist) RemoveTrack(trackID string) error {
+	t, err := p.c.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return err
+	}
+
+	return p.p.RemoveTrack(t)
+}
+
+func (p *remotePlaylist) Delete() error {
+	return p.p.Delete()
+}
@@ -0,0 +1,345 @@
+package mpd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type command struct {
+	// min and max are the number of arguments, max is -1 if unlimited.
+	min, max int
+	run      func(s *Server, r *response, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"ping":             {0, 0, ping},
+		"commands":         {0, 0, listCommands},
+		"status":           {0, 0, status},
+		"currentsong":      {0, 0, currentSong},
+		"play":             {0, 1, play},
+		"playid":           {0, 1, play},
+		"pause":            {0, 1, pause},
+		"stop":             {0, 0, stop},
+		"next":             {0, 0, next},
+		"previous":         {0, 0, previous},
+		"setvol":           {1, 1, setVolume},
+		"seek":             {2, 2, seek},
+		"seekid":           {2, 2, seek},
+		"seekcur":          {1, 1, seekCurrent},
+		"listplaylists":    {0, 0, listPlaylists},
+		"listplaylistinfo": {1, 1, listPlaylistInfo},
+		"search":           {1, -1, search(true)},
+		"find":             {1, -1, search(false)},
+	}
+}
+
+type response struct {
+	bytes.Buffer
+}
+
+func (r *response) field(key string, value interface{}) {
+	fmt.Fprintf(r, "%v: %v\n", key, value)
+}
+
+// song writes a track, pos is its position in the queue or -1 if it is not queued.
+func (r *response) song(t *Track, pos int) {
+	r.field("file", trackFile(t))
+	if t.Name != "" {
+		r.field("Title", t.Name)
+	}
+	if t.Artist != "" {
+		r.field("Artist", t.Artist)
+	}
+	if t.Album != "" {
+		r.field("Album", t.Album)
+	}
+	if t.Genre != "" {
+		r.field("Genre", t.Genre)
+	}
+	if t.Year != 0 {
+		r.field("Date", t.Year)
+	}
+	r.field("Time", int(t.Duration))
+	r.field("duration", fmt.Sprintf("%.3f", t.Duration))
+	if pos >= 0 {
+		r.field("Pos", pos)
+		r.field("Id", pos)
+	}
+}
+
+// trackFile is the URI of a track, tracks without a file are identified by their persistent ID.
+func trackFile(t *Track) string {
+	if t.Location != "" {
+		return t.Location
+	}
+	return t.PersistentID
+}
+
+func ping(_ *Server, _ *response, _ []string) error {
+	return nil
+}
+
+func listCommands(_ *Server, r *response, _ []string) error {
+	names := []string{"close", "command_list_begin", "command_list_ok_begin", "command_list_end", "idle", "noidle", "password"}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.field("command", name)
+	}
+	return nil
+}
+
+func status(s *Server, r *response, _ []string) error {
+	st, err := s.player.Status()
+	if err != nil {
+		return err
+	}
+
+	r.field("volume", st.Volume)
+	r.field("repeat", 0)
+	r.field("random", 0)
+	r.field("single", 0)
+	r.field("consume", 0)
+	r.field("playlist", 1)
+	if st.Track == nil {
+		r.field("playlistlength", 0)
+		r.field("state", st.State)
+		return nil
+	}
+
+	r.field("playlistlength", 1)
+	r.field("state", st.State)
+	r.field("song", 0)
+	r.field("songid", 0)
+	r.field("time", fmt.Sprintf("%d:%d", int(st.Elapsed), int(st.Track.Duration)))
+	r.field("elapsed", fmt.Sprintf("%.3f", st.Elapsed))
+	r.field("duration", fmt.Sprintf("%.3f", st.Track.Duration))
+	return nil
+}
+
+func currentSong(s *Server, r *response, _ []string) error {
+	st, err := s.player.Status()
+	if err != nil {
+		return err
+	}
+
+	if st.Track != nil {
+		r.song(st.Track, 0)
+	}
+	return nil
+}
+
+// checkSong accepts the position or the ID of the current song, which are both 0.
+func checkSong(arg string) error {
+	if arg != "0" {
+		return argError("Bad song index")
+	}
+	return nil
+}
+
+func play(s *Server, _ *response, args []string) error {
+	if len(args) > 0 {
+		err := checkSong(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.player.Play()
+}
+
+func pause(s *Server, _ *response, args []string) error {
+	if len(args) == 0 {
+		st, err := s.player.Status()
+		if err != nil {
+			return err
+		}
+		if st.State == "play" {
+			return s.player.Pause()
+		}
+		return s.player.Play()
+	}
+
+	switch args[0] {
+	case "1":
+		return s.player.Pause()
+	case "0":
+		return s.player.Play()
+	}
+
+	return argError("Boolean (0/1) expected: %v", args[0])
+}
+
+func stop(s *Server, _ *response, _ []string) error {
+	return s.player.Stop()
+}
+
+func next(s *Server, _ *response, _ []string) error {
+	return s.player.NextTrack()
+}
+
+func previous(s *Server, _ *response, _ []string) error {
+	return s.player.PreviousTrack()
+}
+
+func setVolume(s *Server, _ *response, args []string) error {
+	v, err := strconv.Atoi(args[0])
+	if err != nil || v < 0 || v > 100 {
+		return argError("Invalid volume value: %v", args[0])
+	}
+
+	return s.player.SetSoundVolume(v)
+}
+
+func parseTime(arg string) (float64, error) {
+	t, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, argError("Float expected: %v", arg)
+	}
+	return t, nil
+}
+
+func seek(s *Server, _ *response, args []string) error {
+	err := checkSong(args[0])
+	if err != nil {
+		return err
+	}
+
+	t, err := parseTime(args[1])
+	if err != nil {
+		return err
+	}
+	if t < 0 {
+		return argError("Negative time: %v", args[1])
+	}
+
+	return s.player.SetPosition(t)
+}
+
+// seekCurrent seeks to the time, or by it if it is prefixed by + or -.
+func seekCurrent(s *Server, _ *response, args []string) error {
+	t, err := parseTime(args[0])
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(args[0], "+") || strings.HasPrefix(args[0], "-") {
+		st, err := s.player.Status()
+		if err != nil {
+			return err
+		}
+
+		t += st.Elapsed
+		if t < 0 {
+			t = 0
+		}
+	}
+
+	return s.player.SetPosition(t)
+}
+
+func listPlaylists(s *Server, r *response, _ []string) error {
+	playlists, err := s.player.Playlists()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range playlists {
+		r.field("playlist", p.Name)
+	}
+	return nil
+}
+
+// listPlaylistInfo lists the tracks of a playlist, MPD identifies playlists by name.
+func listPlaylistInfo(s *Server, r *response, args []string) error {
+	playlists, err := s.player.Playlists()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range playlists {
+		if p.Name != args[0] {
+			continue
+		}
+
+		tracks, err := s.player.PlaylistTracks(p.PersistentID)
+		if err != nil {
+			return err
+		}
+
+		for i := range tracks {
+			r.song(&tracks[i], -1)
+		}
+		return nil
+	}
+
+	return &ackError{code: ackNoExist, message: "No such playlist"}
+}
+
+// search returns the handler of search, which ignores case and matches substrings, or of find, which matches exactly.
+func search(fold bool) func(s *Server, r *response, args []string) error {
+	return func(s *Server, r *response, args []string) error {
+		start, end := 0, -1
+		if n := len(args); n >= 2 && args[n-2] == "window" {
+			var err error
+			start, end, err = parseWindow(args[n-1])
+			if err != nil {
+				return err
+			}
+			args = args[:n-2]
+		}
+
+		match, err := parseFilter(args, fold)
+		if err != nil {
+			return err
+		}
+
+		tracks, err := s.player.Tracks()
+		if err != nil {
+			return err
+		}
+
+		n := 0
+		for i := range tracks {
+			if !match(&tracks[i]) {
+				continue
+			}
+			if n >= start && (end < 0 || n < end) {
+				r.song(&tracks[i], -1)
+			}
+			n++
+		}
+		return nil
+	}
+}
+
+func parseWindow(arg string) (int, int, error) {
+	i := strings.Index(arg, ":")
+	if i < 0 {
+		return 0, 0, argError("Invalid window: %v", arg)
+	}
+
+	start, err := strconv.Atoi(arg[:i])
+	if err != nil || start < 0 {
+		return 0, 0, argError("Invalid window: %v", arg)
+	}
+
+	end := -1
+	if arg[i+1:] != "" {
+		end, err = strconv.Atoi(arg[i+1:])
+		if err != nil || end < start {
+			return 0, 0, argError("Invalid window: %v", arg)
+		}
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,242 @@
+package mpd
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+type filter func(t *Track) bool
+
+func and(filters []filter) filter {
+	return func(t *Track) bool {
+		for _, f := range filters {
+			if !f(t) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// tagValues returns the values of a tag of t, "any" is all of them.
+func tagValues(t *Track, tag string) ([]string, bool) {
+	year := ""
+	if t.Year != 0 {
+		year = strconv.Itoa(t.Year)
+	}
+
+	switch strings.ToLower(tag) {
+	case "any":
+		return []string{trackFile(t), t.Name, t.Artist, t.Album, t.Genre, year}, true
+	case "file":
+		return []string{trackFile(t)}, true
+	case "title":
+		return []string{t.Name}, true
+	case "artist":
+		return []string{t.Artist}, true
+	case "album":
+		return []string{t.Album}, true
+	case "genre":
+		return []string{t.Genre}, true
+	case "date":
+		return []string{year}, true
+	}
+
+	return nil, false
+}
+
+// tagFilter matches the tracks having a value of tag for which "value op value" holds, or none for "!=".
+// fold ignores case.
+func tagFilter(tag, op, value string, fold bool) (filter, error) {
+	if _, ok := tagValues(&Track{}, tag); !ok {
+		return nil, argError("Unknown filter type: %v", tag)
+	}
+
+	if fold {
+		value = strings.ToLower(value)
+	}
+
+	var match func(v string) bool
+	switch op {
+	case "==", "!=":
+		match = func(v string) bool { return v == value }
+	case "contains":
+		match = func(v string) bool { return strings.Contains(v, value) }
+	default:
+		return nil, argError("Unknown filter operator: %v", op)
+	}
+
+	return func(t *Track) bool {
+		values, _ := tagValues(t, tag)
+		found := false
+		for _, v := range values {
+			if fold {
+				v = strings.ToLower(v)
+			}
+			if match(v) {
+				found = true
+				break
+			}
+		}
+		return found != (op == "!=")
+	}, nil
+}
+
+// parseFilter parses the filter of search and find.
+// It is either TYPE VALUE pairs or an expression such as "((artist == 'X') AND (title contains 'Y'))".
+// The pairs of search match substrings, the ones of find match exactly.
+func parseFilter(args []string, fold bool) (filter, error) {
+	if len(args) == 0 {
+		return nil, argError("Incorrect number of filter arguments")
+	}
+
+	if strings.HasPrefix(args[0], "(") {
+		if len(args) != 1 {
+			return nil, argError("Incorrect number of filter arguments")
+		}
+
+		p := &exprParser{s: args[0], fold: fold}
+		f, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.i != len(p.s) {
+			return nil, argError("Unparsed garbage after expression")
+		}
+		return f, nil
+	}
+
+	if len(args)%2 != 0 {
+		return nil, argError("Incorrect number of filter arguments")
+	}
+
+	op := "=="
+	if fold {
+		op = "contains"
+	}
+
+	var filters []filter
+	for i := 0; i < len(args); i += 2 {
+		f, err := tagFilter(args[i], op, args[i+1], fold)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	return and(filters), nil
+}
+
+type exprParser struct {
+	s    string
+	i    int
+	fold bool
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.i < len(p.s) && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *exprParser) peek(c byte) bool {
+	return p.i < len(p.s) && p.s[p.i] == c
+}
+
+func (p *exprParser) expect(c byte) error {
+	if !p.peek(c) {
+		return argError("'%c' expected", c)
+	}
+	p.i++
+	return nil
+}
+
+func (p *exprParser) word() string {
+	start := p.i
+	for p.i < len(p.s) && p.s[p.i] != ' ' && p.s[p.i] != ')' {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+func (p *exprParser) quoted() (string, error) {
+	if !p.peek('\'') && !p.peek('"') {
+		return "", argError("Quoted string expected")
+	}
+
+	quote := p.s[p.i]
+	var buf bytes.Buffer
+	for p.i++; p.i < len(p.s) && p.s[p.i] != quote; p.i++ {
+		if p.s[p.i] == '\\' && p.i+1 < len(p.s) {
+			p.i++
+		}
+		buf.WriteByte(p.s[p.i])
+	}
+	if p.i == len(p.s) {
+		return "", argError("Closing quote not found")
+	}
+	p.i++
+
+	return buf.String(), nil
+}
+
+func (p *exprParser) expr() (filter, error) {
+	p.skipSpaces()
+	err := p.expect('(')
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+
+	var f filter
+	switch {
+	case p.peek('!'):
+		p.i++
+		sub, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		f = func(t *Track) bool { return !sub(t) }
+	case p.peek('('):
+		var filters []filter
+		for {
+			sub, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, sub)
+
+			p.skipSpaces()
+			if p.peek(')') {
+				break
+			}
+			if w := p.word(); w != "AND" {
+				return nil, argError("AND expected: %v", w)
+			}
+		}
+		f = and(filters)
+	default:
+		tag := p.word()
+		p.skipSpaces()
+		op := p.word()
+		p.skipSpaces()
+		value, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		f, err = tagFilter(tag, op, value, p.fold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.skipSpaces()
+	err = p.expect(')')
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,199 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesPlayer struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesPlayer returns the Player of an iTunes application.
+func NewItunesPlayer(it *itunes.Itunes) Player {
+	return &itunesPlayer{it: it}
+}
+
+// track is a track of the local or the remote iTunes.
+type track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Album() string
+	Genre() string
+	Location() string
+	Year() int
+	Duration() time.Duration
+}
+
+func newTrack(t track) Track {
+	return Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Genre:        t.Genre(),
+		Location:     t.Location(),
+		Year:         t.Year(),
+		Duration:     t.Duration().Seconds(),
+	}
+}
+
+func collectTracks(output chan *itunes.Track) []Track {
+	var tracks []Track
+	for t := range output {
+		tracks = append(tracks, newTrack(t))
+		t.Close()
+	}
+	return tracks
+}
+
+func (p *itunesPlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	volume, err := p.it.SoundVolume()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: "stop", Volume: volume}
+	t, err := p.it.CurrentTrack()
+	if errors.Is(err, itunes.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	track := newTrack(t)
+	st.Track = &track
+
+	// iTunes reports a paused player as stopped, but keeps its current track.
+	st.State = "pause"
+	if state != itunes.Stopped {
+		st.State = "play"
+	}
+
+	pos, err := p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+	st.Elapsed = pos.Seconds()
+
+	return st, nil
+}
+
+func (p *itunesPlayer) Play() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Play()
+}
+
+func (p *itunesPlayer) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Pause()
+}
+
+func (p *itunesPlayer) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Stop()
+}
+
+func (p *itunesPlayer) NextTrack() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.NextTrack()
+}
+
+func (p *itunesPlayer) PreviousTrack() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.PreviousTrack()
+}
+
+func (p *itunesPlayer) SetSoundVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SetSoundVolume(volume)
+}
+
+func (p *itunesPlayer) SetPosition(seconds float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SetPosition(time.Duration(seconds * float64(time.Second)))
+}
+
+func (p *itunesPlayer) Playlists() ([]Playlist, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count, err := p.it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := p.it.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, Playlist{PersistentID: pl.PersistentID(), Name: pl.Name()})
+		pl.Close()
+	}
+
+	return playlists, nil
+}
+
+func (p *itunesPlayer) PlaylistTracks(playlistID string) ([]Track, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		var notFound *itunes.NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+		}
+		return nil, err
+	}
+	defer pl.Close()
+
+	output, err := pl.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	return collectTracks(output), nil
+}
+
+func (p *itunesPlayer) Tracks() ([]Track, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	output, err := p.it.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	return collectTracks(output), nil
+}
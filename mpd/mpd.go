@@ -0,0 +1,420 @@
+// Package mpd serves the player over the protocol of the Music Player Daemon,
+// so that MPD clients such as ncmpcpp or MPDroid can control it.
+//
+// The server implements what a remote control needs: the status, playback, volume, seeking,
+// the stored playlists and the library search.
+// The current track is reported as the only song of the queue.
+//
+// If the server has a password, a client can only ping until it sends the password command.
+// The password goes over the connection in clear text like with MPD itself.
+package mpd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is wrapped by the Player errors for a missing playlist.
+var ErrNotFound = errors.New("not found")
+
+type Track struct {
+	PersistentID string
+	Name         string
+	Artist       string
+	Album        string
+	Genre        string
+	// Location is the path of the file, empty if the track is not a file.
+	Location string
+	Year     int
+	// Duration is in seconds.
+	Duration float64
+}
+
+type Status struct {
+	// State is "play", "pause" or "stop".
+	State  string
+	Volume int
+	// Elapsed is in seconds.
+	Elapsed float64
+	// Track is nil if the player has no track.
+	Track *Track
+}
+
+type Playlist struct {
+	PersistentID string
+	Name         string
+}
+
+// Player is what the server controls, NewItunesPlayer implements it for iTunes.
+type Player interface {
+	Status() (*Status, error)
+	Play() error
+	Pause() error
+	Stop() error
+	NextTrack() error
+	PreviousTrack() error
+	SetSoundVolume(volume int) error
+	// SetPosition seeks the current track to seconds.
+	SetPosition(seconds float64) error
+
+	Playlists() ([]Playlist, error)
+	PlaylistTracks(playlistID string) ([]Track, error)
+	// Tracks returns the tracks of the library for search and find.
+	Tracks() ([]Track, error)
+}
+
+const (
+	protocolVersion = "0.23.0"
+	// idle clients are notified of the changes found by polling the player at this interval.
+	pollInterval = time.Second
+	maxLineSize  = 64 * 1024
+)
+
+// the error codes of ACK responses.
+const (
+	ackArg        = 2
+	ackPassword   = 3
+	ackPermission = 4
+	ackUnknown    = 5
+	ackNoExist    = 50
+	ackSystem     = 52
+)
+
+type ackError struct {
+	code    int
+	message string
+}
+
+func (e *ackError) Error() string {
+	return e.message
+}
+
+func argError(format string, args ...interface{}) error {
+	return &ackError{code: ackArg, message: fmt.Sprintf(format, args...)}
+}
+
+type Server struct {
+	player   Player
+	password string
+	interval time.Duration
+}
+
+// New returns a server of player, the clients must send password before the other commands unless it is empty.
+func New(player Player, password string) *Server {
+	return &Server{player: player, password: password, interval: pollInterval}
+}
+
+// ListenAndServe listens on the TCP address addr, usually port 6600, and serves the clients.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve serves the clients accepted by l until l fails.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.serveConn(c)
+	}
+}
+
+type conn struct {
+	s     *Server
+	w     *bufio.Writer
+	lines chan string
+	done  chan struct{}
+	// authorized is true once the client sent the password, or from the start if the server has none.
+	authorized bool
+}
+
+func (c *conn) read(rw net.Conn) {
+	defer close(c.lines)
+	sc := bufio.NewScanner(rw)
+	sc.Buffer(make([]byte, 4096), maxLineSize)
+	for sc.Scan() {
+		select {
+		case c.lines <- sc.Text():
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (s *Server) serveConn(rw net.Conn) {
+	defer rw.Close()
+	c := &conn{
+		s:          s,
+		authorized: s.password == "",
+		w:          bufio.NewWriter(rw),
+		lines:      make(chan string),
+		done:       make(chan struct{}),
+	}
+	defer close(c.done)
+	go c.read(rw)
+
+	fmt.Fprintf(c.w, "OK MPD %v\n", protocolVersion)
+	for c.w.Flush() == nil {
+		line, ok := <-c.lines
+		if !ok || !c.handle(line) {
+			return
+		}
+	}
+}
+
+// handle processes a request, it returns false to close the connection.
+func (c *conn) handle(line string) bool {
+	args, err := splitArgs(line)
+	if err != nil {
+		c.ack(0, "", err)
+		return true
+	}
+	if len(args) == 0 {
+		c.ack(0, "", &ackError{code: ackUnknown, message: "No command given"})
+		return true
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "close":
+		return false
+	case "noidle":
+		// not idling, there is nothing to cancel.
+		return true
+	case "idle":
+		if !c.allowed(0, name) {
+			return true
+		}
+		return c.idle(args)
+	case "command_list_begin", "command_list_ok_begin":
+		return c.commandList(name == "command_list_ok_begin")
+	}
+
+	if c.run(0, name, args) {
+		c.w.WriteString("OK\n")
+	}
+	return true
+}
+
+func (c *conn) ack(index int, name string, err error) {
+	code := ackSystem
+	var ae *ackError
+	switch {
+	case errors.As(err, &ae):
+		code = ae.code
+	case errors.Is(err, ErrNotFound):
+		code = ackNoExist
+	}
+
+	fmt.Fprintf(c.w, "ACK [%d@%d] {%v} %v\n", code, index, name, err)
+}
+
+// allowed acks the command at index and returns false if the client has not sent the password yet.
+func (c *conn) allowed(index int, name string) bool {
+	if c.authorized || name == "ping" {
+		return true
+	}
+
+	c.ack(index, name, &ackError{code: ackPermission, message: fmt.Sprintf("you don't have permission for \"%v\"", name)})
+	return false
+}
+
+// login checks the argument of the password command.
+func (c *conn) login(index int, args []string) bool {
+	if len(args) != 1 {
+		c.ack(index, "password", argError("wrong number of arguments for \"password\""))
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(args[0]), []byte(c.s.password)) != 1 {
+		c.ack(index, "password", &ackError{code: ackPassword, message: "incorrect password"})
+		return false
+	}
+
+	c.authorized = true
+	return true
+}
+
+// run runs the command at index of a command list and writes the response without the final OK.
+// It returns false if the command failed.
+func (c *conn) run(index int, name string, args []string) bool {
+	if name == "password" {
+		return c.login(index, args)
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		c.ack(index, "", &ackError{code: ackUnknown, message: fmt.Sprintf("unknown command \"%v\"", name)})
+		return false
+	}
+
+	if !c.allowed(index, name) {
+		return false
+	}
+
+	if len(args) < cmd.min || (cmd.max >= 0 && len(args) > cmd.max) {
+		c.ack(index, name, argError("wrong number of arguments for \"%v\"", name))
+		return false
+	}
+
+	var r response
+	err := cmd.run(c.s, &r, args)
+	if err != nil {
+		c.ack(index, name, err)
+		return false
+	}
+
+	c.w.Write(r.Bytes())
+	return true
+}
+
+func (c *conn) commandList(listOK bool) bool {
+	var list [][]string
+	for {
+		line, ok := <-c.lines
+		if !ok {
+			return false
+		}
+		if line == "command_list_end" {
+			break
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			c.ack(len(list), "", err)
+			return true
+		}
+		if len(args) == 0 {
+			c.ack(len(list), "", &ackError{code: ackUnknown, message: "No command given"})
+			return true
+		}
+		list = append(list, args)
+	}
+
+	for i, args := range list {
+		if !c.run(i, args[0], args[1:]) {
+			return true
+		}
+		if listOK {
+			c.w.WriteString("list_OK\n")
+		}
+	}
+	c.w.WriteString("OK\n")
+	return true
+}
+
+// idle waits until the player or the mixer changes, or the client sends noidle.
+func (c *conn) idle(args []string) bool {
+	subsystems := map[string]bool{}
+	for _, arg := range args {
+		subsystems[arg] = true
+	}
+	watch := func(subsystem string) bool {
+		return len(subsystems) == 0 || subsystems[subsystem]
+	}
+
+	last, err := c.s.player.Status()
+	if err != nil {
+		c.ack(0, "idle", err)
+		return true
+	}
+	c.w.Flush()
+
+	ticker := time.NewTicker(c.s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return false
+			}
+			// any command other than noidle while idling is a protocol violation.
+			if strings.TrimSpace(line) != "noidle" {
+				return false
+			}
+			c.w.WriteString("OK\n")
+			return true
+		case <-ticker.C:
+		}
+
+		st, err := c.s.player.Status()
+		if err != nil {
+			continue
+		}
+
+		var changed []string
+		if watch("player") && (st.State != last.State || trackID(st.Track) != trackID(last.Track)) {
+			changed = append(changed, "player")
+		}
+		if watch("mixer") && st.Volume != last.Volume {
+			changed = append(changed, "mixer")
+		}
+		last = st
+		if len(changed) == 0 {
+			continue
+		}
+
+		for _, subsystem := range changed {
+			fmt.Fprintf(c.w, "changed: %v\n", subsystem)
+		}
+		c.w.WriteString("OK\n")
+		return true
+	}
+}
+
+func trackID(t *Track) string {
+	if t == nil {
+		return ""
+	}
+	return t.PersistentID
+}
+
+// splitArgs splits a request line into the command and its arguments.
+// Arguments with spaces are quoted by double quotes, in which backslash escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t':
+			i++
+			continue
+		case '"':
+			var buf bytes.Buffer
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				buf.WriteByte(line[i])
+			}
+			if i == len(line) {
+				return nil, argError("Missing closing '\"'")
+			}
+			args = append(args, buf.String())
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		args = append(args, line[start:i])
+	}
+
+	return args, nil
+}
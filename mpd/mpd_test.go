@@ -0,0 +1,282 @@
+package mpd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakePlayer struct {
+	mu        sync.Mutex
+	status    Status
+	calls     []string
+	playlists []Playlist
+	tracks    []Track
+}
+
+func (p *fakePlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.status
+	return &st, nil
+}
+
+func (p *fakePlayer) call(format string, args ...interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (p *fakePlayer) Play() error                       { return p.call("play") }
+func (p *fakePlayer) Pause() error                      { return p.call("pause") }
+func (p *fakePlayer) Stop() error                       { return p.call("stop") }
+func (p *fakePlayer) NextTrack() error                  { return p.call("next") }
+func (p *fakePlayer) PreviousTrack() error              { return p.call("previous") }
+func (p *fakePlayer) SetSoundVolume(volume int) error   { return p.call("volume %v", volume) }
+func (p *fakePlayer) SetPosition(seconds float64) error { return p.call("position %v", seconds) }
+
+func (p *fakePlayer) Playlists() ([]Playlist, error) {
+	return p.playlists, nil
+}
+
+func (p *fakePlayer) PlaylistTracks(playlistID string) ([]Track, error) {
+	if playlistID != "P1" {
+		return nil, fmt.Errorf("%w: playlist:%v", ErrNotFound, playlistID)
+	}
+	return p.tracks[:2], nil
+}
+
+func (p *fakePlayer) Tracks() ([]Track, error) {
+	return p.tracks, nil
+}
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// request sends lines and returns the response up to OK or ACK.
+func (c *client) request(lines ...string) string {
+	c.t.Helper()
+	_, err := fmt.Fprint(c.conn, strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	return c.response()
+}
+
+func (c *client) response() string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var b strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("%v after %q", err, b.String())
+		}
+		b.WriteString(line)
+		if line == "OK\n" || strings.HasPrefix(line, "ACK ") {
+			return b.String()
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*fakePlayer, *client) {
+	return newTestServerWithPassword(t, "")
+}
+
+func newTestServerWithPassword(t *testing.T, password string) (*fakePlayer, *client) {
+	p := &fakePlayer{
+		status: Status{State: "play", Volume: 30, Elapsed: 12.5},
+		tracks: []Track{
+			{PersistentID: "T1", Name: "Help!", Artist: "The Beatles", Album: "Help!", Genre: "Rock", Year: 1965, Duration: 138, Location: "/music/help.m4a"},
+			{PersistentID: "T2", Name: "Yesterday", Artist: "The Beatles", Album: "Help!", Duration: 125},
+			{PersistentID: "T3", Name: "Help Me", Artist: "Joni Mitchell", Album: "Court and Spark", Duration: 222},
+		},
+		playlists: []Playlist{{PersistentID: "P1", Name: "Road Trip"}},
+	}
+	p.status.Track = &p.tracks[0]
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(p, password)
+	s.interval = 10 * time.Millisecond
+	go s.Serve(l)
+	t.Cleanup(func() { l.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(greeting, "OK MPD ") {
+		t.Fatalf("unexpected greeting: %q %v", greeting, err)
+	}
+
+	return p, c
+}
+
+func TestCommands(t *testing.T) {
+	p, c := newTestServer(t)
+
+	tests := []struct {
+		request, expect string
+	}{
+		{"ping", "OK\n"},
+		{"status", "volume: 30\nrepeat: 0\nrandom: 0\nsingle: 0\nconsume: 0\nplaylist: 1\nplaylistlength: 1\nstate: play\nsong: 0\nsongid: 0\ntime: 12:138\nelapsed: 12.500\nduration: 138.000\nOK\n"},
+		{"currentsong", "file: /music/help.m4a\nTitle: Help!\nArtist: The Beatles\nAlbum: Help!\nGenre: Rock\nDate: 1965\nTime: 138\nduration: 138.000\nPos: 0\nId: 0\nOK\n"},
+		{"pause", "OK\n"},
+		{"pause 0", "OK\n"},
+		{"pause 2", "ACK [2@0] {pause} Boolean (0/1) expected: 2\n"},
+		{"next", "OK\n"},
+		{"previous", "OK\n"},
+		{"play 0", "OK\n"},
+		{"play 3", "ACK [2@0] {play} Bad song index\n"},
+		{"setvol 70", "OK\n"},
+		{"setvol 101", "ACK [2@0] {setvol} Invalid volume value: 101\n"},
+		{"setvol", "ACK [2@0] {setvol} wrong number of arguments for \"setvol\"\n"},
+		{"seek 0 30", "OK\n"},
+		{"seekcur -2.5", "OK\n"},
+		{"listplaylists", "playlist: Road Trip\nOK\n"},
+		{`listplaylistinfo "Road Trip"`, "file: /music/help.m4a\nTitle: Help!\nArtist: The Beatles\nAlbum: Help!\nGenre: Rock\nDate: 1965\nTime: 138\nduration: 138.000\nfile: T2\nTitle: Yesterday\nArtist: The Beatles\nAlbum: Help!\nTime: 125\nduration: 125.000\nOK\n"},
+		{"listplaylistinfo Nothing", "ACK [50@0] {listplaylistinfo} No such playlist\n"},
+		{"unknown", "ACK [5@0] {} unknown command \"unknown\"\n"},
+		{`find title "help"`, "OK\n"},
+	}
+
+	for _, test := range tests {
+		res := c.request(test.request)
+		if res != test.expect {
+			t.Errorf("%v: expect %q, but %q", test.request, test.expect, res)
+		}
+	}
+
+	expect := "pause,play,next,previous,play,volume 70,position 30,position 10"
+	if strings.Join(p.calls, ",") != expect {
+		t.Errorf("expect %v, but %v", expect, strings.Join(p.calls, ","))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	_, c := newTestServer(t)
+
+	tests := []struct {
+		request string
+		expect  []string
+	}{
+		{`search title "help"`, []string{"Help!", "Help Me"}},
+		{`search artist beatles title YES`, []string{"Yesterday"}},
+		{`search any "spark"`, []string{"Help Me"}},
+		{`search title help window 1:2`, []string{"Help Me"}},
+		{`find title "Help!"`, []string{"Help!"}},
+		{`find artist "the beatles"`, nil},
+		{`find "((artist == 'The Beatles') AND (title contains 'Yes'))"`, []string{"Yesterday"}},
+		{`find "(!(artist == 'The Beatles'))"`, []string{"Help Me"}},
+		{`search "(album != 'help!')"`, []string{"Help Me"}},
+		{`find "(date == \"1965\")"`, []string{"Help!"}},
+	}
+
+	for _, test := range tests {
+		res := c.request(test.request)
+		var titles []string
+		for _, line := range strings.Split(res, "\n") {
+			if strings.HasPrefix(line, "Title: ") {
+				titles = append(titles, strings.TrimPrefix(line, "Title: "))
+			}
+		}
+		if !strings.HasSuffix(res, "OK\n") || strings.Join(titles, ",") != strings.Join(test.expect, ",") {
+			t.Errorf("%v: expect %v, but %q", test.request, test.expect, res)
+		}
+	}
+
+	for _, request := range []string{`find title`, `search bitrate 1`, `find "(title = 'x')"`, `find "(title == 'x'"`, `search window 0:1`} {
+		res := c.request(request)
+		if !strings.HasPrefix(res, "ACK [2@0]") {
+			t.Errorf("%v: expect an argument error, but %q", request, res)
+		}
+	}
+}
+
+func TestCommandList(t *testing.T) {
+	p, c := newTestServer(t)
+
+	res := c.request("command_list_ok_begin", "setvol 40", "ping", "command_list_end")
+	if res != "list_OK\nlist_OK\nOK\n" {
+		t.Errorf("unexpected response: %q", res)
+	}
+
+	res = c.request("command_list_begin", "next", "setvol x", "previous", "command_list_end")
+	if res != "ACK [2@1] {setvol} Invalid volume value: x\n" {
+		t.Errorf("unexpected response: %q", res)
+	}
+
+	if strings.Join(p.calls, ",") != "volume 40,next" {
+		t.Errorf("unexpected calls: %v", p.calls)
+	}
+}
+
+func TestIdle(t *testing.T) {
+	p, c := newTestServer(t)
+
+	fmt.Fprint(c.conn, "idle\n")
+	time.Sleep(30 * time.Millisecond)
+	p.mu.Lock()
+	p.status.Volume = 50
+	p.mu.Unlock()
+	res := c.response()
+	if res != "changed: mixer\nOK\n" {
+		t.Errorf("unexpected response: %q", res)
+	}
+
+	fmt.Fprint(c.conn, "idle player\n")
+	time.Sleep(30 * time.Millisecond)
+	p.mu.Lock()
+	p.status.Volume = 60
+	p.mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+	res = c.request("noidle")
+	if res != "OK\n" {
+		t.Errorf("expect no change of player, but %q", res)
+	}
+}
+
+func TestPassword(t *testing.T) {
+	p, c := newTestServerWithPassword(t, "secret")
+
+	tests := []struct {
+		request, expect string
+	}{
+		{"ping", "OK\n"},
+		{"status", "ACK [4@0] {status} you don't have permission for \"status\"\n"},
+		{"idle", "ACK [4@0] {idle} you don't have permission for \"idle\"\n"},
+		{"pause", "ACK [4@0] {pause} you don't have permission for \"pause\"\n"},
+		{"password", "ACK [2@0] {password} wrong number of arguments for \"password\"\n"},
+		{"password wrong", "ACK [3@0] {password} incorrect password\n"},
+		{"command_list_begin\npassword secret\npause\ncommand_list_end", "OK\n"},
+		{"next", "OK\n"},
+	}
+
+	for _, test := range tests {
+		res := c.request(test.request)
+		if res != test.expect {
+			t.Errorf("%v: expect %q, but %q", test.request, test.expect, res)
+		}
+	}
+
+	expect := "pause,next"
+	if strings.Join(p.calls, ",") != expect {
+		t.Errorf("expect %v, but %v", expect, strings.Join(p.calls, ","))
+	}
+}
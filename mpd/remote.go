@@ -0,0 +1,122 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package mpd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+type remotePlayer struct {
+	c *remote.Client
+}
+
+// NewRemotePlayer returns the Player of iTunes on another machine, served by the remote package.
+func NewRemotePlayer(c *remote.Client) Player {
+	return &remotePlayer{c: c}
+}
+
+func (p *remotePlayer) Status() (*Status, error) {
+	state, err := p.c.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	volume, err := p.c.SoundVolume()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: "stop", Volume: volume}
+	t, err := p.c.CurrentTrack()
+	if errors.Is(err, remote.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	track := newTrack(t)
+	st.Track = &track
+
+	// iTunes reports a paused player as stopped, but keeps its current track.
+	st.State = "pause"
+	if state != itunes.Stopped {
+		st.State = "play"
+	}
+
+	pos, err := p.c.Position()
+	if err != nil {
+		return nil, err
+	}
+	st.Elapsed = pos.Seconds()
+
+	return st, nil
+}
+
+func (p *remotePlayer) Play() error          { return p.c.Play() }
+func (p *remotePlayer) Pause() error         { return p.c.Pause() }
+func (p *remotePlayer) Stop() error          { return p.c.Stop() }
+func (p *remotePlayer) NextTrack() error     { return p.c.NextTrack() }
+func (p *remotePlayer) PreviousTrack() error { return p.c.PreviousTrack() }
+
+func (p *remotePlayer) SetSoundVolume(volume int) error {
+	return p.c.SetSoundVolume(volume)
+}
+
+func (p *remotePlayer) SetPosition(seconds float64) error {
+	return p.c.SetPosition(time.Duration(seconds * float64(time.Second)))
+}
+
+func (p *remotePlayer) Playlists() ([]Playlist, error) {
+	count, err := p.c.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := p.c.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, Playlist{PersistentID: pl.PersistentID(), Name: pl.Name()})
+	}
+
+	return playlists, nil
+}
+
+func collectRemoteTracks(each func(fn func(*remote.Track) error) error) ([]Track, error) {
+	var tracks []Track
+	err := each(func(t *remote.Track) error {
+		tracks = append(tracks, newTrack(t))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (p *remotePlayer) PlaylistTracks(playlistID string) ([]Track, error) {
+	pl, err := p.c.FindPlaylistByPersistentID(playlistID)
+	if errors.Is(err, remote.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return collectRemoteTracks(pl.EachTrack)
+}
+
+func (p *remotePlayer) Tracks() ([]Track, error) {
+	return collectRemoteTracks(p.c.EachTrack)
+}
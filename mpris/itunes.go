@@ -0,0 +1,119 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package mpris
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesPlayer struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesPlayer returns the Player of an iTunes application.
+func NewItunesPlayer(it *itunes.Itunes) Player {
+	return &itunesPlayer{it: it}
+}
+
+func (p *itunesPlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	volume, err := p.it.SoundVolume()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: "Stopped", Volume: volume}
+	t, err := p.it.CurrentTrack()
+	if errors.Is(err, itunes.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	st.Track = &Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Genre:        t.Genre(),
+		Duration:     t.Duration().Seconds(),
+	}
+
+	// iTunes reports a paused player as stopped, but keeps its current track.
+	st.State = "Paused"
+	if state != itunes.Stopped {
+		st.State = "Playing"
+	}
+
+	pos, err := p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+	st.Position = pos.Seconds()
+
+	return st, nil
+}
+
+func (p *itunesPlayer) Play() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Play()
+}
+
+func (p *itunesPlayer) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Pause()
+}
+
+func (p *itunesPlayer) PlayPause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.PlayPause()
+}
+
+func (p *itunesPlayer) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Stop()
+}
+
+func (p *itunesPlayer) NextTrack() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.NextTrack()
+}
+
+func (p *itunesPlayer) PreviousTrack() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.PreviousTrack()
+}
+
+func (p *itunesPlayer) SetSoundVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SetSoundVolume(volume)
+}
+
+func (p *itunesPlayer) SetPosition(seconds float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SetPosition(time.Duration(seconds * float64(time.Second)))
+}
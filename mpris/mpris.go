@@ -0,0 +1,396 @@
+// Package mpris exports a player on D-Bus as an MPRIS2 media player,
+// so that Linux desktops show and control it like a local one.
+//
+// The bridge implements org.mpris.MediaPlayer2 and org.mpris.MediaPlayer2.Player.
+// It polls the player and emits PropertiesChanged for the state, the track and the volume.
+package mpris
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+type Track struct {
+	PersistentID string
+	Name         string
+	Artist       string
+	Album        string
+	Genre        string
+	// Duration is in seconds.
+	Duration float64
+}
+
+type Status struct {
+	// State is "Playing", "Paused" or "Stopped".
+	State  string
+	Volume int
+	// Position is in seconds.
+	Position float64
+	// Track is nil if the player has no track.
+	Track *Track
+}
+
+// Player is what the bridge exposes, NewItunesPlayer implements it for iTunes.
+type Player interface {
+	Status() (*Status, error)
+	Play() error
+	Pause() error
+	PlayPause() error
+	Stop() error
+	NextTrack() error
+	PreviousTrack() error
+	SetSoundVolume(volume int) error
+	// SetPosition seeks the current track to seconds.
+	SetPosition(seconds float64) error
+}
+
+const (
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	busPrefix   = "org.mpris.MediaPlayer2."
+	trackPrefix = "/org/mpris/MediaPlayer2/Track/"
+	noTrack     = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+	identity    = "iTunes"
+
+	pollInterval = time.Second
+	// a position further than this from the expected one is reported as a seek.
+	seekThreshold = 2.0
+)
+
+// Bridge is a player exported on a bus.
+type Bridge struct {
+	conn   *dbus.Conn
+	name   string
+	player Player
+	props  *prop.Properties
+
+	mu       sync.Mutex
+	last     *Status
+	lastTime time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Export exports player on conn and owns the bus name org.mpris.MediaPlayer2.<name>.
+// It fails if another player owns the name.
+func Export(conn *dbus.Conn, name string, player Player) (*Bridge, error) {
+	return export(conn, name, player, pollInterval)
+}
+
+func export(conn *dbus.Conn, name string, player Player, interval time.Duration) (*Bridge, error) {
+	st, err := player.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bridge{
+		conn:     conn,
+		name:     busPrefix + name,
+		player:   player,
+		last:     st,
+		lastTime: time.Now(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	b.props, err = prop.Export(conn, objectPath, prop.Map{
+		rootIface: {
+			"CanQuit":             {Value: false, Emit: prop.EmitConst},
+			"CanRaise":            {Value: false, Emit: prop.EmitConst},
+			"HasTrackList":        {Value: false, Emit: prop.EmitConst},
+			"Identity":            {Value: identity, Emit: prop.EmitConst},
+			"SupportedUriSchemes": {Value: []string{}, Emit: prop.EmitConst},
+			"SupportedMimeTypes":  {Value: []string{}, Emit: prop.EmitConst},
+		},
+		playerIface: {
+			"PlaybackStatus": {Value: st.State, Emit: prop.EmitTrue},
+			"Metadata":       {Value: metadata(st.Track), Emit: prop.EmitTrue},
+			"Volume":         {Value: float64(st.Volume) / 100, Writable: true, Emit: prop.EmitTrue, Callback: b.setVolume},
+			"Position":       {Value: microseconds(st.Position), Emit: prop.EmitFalse},
+			"Rate":           {Value: 1.0, Emit: prop.EmitConst},
+			"MinimumRate":    {Value: 1.0, Emit: prop.EmitConst},
+			"MaximumRate":    {Value: 1.0, Emit: prop.EmitConst},
+			"CanGoNext":      {Value: true, Emit: prop.EmitConst},
+			"CanGoPrevious":  {Value: true, Emit: prop.EmitConst},
+			"CanPlay":        {Value: true, Emit: prop.EmitConst},
+			"CanPause":       {Value: true, Emit: prop.EmitConst},
+			"CanSeek":        {Value: st.Track != nil, Emit: prop.EmitTrue},
+			"CanControl":     {Value: true, Emit: prop.EmitConst},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.Export(rootObject{}, objectPath, rootIface)
+	if err == nil {
+		err = conn.ExportWithMap(playerObject{b}, playerMethods, objectPath, playerIface)
+	}
+	if err == nil {
+		err = conn.Export(b.introspectable(), objectPath, "org.freedesktop.DBus.Introspectable")
+	}
+	if err != nil {
+		b.unexport()
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(b.name, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New(fmt.Sprintf("%v is owned by another player", b.name))
+	}
+	if err != nil {
+		b.unexport()
+		return nil, err
+	}
+
+	go b.poll(interval)
+	return b, nil
+}
+
+// playerMethods maps the Go names of the methods which differ from the D-Bus ones.
+// Seek is not a Go name because vet expects it to be io.Seeker.
+var playerMethods = map[string]string{"SeekBy": "Seek"}
+
+func (b *Bridge) introspectable() introspect.Introspectable {
+	methods := introspect.Methods(playerObject{})
+	for i := range methods {
+		if name, ok := playerMethods[methods[i].Name]; ok {
+			methods[i].Name = name
+		}
+	}
+
+	return introspect.NewIntrospectable(&introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{Name: rootIface, Methods: introspect.Methods(rootObject{}), Properties: b.props.Introspection(rootIface)},
+			{
+				Name:       playerIface,
+				Methods:    methods,
+				Properties: b.props.Introspection(playerIface),
+				Signals:    []introspect.Signal{{Name: "Seeked", Args: []introspect.Arg{{Name: "Position", Type: "x"}}}},
+			},
+		},
+	})
+}
+
+func (b *Bridge) unexport() {
+	for _, iface := range []string{rootIface, playerIface, "org.freedesktop.DBus.Properties", "org.freedesktop.DBus.Introspectable"} {
+		b.conn.Export(nil, objectPath, iface)
+	}
+}
+
+// Close stops the bridge and releases the bus name, the connection stays open.
+func (b *Bridge) Close() error {
+	close(b.stop)
+	<-b.done
+	b.unexport()
+	_, err := b.conn.ReleaseName(b.name)
+	return err
+}
+
+func microseconds(seconds float64) int64 {
+	return int64(seconds * 1e6)
+}
+
+func trackPath(t *Track) dbus.ObjectPath {
+	if t == nil {
+		return noTrack
+	}
+	// persistent IDs are hexadecimal, which is valid in object paths.
+	return dbus.ObjectPath(trackPrefix + t.PersistentID)
+}
+
+func metadata(t *Track) map[string]dbus.Variant {
+	m := map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(trackPath(t))}
+	if t == nil {
+		return m
+	}
+
+	m["mpris:length"] = dbus.MakeVariant(microseconds(t.Duration))
+	m["xesam:title"] = dbus.MakeVariant(t.Name)
+	m["xesam:album"] = dbus.MakeVariant(t.Album)
+	if t.Artist != "" {
+		m["xesam:artist"] = dbus.MakeVariant([]string{t.Artist})
+	}
+	if t.Genre != "" {
+		m["xesam:genre"] = dbus.MakeVariant([]string{t.Genre})
+	}
+	return m
+}
+
+func (b *Bridge) poll(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.update(false)
+		}
+	}
+}
+
+// update reads the status of the player and emits the changes, seeked is true after a seek by the bridge.
+func (b *Bridge) update(seeked bool) {
+	st, err := b.player.Status()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	last := b.last
+	now := time.Now()
+	sameTrack := (st.Track == nil && last.Track == nil) || (st.Track != nil && last.Track != nil && *st.Track == *last.Track)
+	if !sameTrack {
+		b.props.SetMust(playerIface, "Metadata", metadata(st.Track))
+		b.props.SetMust(playerIface, "CanSeek", st.Track != nil)
+	}
+	if st.State != last.State {
+		b.props.SetMust(playerIface, "PlaybackStatus", st.State)
+	}
+	if st.Volume != last.Volume {
+		b.props.SetMust(playerIface, "Volume", float64(st.Volume)/100)
+	}
+	b.props.SetMust(playerIface, "Position", microseconds(st.Position))
+
+	// the position is not signalled, but a jump of it is a seek by another client.
+	expected := last.Position
+	if last.State == "Playing" {
+		expected += now.Sub(b.lastTime).Seconds()
+	}
+	if seeked || (sameTrack && st.Track != nil && math.Abs(st.Position-expected) > seekThreshold) {
+		b.conn.Emit(objectPath, playerIface+".Seeked", microseconds(st.Position))
+	}
+
+	b.last = st
+	b.lastTime = now
+}
+
+func (b *Bridge) setVolume(c *prop.Change) *dbus.Error {
+	v := c.Value.(float64)
+	v = math.Max(0, math.Min(1, v))
+	return dbusError(b.player.SetSoundVolume(int(math.Round(v * 100))))
+}
+
+func dbusError(err error) *dbus.Error {
+	if err == nil {
+		return nil
+	}
+	return dbus.MakeFailedError(err)
+}
+
+// rootObject implements the methods of org.mpris.MediaPlayer2, the player can neither be raised nor quit.
+type rootObject struct{}
+
+func (rootObject) Raise() *dbus.Error {
+	return nil
+}
+
+func (rootObject) Quit() *dbus.Error {
+	return nil
+}
+
+// playerObject implements the methods of org.mpris.MediaPlayer2.Player.
+type playerObject struct {
+	b *Bridge
+}
+
+// control runs a command of the player and emits its changes without waiting for the next poll.
+func (o playerObject) control(f func() error) *dbus.Error {
+	err := f()
+	if err != nil {
+		return dbusError(err)
+	}
+
+	o.b.update(false)
+	return nil
+}
+
+func (o playerObject) Next() *dbus.Error {
+	return o.control(o.b.player.NextTrack)
+}
+
+func (o playerObject) Previous() *dbus.Error {
+	return o.control(o.b.player.PreviousTrack)
+}
+
+func (o playerObject) Pause() *dbus.Error {
+	return o.control(o.b.player.Pause)
+}
+
+func (o playerObject) PlayPause() *dbus.Error {
+	return o.control(o.b.player.PlayPause)
+}
+
+func (o playerObject) Stop() *dbus.Error {
+	return o.control(o.b.player.Stop)
+}
+
+func (o playerObject) Play() *dbus.Error {
+	return o.control(o.b.player.Play)
+}
+
+// seek moves to position in microseconds, past the end of the track it skips to the next track.
+func (o playerObject) seek(st *Status, position int64) *dbus.Error {
+	if position < 0 {
+		position = 0
+	}
+	if position > microseconds(st.Track.Duration) {
+		return o.Next()
+	}
+
+	err := o.b.player.SetPosition(float64(position) / 1e6)
+	if err != nil {
+		return dbusError(err)
+	}
+
+	o.b.update(true)
+	return nil
+}
+
+// SeekBy implements Seek, moving by offset in microseconds.
+func (o playerObject) SeekBy(offset int64) *dbus.Error {
+	st, err := o.b.player.Status()
+	if err != nil {
+		return dbusError(err)
+	}
+	if st.Track == nil {
+		return nil
+	}
+
+	return o.seek(st, microseconds(st.Position)+offset)
+}
+
+// SetPosition is ignored unless trackID is the current track, as the specification requires.
+func (o playerObject) SetPosition(trackID dbus.ObjectPath, position int64) *dbus.Error {
+	st, err := o.b.player.Status()
+	if err != nil {
+		return dbusError(err)
+	}
+	if st.Track == nil || trackID != trackPath(st.Track) || position < 0 || position > microseconds(st.Track.Duration) {
+		return nil
+	}
+
+	return o.seek(st, position)
+}
+
+func (o playerObject) OpenUri(uri string) *dbus.Error {
+	return dbus.NewError("org.mpris.MediaPlayer2.Player.Error.NotSupported", []interface{}{"OpenUri is not supported"})
+}
@@ -0,0 +1,196 @@
+package mpris
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type fakePlayer struct {
+	mu     sync.Mutex
+	status Status
+	calls  []string
+}
+
+func (p *fakePlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.status
+	return &st, nil
+}
+
+func (p *fakePlayer) set(f func(st *Status)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f(&p.status)
+}
+
+func (p *fakePlayer) call(format string, args ...interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (p *fakePlayer) Play() error                     { return p.call("play") }
+func (p *fakePlayer) Pause() error                    { return p.call("pause") }
+func (p *fakePlayer) PlayPause() error                { return p.call("playpause") }
+func (p *fakePlayer) Stop() error                     { return p.call("stop") }
+func (p *fakePlayer) NextTrack() error                { return p.call("next") }
+func (p *fakePlayer) PreviousTrack() error            { return p.call("previous") }
+func (p *fakePlayer) SetSoundVolume(volume int) error { return p.call("volume %v", volume) }
+
+func (p *fakePlayer) SetPosition(seconds float64) error {
+	p.set(func(st *Status) { st.Position = seconds })
+	return p.call("position %v", seconds)
+}
+
+// privateBus starts a session bus for the test and returns its address.
+func privateBus(t *testing.T) string {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	cmd := exec.Command(path, "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func connect(t *testing.T, address string) *dbus.Conn {
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestBridge(t *testing.T) {
+	address := privateBus(t)
+
+	p := &fakePlayer{status: Status{State: "Playing", Volume: 30, Position: 12, Track: &Track{PersistentID: "A1B2", Name: "Help!", Artist: "The Beatles", Album: "Help!", Duration: 138}}}
+	b, err := export(connect(t, address), "test", p, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	_, err = export(connect(t, address), "test", p, time.Second)
+	if err == nil {
+		t.Error("expect the name to be owned")
+	}
+
+	client := connect(t, address)
+	signals := make(chan *dbus.Signal, 16)
+	client.Signal(signals)
+	err = client.AddMatchSignal(dbus.WithMatchObjectPath(objectPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := client.Object("org.mpris.MediaPlayer2.test", objectPath)
+	get := func(name string) interface{} {
+		v, err := obj.GetProperty(name)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		return v.Value()
+	}
+
+	if get(rootIface+".Identity") != "iTunes" || get(playerIface+".PlaybackStatus") != "Playing" || get(playerIface+".Volume") != 0.3 {
+		t.Errorf("unexpected properties")
+	}
+	m := get(playerIface + ".Metadata").(map[string]dbus.Variant)
+	if m["mpris:trackid"].Value() != dbus.ObjectPath("/org/mpris/MediaPlayer2/Track/A1B2") || m["xesam:title"].Value() != "Help!" || m["mpris:length"].Value() != int64(138e6) {
+		t.Errorf("unexpected metadata: %v", m)
+	}
+
+	var xml string
+	err = obj.Call("org.freedesktop.DBus.Introspectable.Introspect", 0).Store(&xml)
+	if err != nil || !strings.Contains(xml, `<method name="Seek">`) || !strings.Contains(xml, `<property name="Volume" type="d" access="readwrite">`) {
+		t.Errorf("unexpected introspection: %v %v", xml, err)
+	}
+
+	for _, method := range []string{"PlayPause", "Next", "Previous", "Stop"} {
+		err := obj.Call(playerIface+"."+method, 0).Err
+		if err != nil {
+			t.Errorf("%v: %v", method, err)
+		}
+	}
+	err = obj.Call("org.freedesktop.DBus.Properties.Set", 0, playerIface, "Volume", dbus.MakeVariant(0.5)).Err
+	if err != nil {
+		t.Error(err)
+	}
+	err = obj.Call(playerIface+".Seek", 0, int64(3e6)).Err
+	if err != nil {
+		t.Error(err)
+	}
+	// ignored as the track is not the current one.
+	err = obj.Call(playerIface+".SetPosition", 0, dbus.ObjectPath("/org/mpris/MediaPlayer2/Track/FFFF"), int64(1e6)).Err
+	if err != nil {
+		t.Error(err)
+	}
+	err = obj.Call(playerIface+".OpenUri", 0, "file:///x.mp3").Err
+	if err == nil {
+		t.Error("expect OpenUri to fail")
+	}
+
+	p.mu.Lock()
+	calls := strings.Join(p.calls, ",")
+	p.mu.Unlock()
+	if calls != "playpause,next,previous,stop,volume 50,position 15" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	p.set(func(st *Status) {
+		st.State = "Paused"
+		st.Track = &Track{PersistentID: "C3", Name: "Yesterday"}
+	})
+
+	changed := map[string]dbus.Variant{}
+	seeked := false
+	timeout := time.After(2 * time.Second)
+	for changed["PlaybackStatus"].Value() != "Paused" || changed["Metadata"].Value() == nil {
+		select {
+		case s := <-signals:
+			switch s.Name {
+			case "org.freedesktop.DBus.Properties.PropertiesChanged":
+				for k, v := range s.Body[1].(map[string]dbus.Variant) {
+					changed[k] = v
+				}
+			case playerIface + ".Seeked":
+				seeked = s.Body[0] == int64(15e6)
+			}
+		case <-timeout:
+			t.Fatalf("expect PropertiesChanged, but %v", changed)
+		}
+	}
+
+	m = changed["Metadata"].Value().(map[string]dbus.Variant)
+	if m["xesam:title"].Value() != "Yesterday" || !seeked {
+		t.Errorf("unexpected signals: %v %v", m, seeked)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package mpris
+
+import (
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+type remotePlayer struct {
+	c *remote.Client
+}
+
+// NewRemotePlayer returns the Player of iTunes on another machine, served by the remote package.
+func NewRemotePlayer(c *remote.Client) Player {
+	return &remotePlayer{c: c}
+}
+
+func (p *remotePlayer) Status() (*Status, error) {
+	rst, err := p.c.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: "Stopped", Volume: rst.Volume}
+	t := rst.Track
+	if t == nil {
+		return st, nil
+	}
+
+	st.Track = &Track{
+		PersistentID: t.PersistentID,
+		Name:         t.Name,
+		Artist:       t.Artist,
+		Album:        t.Album,
+		Genre:        t.Genre,
+		Duration:     t.Duration.Seconds(),
+	}
+
+	// iTunes reports a paused player as stopped, but keeps its current track.
+	st.State = "Paused"
+	if rst.State != "Stopped" {
+		st.State = "Playing"
+	}
+	st.Position = rst.Position.Seconds()
+
+	return st, nil
+}
+
+func (p *remotePlayer) Play() error          { return p.c.Play() }
+func (p *remotePlayer) Pause() error         { return p.c.Pause() }
+func (p *remotePlayer) PlayPause() error     { return p.c.PlayPause() }
+func (p *remotePlayer) Stop() error          { return p.c.Stop() }
+func (p *remotePlayer) NextTrack() error     { return p.c.NextTrack() }
+func (p *remotePlayer) PreviousTrack() error { return p.c.PreviousTrack() }
+
+func (p *remotePlayer) SetSoundVolume(volume int) error {
+	return p.c.SetSoundVolume(volume)
+}
+
+func (p *remotePlayer) SetPosition(seconds float64) error {
+	return p.c.SetPosition(time.Duration(seconds * float64(time.Second)))
+}
@@ -1,5 +1,176 @@
 package itunes
 
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaegaki/itunes-app-interface/playlistfile"
+)
+
 func (p *Playlist) Name() string {
 	return p.name
 }
+
+// IsUserPlaylist reports whether the playlist is an ordinary playlist made by the user,
+// neither the library, a smart playlist nor a folder.
+func (p *Playlist) IsUserPlaylist() bool {
+	return p.isUser
+}
+
+type ExportOptions struct {
+	// RelativeTo makes file locations relative to the directory, usually where the playlist file is saved,
+	// so that the playlist keeps working on a portable drive mounted elsewhere.
+	RelativeTo string
+	// Slash uses "/" as the path separator, which players understand on any platform.
+	Slash bool
+}
+
+func (p *Playlist) Export(w io.Writer, format playlistfile.Format) error {
+	return p.ExportWithOptions(w, format, ExportOptions{})
+}
+
+func (p *Playlist) ExportWithOptions(w io.Writer, format playlistfile.Format, opts ExportOptions) error {
+	output, err := p.GetTracks()
+	if err != nil {
+		return err
+	}
+
+	pl := &playlistfile.Playlist{
+		Title:   p.Name(),
+		Entries: make([]playlistfile.Entry, 0, 100),
+	}
+	for t := range output {
+		pl.Entries = append(pl.Entries, playlistfile.Entry{
+			Location: exportLocation(t, opts),
+			Title:    t.Name(),
+			Artist:   t.Artist(),
+			Album:    t.Album(),
+			Duration: t.Duration(),
+		})
+		t.Close()
+	}
+
+	return playlistfile.Write(w, pl, format)
+}
+
+func exportLocation(t *Track, opts ExportOptions) string {
+	location := t.Location()
+	if !t.IsFileTrack() || location == "" {
+		return location
+	}
+
+	return opts.FileLocation(location)
+}
+
+// FileLocation applies the options to the path of a file track.
+func (opts ExportOptions) FileLocation(location string) string {
+	if opts.RelativeTo != "" {
+		base, err := filepath.Abs(opts.RelativeTo)
+		if err == nil {
+			// fails if the file is on another volume, the absolute path is kept then.
+			rel, err := filepath.Rel(base, location)
+			if err == nil {
+				location = rel
+			}
+		}
+	}
+
+	if opts.Slash {
+		location = filepath.ToSlash(location)
+	}
+
+	return location
+}
+
+type PlaylistImportReport struct {
+	// Resolved is the number of entries added to the playlist.
+	Resolved int
+	// Unresolved are the entries matching no track in the library.
+	Unresolved []playlistfile.Entry
+}
+
+// ImportPlaylist creates a playlist from a M3U/M3U8, PLS or XSPF file.
+// Entries are resolved to library tracks by file path, then by title, artist and duration,
+// so playlists written on another machine can be imported as well.
+// name defaults to the title of the playlist file, or its base name without the extension.
+// The playlist is deleted again if a track cannot be added.
+func (it *Itunes) ImportPlaylist(path, name string) (*Playlist, *PlaylistImportReport, error) {
+	pl, err := playlistfile.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := it.GetTracks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	candidates := make([]playlistfile.Candidate, 0, 1000)
+	for t := range output {
+		location := ""
+		if t.IsFileTrack() {
+			location = t.Location()
+		}
+		candidates = append(candidates, playlistfile.Candidate{
+			ID:       t.PersistentID(),
+			Location: location,
+			Title:    t.Name(),
+			Artist:   t.Artist(),
+			Duration: t.Duration(),
+		})
+		t.Close()
+	}
+
+	resolver := playlistfile.NewResolver(candidates)
+	report := &PlaylistImportReport{}
+	ids := make([]string, 0, len(pl.Entries))
+	for _, e := range pl.Entries {
+		id, ok := resolver.Resolve(e)
+		if !ok {
+			report.Unresolved = append(report.Unresolved, e)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	if name == "" {
+		name = pl.Title
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p, err := it.CreatePlaylist(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range ids {
+		err = it.addTrackByPersistentID(p, id)
+		if err != nil {
+			// a partial playlist would pass for the imported one.
+			p.Delete()
+			p.Close()
+			return nil, nil, err
+		}
+		report.Resolved++
+	}
+
+	return p, report, nil
+}
+
+func (it *Itunes) addTrackByPersistentID(p *Playlist, id string) error {
+	t, err := it.FindTrackByPersistentID(id)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	added, err := p.AddTrack(t)
+	if err != nil {
+		return err
+	}
+	added.Close()
+
+	return nil
+}
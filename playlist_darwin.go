@@ -10,7 +10,8 @@ import (
 type Playlist struct {
 	persistentID string
 
-	name string
+	name   string
+	isUser bool
 }
 
 func createPlaylist(values []string) (*Playlist, error) {
@@ -18,8 +19,12 @@ func createPlaylist(values []string) (*Playlist, error) {
 
 	count := len(values)
 	var name string
+	var isUser bool
 
 	switch {
+	case count > 2:
+		isUser = values[2] == "true"
+		fallthrough
 	case count > 1:
 		name = values[1]
 	}
@@ -27,7 +32,8 @@ func createPlaylist(values []string) (*Playlist, error) {
 	p := &Playlist{
 		persistentID: persistentID,
 
-		name: name,
+		name:   name,
+		isUser: isUser,
 	}
 
 	return p, nil
@@ -38,7 +44,7 @@ func (p *Playlist) Close() {
 }
 
 func (p *Playlist) TrackCount() (int, error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`p(findPlaylistByPersistentId("%v").tracks.length);`, p.persistentID))
+	columns, err := getColumnsByJS(fmt.Sprintf(`p(findPlaylistByPersistentId(%s).tracks.length);`, jsString(p.persistentID)))
 	if err != nil {
 		return 0, err
 	}
@@ -55,7 +61,7 @@ func (p *Playlist) TrackCount() (int, error) {
 }
 
 func (p *Playlist) GetTrack(index int) (t *Track, err error) {
-	columns, err := getColumnsByJS(fmt.Sprintf(`logTrack(findPlaylistByPersistentId("%v").tracks[%v]());`, p.persistentID, index))
+	columns, err := getColumnsByJS(fmt.Sprintf(`logTrack(findPlaylistByPersistentId(%s).tracks[%v]());`, jsString(p.persistentID), index))
 	if err != nil {
 		return nil, err
 	}
@@ -63,8 +69,16 @@ func (p *Playlist) GetTrack(index int) (t *Track, err error) {
 	return createTrack(columns)
 }
 func (p *Playlist) GetTracks() (chan *Track, error) {
-	log.Println(p.GetTrack(0))
-	o, err := execJS(fmt.Sprintf(`findPlaylistByPersistentId("%v").tracks().forEach(logTrack);`, p.persistentID))
+	return p.getTracks(fmt.Sprintf(`logTracks(findPlaylistByPersistentId(%s));`, jsString(p.persistentID)))
+}
+
+// GetTracksRange gets up to limit tracks from offset, the index of the first track is 0.
+func (p *Playlist) GetTracksRange(offset, limit int) (chan *Track, error) {
+	return p.getTracks(fmt.Sprintf(`logTracks(findPlaylistByPersistentId(%s), %d, %d);`, jsString(p.persistentID), offset, offset+limit))
+}
+
+func (p *Playlist) getTracks(script string) (chan *Track, error) {
+	o, err := execJS(script)
 	if err != nil {
 		return nil, err
 	}
@@ -97,16 +111,16 @@ func (p *Playlist) PersistentID() string {
 }
 
 func (p *Playlist) PlayFirstTrack() error {
-	_, err := getColumnsByJS(fmt.Sprintf(`findPlaylistByPersistentId("%v")`, p.persistentID))
+	_, err := getColumnsByJS(fmt.Sprintf(`app.play(findPlaylistByPersistentId(%s))`, jsString(p.persistentID)))
 	return err
 }
 
 func (p *Playlist) SetShuffle(isShuffle bool) error {
-	return errors.New("SetShuffle is not support on OSX.")
+	return &NotSupportedError{Feature: "SetShuffle", Platform: "OSX"}
 }
 
 func (p *Playlist) Shuffle() (bool, error) {
-	return false, errors.New("Shuffle is not support on OSX.")
+	return false, &NotSupportedError{Feature: "Shuffle", Platform: "OSX"}
 }
 
 func (p *Playlist) AddTrack(t *Track) (result *Track, err error) {
@@ -123,7 +137,40 @@ func (p *Playlist) AddTrack(t *Track) (result *Track, err error) {
 }
 
 func (p *Playlist) Delete() error {
-	_, err := getColumnsByJS(fmt.Sprintf(`findPlaylistByPersistentId("%v").delete()`, p.persistentID))
+	_, err := getColumnsByJS(fmt.Sprintf(`findPlaylistByPersistentId(%s).delete()`, jsString(p.persistentID)))
+
+	return err
+}
+
+// removeTracks removes count tracks from index, the first track is at 0.
+func (p *Playlist) removeTracks(index, count int) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`
+var tracks = findPlaylistByPersistentId(%s).tracks;
+for (var i = %d; i >= %d; i--) {
+	tracks[i].delete();
+}`, jsString(p.persistentID), index+count-1, index))
+
+	return err
+}
+
+func (p *Playlist) SetName(name string) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`findPlaylistByPersistentId(%s).name = %s;`, jsString(p.persistentID), jsString(name)))
+	if err != nil {
+		return err
+	}
+
+	p.name = name
+	return nil
+}
+
+// RemoveTrack removes the track from the playlist, the track stays in the library.
+func (p *Playlist) RemoveTrack(t *Track) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`
+var playlist = findPlaylistByPersistentId(%s);
+var index = playlist.tracks.persistentID().indexOf(%s);
+if (index >= 0) {
+	playlist.tracks[index].delete();
+}`, jsString(p.persistentID), jsString(t.persistentID)))
 
 	return err
 }
@@ -0,0 +1,83 @@
+package itunes
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+type Playlist struct {
+	it           *Itunes
+	path         dbus.ObjectPath
+	persistentID string
+
+	name   string
+	isUser bool
+}
+
+func createPlaylist(it *Itunes, p mprisPlaylist) *Playlist {
+	return &Playlist{
+		it:           it,
+		path:         p.Path,
+		persistentID: makePersistentID(p.Path),
+
+		name: p.Name,
+		// MPRIS only lists the playlists of the user.
+		isUser: true,
+	}
+}
+
+// for compatibility
+func (p *Playlist) Close() {
+}
+
+func (_ *Playlist) TrackCount() (int, error) {
+	return 0, &NotSupportedError{Feature: "Playlist.TrackCount", Platform: "Linux"}
+}
+
+func (_ *Playlist) GetTrack(index int) (t *Track, err error) {
+	return nil, &NotSupportedError{Feature: "Playlist.GetTrack", Platform: "Linux"}
+}
+
+func (_ *Playlist) GetTracks() (chan *Track, error) {
+	return nil, &NotSupportedError{Feature: "Playlist.GetTracks", Platform: "Linux"}
+}
+
+func (_ *Playlist) GetTracksRange(offset, limit int) (chan *Track, error) {
+	return nil, &NotSupportedError{Feature: "Playlist.GetTracksRange", Platform: "Linux"}
+}
+
+func (p *Playlist) PersistentID() string {
+	return p.persistentID
+}
+
+func (p *Playlist) PlayFirstTrack() error {
+	return p.it.callMethod(playlistsIface+".ActivatePlaylist", p.path)
+}
+
+// SetShuffle sets the shuffle of the player, MPRIS has no shuffle per playlist.
+func (p *Playlist) SetShuffle(isShuffle bool) error {
+	return p.it.putProperty(playerIface+".Shuffle", isShuffle)
+}
+
+func (p *Playlist) Shuffle() (bool, error) {
+	return p.it.getBool(playerIface + ".Shuffle")
+}
+
+func (_ *Playlist) AddTrack(t *Track) (result *Track, err error) {
+	return nil, &NotSupportedError{Feature: "AddTrack", Platform: "Linux"}
+}
+
+func (_ *Playlist) Delete() error {
+	return &NotSupportedError{Feature: "Playlist.Delete", Platform: "Linux"}
+}
+
+func (_ *Playlist) SetName(name string) error {
+	return &NotSupportedError{Feature: "SetName", Platform: "Linux"}
+}
+
+func (_ *Playlist) removeTracks(index, count int) error {
+	return &NotSupportedError{Feature: "RemoveTrack", Platform: "Linux"}
+}
+
+func (_ *Playlist) RemoveTrack(t *Track) error {
+	return &NotSupportedError{Feature: "RemoveTrack", Platform: "Linux"}
+}
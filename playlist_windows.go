@@ -3,6 +3,7 @@ package itunes
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/yaegaki/go-ole-handler"
 )
@@ -15,9 +16,16 @@ type Playlist struct {
 	highID uint32
 	lowID  uint32
 
-	name string
+	name   string
+	isUser bool
 }
 
+// ITPlaylistKind
+const playlistKindUser = 2
+
+// ITUserPlaylistSpecialKind
+const userPlaylistSpecialKindNone = 0
+
 func createPlaylist(it *Itunes, handler *olehandler.OleHandler) (*Playlist, error) {
 	v, err := it.handler.GetProperty("ITObjectPersistentIDHigh", handler.Handle)
 	if err != nil {
@@ -40,6 +48,27 @@ func createPlaylist(it *Itunes, handler *olehandler.OleHandler) (*Playlist, erro
 		return nil, err
 	}
 
+	kind, err := handler.GetIntProperty("Kind")
+	if err != nil {
+		return nil, err
+	}
+
+	// Smart and SpecialKind are properties of IITUserPlaylist.
+	isUser := false
+	if kind == playlistKindUser {
+		smart, err := handler.GetBoolProperty("Smart")
+		if err != nil {
+			return nil, err
+		}
+
+		specialKind, err := handler.GetIntProperty("SpecialKind")
+		if err != nil {
+			return nil, err
+		}
+
+		isUser = !smart && specialKind == userPlaylistSpecialKindNone
+	}
+
 	p := &Playlist{
 		handler: handler,
 
@@ -48,7 +77,8 @@ func createPlaylist(it *Itunes, handler *olehandler.OleHandler) (*Playlist, erro
 		highID: highID,
 		lowID:  lowID,
 
-		name: name,
+		name:   name,
+		isUser: isUser,
 	}
 
 	return p, nil
@@ -64,6 +94,10 @@ func (p *Playlist) TrackCount() (int, error) {
 
 func (p *Playlist) GetTrack(index int) (t *Track, err error) {
 	err = p.tracks.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return &NotFoundError{Kind: "track", ID: strconv.Itoa(index)}
+		}
+
 		t, err = createTrack(p.itunes, handler)
 		return err
 	}, index+1)
@@ -76,11 +110,32 @@ func (p *Playlist) GetTracks() (chan *Track, error) {
 		return nil, err
 	}
 
+	return p.getTracks(1, count), nil
+}
+
+// GetTracksRange gets up to limit tracks from offset, the index of the first track is 0.
+func (p *Playlist) GetTracksRange(offset, limit int) (chan *Track, error) {
+	count, err := p.TrackCount()
+	if err != nil {
+		return nil, err
+	}
+
+	last := offset + limit
+	if last > count {
+		last = count
+	}
+
+	return p.getTracks(offset+1, last), nil
+}
+
+// getTracks gets the tracks from first to last, the index of the first track is 1.
+func (p *Playlist) getTracks(first, last int) chan *Track {
 	output := make(chan *Track)
 	go func() {
 		defer close(output)
-		for i := 1; i <= count; i++ {
+		for i := first; i <= last; i++ {
 			var t *Track
+			var err error
 			err = p.tracks.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
 				t, err = createTrack(p.itunes, handler)
 				return err
@@ -99,7 +154,7 @@ func (p *Playlist) GetTracks() (chan *Track, error) {
 		}
 	}()
 
-	return output, nil
+	return output
 }
 
 func (p *Playlist) PersistentID() string {
@@ -129,3 +184,41 @@ func (p *Playlist) AddTrack(t *Track) (result *Track, err error) {
 func (p *Playlist) Delete() error {
 	return p.handler.CallMethod("Delete")
 }
+
+func (p *Playlist) SetName(name string) error {
+	err := p.handler.PutProperty("Name", name)
+	if err != nil {
+		return err
+	}
+
+	p.name = name
+	return nil
+}
+
+// removeTracks removes count tracks from index, the first track is at 0.
+func (p *Playlist) removeTracks(index, count int) error {
+	for i := index + count; i > index; i-- {
+		err := p.tracks.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+			if isNull(handler) {
+				return &NotFoundError{Kind: "track", ID: strconv.Itoa(i - 1)}
+			}
+			defer handler.Close()
+
+			return handler.CallMethod("Delete")
+		}, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveTrack removes the track from the playlist, the track stays in the library.
+func (p *Playlist) RemoveTrack(t *Track) error {
+	return p.itunes.findItemByPersistentID(p.tracks, "track", t.PersistentID(), func(handler *olehandler.OleHandler) error {
+		defer handler.Close()
+		// deleting a track of a user playlist only removes it from the playlist.
+		return handler.CallMethod("Delete")
+	})
+}
@@ -0,0 +1,220 @@
+// Package playlistfile reads and writes M3U/M3U8, PLS and XSPF playlist files.
+package playlistfile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Format int
+
+const (
+	M3U Format = iota
+	M3U8
+	PLS
+	XSPF
+)
+
+func (f Format) String() string {
+	switch f {
+	case M3U:
+		return "M3U"
+	case M3U8:
+		return "M3U8"
+	case PLS:
+		return "PLS"
+	case XSPF:
+		return "XSPF"
+	}
+
+	return ""
+}
+
+func (f Format) Ext() string {
+	switch f {
+	case M3U:
+		return ".m3u"
+	case M3U8:
+		return ".m3u8"
+	case PLS:
+		return ".pls"
+	case XSPF:
+		return ".xspf"
+	}
+
+	return ""
+}
+
+// FormatOf detects the format from the extension of path.
+func FormatOf(path string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range []Format{M3U, M3U8, PLS, XSPF} {
+		if f.Ext() == ext {
+			return f, nil
+		}
+	}
+
+	return 0, errors.New(fmt.Sprintf("unknown playlist format:%v", path))
+}
+
+type Entry struct {
+	// Location is a file path, absolute or relative to the playlist file, or a URL.
+	Location string
+
+	Title  string
+	Artist string
+	Album  string
+	// Duration is zero if unknown.
+	Duration time.Duration
+}
+
+// DisplayTitle returns "Artist - Title" as used by EXTINF and PLS.
+func (e *Entry) DisplayTitle() string {
+	if e.Artist == "" {
+		return e.Title
+	}
+
+	return e.Artist + " - " + e.Title
+}
+
+type Playlist struct {
+	Title   string
+	Entries []Entry
+}
+
+func Write(w io.Writer, p *Playlist, format Format) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case M3U, M3U8:
+		err = writeM3U(bw, p)
+	case PLS:
+		err = writePLS(bw, p)
+	case XSPF:
+		err = writeXSPF(bw, p)
+	default:
+		err = errors.New(fmt.Sprintf("unknown playlist format:%v", format))
+	}
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return -1
+	}
+
+	return int((d + time.Second/2) / time.Second)
+}
+
+func writeM3U(w io.Writer, p *Playlist) error {
+	_, err := fmt.Fprint(w, "#EXTM3U\n")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range p.Entries {
+		_, err = fmt.Fprintf(w, "#EXTINF:%d,%v\n%v\n", seconds(e.Duration), e.DisplayTitle(), e.Location)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePLS(w io.Writer, p *Playlist) error {
+	_, err := fmt.Fprint(w, "[playlist]\n")
+	if err != nil {
+		return err
+	}
+
+	for i, e := range p.Entries {
+		n := i + 1
+		_, err = fmt.Fprintf(w, "File%d=%v\nTitle%d=%v\nLength%d=%d\n", n, e.Location, n, e.DisplayTitle(), n, seconds(e.Duration))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "NumberOfEntries=%d\nVersion=2\n", len(p.Entries))
+	return err
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	// milliseconds
+	Duration int64 `xml:"duration,omitempty"`
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+// locationURI converts a file path to a URI as XSPF requires.
+func locationURI(location string) string {
+	if location == "" || strings.Contains(location, "://") {
+		return location
+	}
+
+	path := filepath.ToSlash(location)
+	if filepath.IsAbs(location) || strings.HasPrefix(path, "/") {
+		if !strings.HasPrefix(path, "/") {
+			// C:/Music -> /C:/Music
+			path = "/" + path
+		}
+		u := url.URL{Scheme: "file", Path: path}
+		return u.String()
+	}
+
+	u := url.URL{Path: path}
+	return u.String()
+}
+
+func writeXSPF(w io.Writer, p *Playlist) error {
+	xp := xspfPlaylist{
+		Version: "1",
+		Title:   p.Title,
+		Tracks:  make([]xspfTrack, len(p.Entries)),
+	}
+	for i, e := range p.Entries {
+		xp.Tracks[i] = xspfTrack{
+			Location: locationURI(e.Location),
+			Title:    e.Title,
+			Creator:  e.Artist,
+			Album:    e.Album,
+			Duration: int64(e.Duration / time.Millisecond),
+		}
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(&xp)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,158 @@
+package playlistfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPlaylist() *Playlist {
+	return &Playlist{
+		Title: "Mix",
+		Entries: []Entry{
+			{Location: "/Music/A B.mp3", Title: "Song", Artist: "Artist", Album: "Album", Duration: 215400 * time.Millisecond},
+			{Location: "Music/C.m4a", Title: "Other"},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format Format
+		expect string
+	}{
+		{M3U8, `#EXTM3U
+#EXTINF:215,Artist - Song
+/Music/A B.mp3
+#EXTINF:-1,Other
+Music/C.m4a
+`},
+		{PLS, `[playlist]
+File1=/Music/A B.mp3
+Title1=Artist - Song
+Length1=215
+File2=Music/C.m4a
+Title2=Other
+Length2=-1
+NumberOfEntries=2
+Version=2
+`},
+		{XSPF, `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>Mix</title>
+  <trackList>
+    <track>
+      <location>file:///Music/A%20B.mp3</location>
+      <title>Song</title>
+      <creator>Artist</creator>
+      <album>Album</album>
+      <duration>215400</duration>
+    </track>
+    <track>
+      <location>Music/C.m4a</location>
+      <title>Other</title>
+    </track>
+  </trackList>
+</playlist>
+`},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := Write(&buf, testPlaylist(), test.format)
+		if err != nil {
+			t.Errorf("%v: Write failed.\n%v", test.format, err)
+			continue
+		}
+
+		if buf.String() != test.expect {
+			t.Errorf("%v: expect\n%v\nbut\n%v", test.format, test.expect, buf.String())
+		}
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	f, err := FormatOf("/tmp/list.M3U8")
+	if err != nil || f != M3U8 {
+		t.Errorf("expect M3U8, but %v %v", f, err)
+	}
+
+	_, err = FormatOf("/tmp/list.txt")
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("expect unknown format error, but %v", err)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	for _, format := range []Format{M3U8, PLS, XSPF} {
+		var buf bytes.Buffer
+		err := Write(&buf, testPlaylist(), format)
+		if err != nil {
+			t.Fatalf("%v: Write failed.\n%v", format, err)
+		}
+
+		p, err := Read(&buf, format)
+		if err != nil {
+			t.Fatalf("%v: Read failed.\n%v", format, err)
+		}
+
+		expect := testPlaylist().Entries
+		if len(p.Entries) != len(expect) {
+			t.Errorf("%v: expect %v entries, but %v", format, len(expect), len(p.Entries))
+			continue
+		}
+
+		for i, e := range p.Entries {
+			x := expect[i]
+			if format != XSPF {
+				// EXTINF and PLS have neither album nor milliseconds.
+				x.Album = ""
+				x.Duration = x.Duration.Truncate(time.Second)
+			}
+
+			if e != x {
+				t.Errorf("%v: expect %+v, but %+v", format, x, e)
+			}
+		}
+	}
+}
+
+func TestReadM3ULatin1(t *testing.T) {
+	p, err := Read(strings.NewReader("#EXTINF:10,Bj\xf6rk - J\xf3ga\r\nj\xf3ga.mp3\r\n"), M3U)
+	if err != nil {
+		t.Fatalf("Read failed.\n%v", err)
+	}
+
+	if len(p.Entries) != 1 || p.Entries[0].Artist != "Björk" || p.Entries[0].Location != "jóga.mp3" {
+		t.Errorf("unexpected entries: %+v", p.Entries)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := NewResolver([]Candidate{
+		{ID: "1", Location: "/Users/me/Music/Artist/Album/01 Song.mp3", Title: "Song", Artist: "Artist", Duration: 200 * time.Second},
+		{ID: "2", Location: "/Users/me/Music/Other/Album/01 Song.mp3", Title: "Song", Artist: "Other", Duration: 180 * time.Second},
+		{ID: "3", Title: "Don't Stop", Artist: "Band", Duration: 240 * time.Second},
+	})
+
+	tests := []struct {
+		entry  Entry
+		expect string
+	}{
+		{Entry{Location: "/users/me/music/artist/album/01 song.mp3"}, "1"},
+		{Entry{Location: "/Volumes/USB/Other/Album/01 Song.mp3"}, "2"},
+		{Entry{Location: "/nowhere.mp3", Title: "dont stop", Artist: "BAND", Duration: 241 * time.Second}, "3"},
+		{Entry{Title: "Band - Don't Stop"}, "3"},
+		{Entry{Title: "Song", Duration: 181 * time.Second}, "2"},
+		{Entry{Title: "Song"}, ""},
+		{Entry{Title: "Song", Artist: "Artist", Duration: 100 * time.Second}, ""},
+	}
+
+	for _, test := range tests {
+		id, ok := r.Resolve(test.entry)
+		if id != test.expect || ok != (test.expect != "") {
+			t.Errorf("%+v: expect %q, but %q", test.entry, test.expect, id)
+		}
+	}
+}
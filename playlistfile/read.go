@@ -0,0 +1,255 @@
+package playlistfile
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReadFile reads a playlist file, detecting the format from the extension.
+// file locations are made absolute relative to the directory of the playlist file.
+func ReadFile(path string) (*Playlist, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := Read(f, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Title == "" {
+		p.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range p.Entries {
+		if e.Location != "" && !isURL(e.Location) && !filepath.IsAbs(e.Location) {
+			p.Entries[i].Location = filepath.Join(dir, e.Location)
+		}
+	}
+
+	return p, nil
+}
+
+func Read(r io.Reader, format Format) (*Playlist, error) {
+	switch format {
+	case M3U, M3U8:
+		return readM3U(r, format)
+	case PLS:
+		return readPLS(r)
+	case XSPF:
+		return readXSPF(r)
+	}
+
+	return nil, errors.New(fmt.Sprintf("unknown playlist format:%v", format))
+}
+
+func isURL(location string) bool {
+	return strings.Contains(location, "://")
+}
+
+// filePath converts file URLs to paths, other locations are returned as is.
+func filePath(location string) string {
+	if !strings.HasPrefix(strings.ToLower(location), "file:") {
+		return location
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	path := u.Path
+	if runtime.GOOS == "windows" && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		// /C:/Music -> C:/Music
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path)
+}
+
+// splitDisplayTitle splits "Artist - Title".
+func splitDisplayTitle(s string) (artist, title string) {
+	i := strings.Index(s, " - ")
+	if i < 0 {
+		return "", strings.TrimSpace(s)
+	}
+
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+3:])
+}
+
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return time.Duration(n) * time.Second
+}
+
+func latin1(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+
+	return string(runes)
+}
+
+func readM3U(r io.Reader, format Format) (*Playlist, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if format == M3U && !utf8.ValidString(text) {
+		// plain M3U files are often in Latin-1.
+		text = latin1(text)
+	}
+
+	p := &Playlist{Entries: make([]Entry, 0, 100)}
+	var pending *Entry
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			pending = &Entry{}
+			if i := strings.IndexByte(info, ','); i >= 0 {
+				pending.Duration = parseSeconds(info[:i])
+				pending.Artist, pending.Title = splitDisplayTitle(info[i+1:])
+			}
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			p.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#"):
+		default:
+			e := Entry{}
+			if pending != nil {
+				e = *pending
+				pending = nil
+			}
+			e.Location = filePath(line)
+			p.Entries = append(p.Entries, e)
+		}
+	}
+
+	return p, scanner.Err()
+}
+
+func readPLS(r io.Reader) (*Playlist, error) {
+	entries := map[int]*Entry{}
+	max := 0
+	entry := func(n int) *Entry {
+		e, ok := entries[n]
+		if !ok {
+			e = &Entry{}
+			entries[n] = e
+		}
+		if n > max {
+			max = n
+		}
+		return e
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		sep := strings.IndexByte(line, '=')
+		if sep < 0 {
+			continue
+		}
+
+		key, value := strings.ToLower(line[:sep]), strings.TrimSpace(line[sep+1:])
+		for _, prefix := range []string{"file", "title", "length"} {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			n, err := strconv.Atoi(key[len(prefix):])
+			if err != nil || n <= 0 {
+				break
+			}
+
+			e := entry(n)
+			switch prefix {
+			case "file":
+				e.Location = filePath(value)
+			case "title":
+				e.Artist, e.Title = splitDisplayTitle(value)
+			case "length":
+				e.Duration = parseSeconds(value)
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p := &Playlist{Entries: make([]Entry, 0, len(entries))}
+	for n := 1; n <= max; n++ {
+		if e, ok := entries[n]; ok && e.Location != "" {
+			p.Entries = append(p.Entries, *e)
+		}
+	}
+
+	return p, nil
+}
+
+func readXSPF(r io.Reader) (*Playlist, error) {
+	var xp xspfPlaylist
+	err := xml.NewDecoder(r).Decode(&xp)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Playlist{
+		Title:   xp.Title,
+		Entries: make([]Entry, 0, len(xp.Tracks)),
+	}
+	for _, t := range xp.Tracks {
+		location := t.Location
+		if !isURL(location) {
+			// relative URIs are percent-encoded.
+			if unescaped, err := url.PathUnescape(location); err == nil {
+				location = filepath.FromSlash(unescaped)
+			}
+		}
+
+		p.Entries = append(p.Entries, Entry{
+			Location: filePath(location),
+			Title:    t.Title,
+			Artist:   t.Creator,
+			Album:    t.Album,
+			Duration: time.Duration(t.Duration) * time.Millisecond,
+		})
+	}
+
+	return p, nil
+}
@@ -0,0 +1,149 @@
+package playlistfile
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Candidate is a library track entries can be resolved to.
+type Candidate struct {
+	ID       string
+	Location string
+	Title    string
+	Artist   string
+	Duration time.Duration
+}
+
+// durations of a metadata match may differ by this much, encoders disagree by a second or two.
+const durationTolerance = 3 * time.Second
+
+// Resolver finds the library track of an entry by its file path,
+// by the end of its path when the library lives elsewhere, and by its metadata otherwise.
+type Resolver struct {
+	paths    map[string]string
+	suffixes map[string][]string
+	titles   map[string][]Candidate
+}
+
+// the file systems of OSX and Windows are case insensitive by default.
+func normalizePath(path string) string {
+	return strings.ToLower(filepath.ToSlash(filepath.Clean(path)))
+}
+
+// pathSuffix returns the last n elements of a normalized path, like "artist/album/01 song.mp3".
+func pathSuffix(path string, n int) string {
+	elements := strings.Split(path, "/")
+	if len(elements) < n {
+		return ""
+	}
+
+	return strings.Join(elements[len(elements)-n:], "/")
+}
+
+var apostrophes = strings.NewReplacer("'", "", "\u2019", "")
+
+// normalizeText ignores case, punctuation and spacing. "Don't" matches "dont".
+func normalizeText(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(apostrophes.Replace(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return strings.Join(fields, " ")
+}
+
+const suffixLength = 3
+
+func NewResolver(candidates []Candidate) *Resolver {
+	r := &Resolver{
+		paths:    map[string]string{},
+		suffixes: map[string][]string{},
+		titles:   map[string][]Candidate{},
+	}
+
+	for _, c := range candidates {
+		if c.Location != "" {
+			path := normalizePath(c.Location)
+			r.paths[path] = c.ID
+			if suffix := pathSuffix(path, suffixLength); suffix != "" {
+				r.suffixes[suffix] = append(r.suffixes[suffix], c.ID)
+			}
+		}
+
+		title := normalizeText(c.Title)
+		r.titles[title] = append(r.titles[title], c)
+	}
+
+	return r
+}
+
+func (r *Resolver) Resolve(e Entry) (string, bool) {
+	if e.Location != "" && !isURL(e.Location) {
+		path := normalizePath(e.Location)
+		if id, ok := r.paths[path]; ok {
+			return id, true
+		}
+
+		if ids := r.suffixes[pathSuffix(path, suffixLength)]; len(ids) == 1 {
+			return ids[0], true
+		}
+	}
+
+	if id, ok := r.resolveByMetadata(e.Title, e.Artist, e.Duration); ok {
+		return id, true
+	}
+
+	// the artist is sometimes a part of the title, like "Artist - Title".
+	if e.Artist == "" {
+		artist, title := splitDisplayTitle(e.Title)
+		if artist != "" {
+			return r.resolveByMetadata(title, artist, e.Duration)
+		}
+	}
+
+	return "", false
+}
+
+func (r *Resolver) resolveByMetadata(title, artist string, duration time.Duration) (string, bool) {
+	if title == "" {
+		return "", false
+	}
+
+	artist = normalizeText(artist)
+	var best *Candidate
+	var bestDiff time.Duration
+	ambiguous := false
+	candidates := r.titles[normalizeText(title)]
+	for i := range candidates {
+		c := &candidates[i]
+		if artist != "" && normalizeText(c.Artist) != artist {
+			continue
+		}
+
+		diff := time.Duration(0)
+		if duration > 0 && c.Duration > 0 {
+			diff = c.Duration - duration
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > durationTolerance {
+				continue
+			}
+		}
+
+		switch {
+		case best == nil || diff < bestDiff:
+			best, bestDiff, ambiguous = c, diff, false
+		case diff == bestDiff && best.ID != c.ID:
+			ambiguous = true
+		}
+	}
+
+	// a title without artist is only trusted when a single track matches.
+	if best == nil || (ambiguous && artist == "") {
+		return "", false
+	}
+
+	return best.ID, true
+}
@@ -0,0 +1,283 @@
+package itunes
+
+import "errors"
+
+// Neither the COM interface nor the scripting dictionary of iTunes/Music exposes Up Next,
+// so the queue is emulated with a temporary playlist, made by the first queued track and deleted when the queue is cleared.
+// The playlist is hidden from PlaylistCount and GetPlaylist, iTunes itself lists it while tracks are queued.
+const queuePlaylistName = "Up Next (itunes-app-interface)"
+
+// queuePlayer is what the queue needs of the player, *Itunes keeps the queue in its queue playlist.
+type queuePlayer interface {
+	// queueTracks returns the IDs of the tracks of the queue, nil if there is no queue playlist,
+	// and the index of the track playing from it, or -1 if the player is not on the queue playlist.
+	queueTracks() (ids []string, current int, err error)
+	// appendQueue adds the tracks to the end of the queue playlist, which is made if there is none.
+	appendQueue(ids []string) error
+	// removeQueueTracks removes count tracks from index.
+	removeQueueTracks(index, count int) error
+	deleteQueue() error
+	// playQueue plays the queue playlist from its first track.
+	playQueue() error
+
+	NextTrack() error
+	Play() error
+}
+
+// enqueue inserts the track after the current one if next is true, at the end of the queue otherwise.
+// The playlist is edited in place, so the player goes on with what it is playing.
+func enqueue(p queuePlayer, id string, next bool) error {
+	ids, current, err := p.queueTracks()
+	if err != nil {
+		return err
+	}
+
+	// the played tracks are dropped, the queue starts at the current track.
+	if current > 0 {
+		err = p.removeQueueTracks(0, current)
+		if err != nil {
+			return err
+		}
+		ids, current = ids[current:], 0
+	}
+
+	if !next || ids == nil {
+		return p.appendQueue([]string{id})
+	}
+
+	// the tracks are appended only, so the queued ones are moved behind the new track.
+	pending := ids[current+1:]
+	if len(pending) > 0 {
+		err = p.removeQueueTracks(current+1, len(pending))
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.appendQueue(append([]string{id}, pending...))
+}
+
+// queued returns the IDs of the tracks after the one playing from the queue.
+func queued(p queuePlayer) ([]string, error) {
+	ids, current, err := p.queueTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{}, ids[current+1:]...), nil
+}
+
+func clearQueue(p queuePlayer) error {
+	ids, current, err := p.queueTracks()
+	if err != nil {
+		return err
+	}
+
+	if ids == nil {
+		return nil
+	}
+
+	if current < 0 {
+		return p.deleteQueue()
+	}
+
+	// the playlist is left to the current track, so that it plays on.
+	if pending := len(ids) - current - 1; pending > 0 {
+		return p.removeQueueTracks(current+1, pending)
+	}
+
+	return nil
+}
+
+func playQueue(p queuePlayer) error {
+	ids, current, err := p.queueTracks()
+	if err != nil {
+		return err
+	}
+
+	if len(ids) <= current+1 {
+		return errors.New("queue is empty")
+	}
+
+	if current < 0 {
+		return p.playQueue()
+	}
+
+	// the player is on the queue playlist, its next track is the first queued one.
+	err = p.NextTrack()
+	if err != nil {
+		return err
+	}
+
+	return p.Play()
+}
+
+// findQueuePlaylist returns the queue playlist, or nil if nothing is queued.
+func (it *Itunes) findQueuePlaylist() (*Playlist, error) {
+	return it.findPlaylistByName(queuePlaylistName)
+}
+
+func (it *Itunes) queueTracks() ([]string, int, error) {
+	p, err := it.findQueuePlaylist()
+	if err != nil || p == nil {
+		return nil, -1, err
+	}
+	defer p.Close()
+
+	output, err := p.GetTracks()
+	if err != nil {
+		return nil, -1, err
+	}
+
+	ids := make([]string, 0)
+	for t := range output {
+		ids = append(ids, t.PersistentID())
+		t.Close()
+	}
+
+	id, err := it.currentTrackID()
+	if err != nil || id == "" {
+		return ids, -1, err
+	}
+
+	cp, err := it.CurrentPlaylist()
+	if err != nil {
+		return nil, -1, err
+	}
+	defer cp.Close()
+
+	if cp.PersistentID() != p.PersistentID() {
+		return ids, -1, nil
+	}
+
+	// a track queued twice is taken for its first place.
+	for i := range ids {
+		if ids[i] == id {
+			return ids, i, nil
+		}
+	}
+
+	return ids, -1, nil
+}
+
+// currentTrackID returns the persistent ID of the current track, or "" if the player has no track.
+func (it *Itunes) currentTrackID() (string, error) {
+	t, err := it.CurrentTrack()
+	if errors.Is(err, ErrNoCurrentTrack) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+
+	return t.PersistentID(), nil
+}
+
+// appendQueue adds the tracks by their persistent IDs, the Track objects of another playlist cannot be added to it.
+func (it *Itunes) appendQueue(ids []string) error {
+	p, err := it.findQueuePlaylist()
+	if err == nil && p == nil {
+		p, err = it.CreatePlaylist(queuePlaylistName)
+	}
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	for _, id := range ids {
+		t, err := it.FindTrackByPersistentID(id)
+		if err != nil {
+			return err
+		}
+
+		added, err := p.AddTrack(t)
+		t.Close()
+		if err != nil {
+			return err
+		}
+		added.Close()
+	}
+
+	return nil
+}
+
+// withQueue calls fn with the queue playlist, if there is one.
+func (it *Itunes) withQueue(fn func(p *Playlist) error) error {
+	p, err := it.findQueuePlaylist()
+	if err != nil || p == nil {
+		return err
+	}
+	defer p.Close()
+
+	return fn(p)
+}
+
+func (it *Itunes) removeQueueTracks(index, count int) error {
+	return it.withQueue(func(p *Playlist) error {
+		return p.removeTracks(index, count)
+	})
+}
+
+func (it *Itunes) deleteQueue() error {
+	return it.withQueue(func(p *Playlist) error {
+		return p.Delete()
+	})
+}
+
+func (it *Itunes) playQueue() error {
+	return it.withQueue(func(p *Playlist) error {
+		return p.PlayFirstTrack()
+	})
+}
+
+// PlayLater appends the track to the end of the queue.
+// Nothing plays from the queue until PlayQueue, after that the player goes through the queued tracks.
+func (it *Itunes) PlayLater(t *Track) error {
+	return enqueue(it, t.PersistentID(), false)
+}
+
+// PlayNext inserts the track at the head of the queue, it plays after the current track if that plays from the queue.
+func (it *Itunes) PlayNext(t *Track) error {
+	return enqueue(it, t.PersistentID(), true)
+}
+
+// QueuedTracks returns the tracks waiting in the queue, after the track playing from it.
+//
+// Editing the queue playlist in iTunes edits the queue. The played tracks are dropped when the queue changes,
+// and once the queue ends the player stops instead of going back to the playlist it was playing before.
+func (it *Itunes) QueuedTracks() ([]*Track, error) {
+	ids, err := queued(it)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]*Track, 0, len(ids))
+	for _, id := range ids {
+		t, err := it.FindTrackByPersistentID(id)
+		if err != nil {
+			closeTracks(tracks)
+			return nil, err
+		}
+		tracks = append(tracks, t)
+	}
+
+	return tracks, nil
+}
+
+// ClearQueue removes the queued tracks and deletes the queue playlist.
+// A track playing from the queue keeps playing, the playlist is deleted by the next ClearQueue after it.
+func (it *Itunes) ClearQueue() error {
+	return clearQueue(it)
+}
+
+// PlayQueue starts playing the queued tracks, from the first one after the track playing from the queue.
+func (it *Itunes) PlayQueue() error {
+	return playQueue(it)
+}
+
+func closeTracks(tracks []*Track) {
+	for _, t := range tracks {
+		t.Close()
+	}
+}
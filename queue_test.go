@@ -0,0 +1,150 @@
+package itunes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeQueue is a queue playlist, the player plays from it if current is not -1.
+type fakeQueue struct {
+	ids     []string
+	current int
+	// playback records the calls that change what the player plays.
+	playback []string
+}
+
+func (q *fakeQueue) queueTracks() ([]string, int, error) {
+	if q.ids == nil {
+		return nil, -1, nil
+	}
+	return append([]string{}, q.ids...), q.current, nil
+}
+
+func (q *fakeQueue) appendQueue(ids []string) error {
+	if q.ids == nil {
+		q.ids = []string{}
+	}
+	q.ids = append(q.ids, ids...)
+	return nil
+}
+
+func (q *fakeQueue) removeQueueTracks(index, count int) error {
+	if index < 0 || index+count > len(q.ids) {
+		return fmt.Errorf("cannot remove %v tracks from %v of %v", count, index, q.ids)
+	}
+
+	q.ids = append(q.ids[:index], q.ids[index+count:]...)
+	if q.current >= index+count {
+		q.current -= count
+	}
+	return nil
+}
+
+func (q *fakeQueue) deleteQueue() error {
+	q.ids = nil
+	q.current = -1
+	return nil
+}
+
+func (q *fakeQueue) playQueue() error {
+	q.current = 0
+	q.playback = append(q.playback, "playQueue")
+	return nil
+}
+
+func (q *fakeQueue) NextTrack() error {
+	q.current++
+	q.playback = append(q.playback, "next")
+	return nil
+}
+
+func (q *fakeQueue) Play() error {
+	q.playback = append(q.playback, "play")
+	return nil
+}
+
+func TestEnqueue(t *testing.T) {
+	tests := []struct {
+		ids     []string
+		current int
+		id      string
+		next    bool
+		expect  string
+	}{
+		{nil, -1, "A", false, "A"},
+		{nil, -1, "A", true, "A"},
+		{[]string{"A", "B"}, -1, "C", false, "A B C"},
+		{[]string{"A", "B"}, -1, "C", true, "C A B"},
+		// the played track is dropped, the current one stays first.
+		{[]string{"A", "B", "C"}, 1, "D", false, "B C D"},
+		{[]string{"A", "B", "C"}, 1, "D", true, "B D C"},
+		{[]string{"A", "B"}, 1, "C", true, "B C"},
+	}
+
+	for _, test := range tests {
+		q := &fakeQueue{ids: test.ids, current: test.current}
+		err := enqueue(q, test.id, test.next)
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+
+		if ids := strings.Join(q.ids, " "); ids != test.expect {
+			t.Errorf("%+v: expect %v, but %v", test, test.expect, ids)
+		}
+		if len(q.playback) != 0 {
+			t.Errorf("%+v: expect the playback not to change, but %v", test, q.playback)
+		}
+	}
+}
+
+func TestQueued(t *testing.T) {
+	q := &fakeQueue{current: -1}
+	ids, err := queued(q)
+	if err != nil || len(ids) != 0 {
+		t.Errorf("expect no tracks, but %v %v", ids, err)
+	}
+
+	q = &fakeQueue{ids: []string{"A", "B", "C"}, current: 0}
+	ids, err = queued(q)
+	if err != nil || strings.Join(ids, " ") != "B C" {
+		t.Errorf("expect B C, but %v %v", ids, err)
+	}
+}
+
+func TestClearQueue(t *testing.T) {
+	q := &fakeQueue{ids: []string{"A", "B"}, current: -1}
+	err := clearQueue(q)
+	if err != nil || q.ids != nil {
+		t.Errorf("expect the playlist to be deleted, but %v %v", q.ids, err)
+	}
+
+	// the current track plays on.
+	q = &fakeQueue{ids: []string{"A", "B", "C"}, current: 1}
+	err = clearQueue(q)
+	if err != nil || strings.Join(q.ids, " ") != "A B" || len(q.playback) != 0 {
+		t.Errorf("expect A B to stay, but %v %v %v", q.ids, q.playback, err)
+	}
+}
+
+func TestPlayQueue(t *testing.T) {
+	for _, q := range []*fakeQueue{{current: -1}, {ids: []string{"A"}, current: 0}} {
+		err := playQueue(q)
+		if err == nil || err.Error() != "queue is empty" {
+			t.Errorf("%v: expect the queue to be empty, but %v", q.ids, err)
+		}
+	}
+
+	q := &fakeQueue{ids: []string{"A", "B"}, current: -1}
+	err := playQueue(q)
+	if err != nil || strings.Join(q.playback, " ") != "playQueue" {
+		t.Errorf("expect the queue to play from its first track, but %v %v", q.playback, err)
+	}
+
+	q = &fakeQueue{ids: []string{"A", "B"}, current: 0}
+	err = playQueue(q)
+	if err != nil || strings.Join(q.playback, " ") != "next play" || q.current != 1 {
+		t.Errorf("expect the next track to play, but %v %v", q.playback, err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+// Artwork is an artwork of a remote track, its image is transferred with it.
+type Artwork struct {
+	format itunes.ArtworkFormat
+	data   []byte
+}
+
+var artworkFormats = []itunes.ArtworkFormat{itunes.JPEG, itunes.PNG, itunes.BMP}
+
+func newArtwork(a *ArtworkData) *Artwork {
+	format := itunes.Unknown
+	for _, f := range artworkFormats {
+		if f.MIMEType() == a.ContentType {
+			format = f
+		}
+	}
+
+	return &Artwork{format: format, data: a.Data}
+}
+
+// for compatibility
+func (_ *Artwork) Close() {
+}
+
+func (a *Artwork) Format() itunes.ArtworkFormat {
+	return a.format
+}
+
+// WriteTo writes the image data to w.
+func (a *Artwork) WriteTo(w io.Writer) (int64, error) {
+	return bytes.NewReader(a.data).WriteTo(w)
+}
+
+func (a *Artwork) SaveToFile(directory, name string) (string, error) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = os.Stat(directory)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(directory, fmt.Sprintf("%v%v", name, a.format.Ext()))
+	err = os.WriteFile(path, a.data, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,498 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type Options struct {
+	// TLSConfig connects over TLS unless nil.
+	TLSConfig *tls.Config
+	Token     string
+	// DialTimeout defaults to 10 seconds.
+	DialTimeout time.Duration
+	// CallTimeout bounds each call, the connection is dropped when it expires. It defaults to 1 minute.
+	CallTimeout time.Duration
+}
+
+const defaultCallTimeout = time.Minute
+
+// ClientTLSConfig returns the TLS config of a client trusting the PEM certificates of caFile,
+// such as the self-signed certificate of the server, or the system roots if caFile is empty.
+func ClientTLSConfig(caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return &tls.Config{}, nil
+	}
+
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New(fmt.Sprintf("no certificate in %v", caFile))
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// NewOptions returns the options of the flags of the commands, TLS is used if useTLS is true or caFile is not empty.
+func NewOptions(token string, useTLS bool, caFile string) (*Options, error) {
+	opts := &Options{Token: token}
+	if useTLS || caFile != "" {
+		config, err := ClientTLSConfig(caFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = config
+	}
+
+	return opts, nil
+}
+
+// Client offers the API of Itunes for the player of a Server, its tracks, playlists and artworks
+// have the methods of those of itunes.
+// A lost connection is dialled again by the next call, so a Client outlives restarts of the server.
+type Client struct {
+	addr string
+	opts Options
+
+	mu     sync.Mutex
+	rpc    *rpc.Client
+	closed bool
+}
+
+// Dial connects to the server at addr.
+func Dial(addr string, opts *Options) (*Client, error) {
+	c := &Client{addr: addr}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.DialTimeout == 0 {
+		c.opts.DialTimeout = handshakeTimeout
+	}
+	if c.opts.CallTimeout == 0 {
+		c.opts.CallTimeout = defaultCallTimeout
+	}
+
+	_, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Client) dial() (*rpc.Client, error) {
+	// keep-alive probes detect a peer gone without closing the connection, such as a sleeping Mac.
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout, KeepAlive: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if c.opts.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, c.opts.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.opts.DialTimeout))
+	err = json.NewEncoder(conn).Encode(&handshake{Version: protocolVersion, Token: c.opts.Token})
+	if err == nil {
+		var reply handshakeReply
+		err = json.NewDecoder(conn).Decode(&reply)
+		if err == nil && reply.Error != "" {
+			err = decodeError(reply.Error)
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return jsonrpc.NewClient(conn), nil
+}
+
+func (c *Client) conn() (*rpc.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, rpc.ErrShutdown
+	}
+
+	if c.rpc == nil {
+		rc, err := c.dial()
+		if err != nil {
+			return nil, err
+		}
+		c.rpc = rc
+	}
+
+	return c.rpc, nil
+}
+
+// drop forgets the connection rc, the next call dials again.
+func (c *Client) drop(rc *rpc.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rpc == rc {
+		c.rpc = nil
+	}
+	rc.Close()
+}
+
+// the methods not retried on a lost connection as they may have run already, and running them twice differs.
+var notRetried = map[string]bool{"Control": true, "CreatePlaylist": true, "AddPlaylistTrack": true}
+
+// call calls the method of the service.
+// A call failing on a lost connection is retried on a new one, except those of notRetried.
+// A call exceeding CallTimeout is not retried, the server may still be running it.
+func (c *Client) call(method string, args interface{}, reply interface{}) error {
+	for retry := true; ; retry = false {
+		rc, err := c.conn()
+		if err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(c.opts.CallTimeout)
+		rpcCall := rc.Go("Itunes."+method, args, reply, make(chan *rpc.Call, 1))
+		select {
+		case <-rpcCall.Done:
+			timer.Stop()
+			err = rpcCall.Error
+		case <-timer.C:
+			// the reply of a late call could not be told from the next ones, so the connection goes.
+			c.drop(rc)
+			return fmt.Errorf("%w: %v", ErrTimeout, method)
+		}
+
+		var serverErr rpc.ServerError
+		if errors.As(err, &serverErr) {
+			return decodeError(string(serverErr))
+		}
+		if err == nil {
+			return nil
+		}
+
+		c.drop(rc)
+		// ErrShutdown means the connection was lost before the call was sent.
+		if retry && (err == rpc.ErrShutdown || !notRetried[method]) {
+			continue
+		}
+		return err
+	}
+}
+
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.rpc != nil {
+		c.rpc.Close()
+		c.rpc = nil
+	}
+}
+
+// Status returns the state, volume, position and current track in one call.
+func (c *Client) Status() (*Status, error) {
+	var st Status
+	err := c.call("Status", &struct{}{}, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
+
+var playerStates = []itunes.PlayerState{itunes.Stopped, itunes.Playing, itunes.FastForward, itunes.Rewind}
+
+func (c *Client) PlayerState() (itunes.PlayerState, error) {
+	st, err := c.Status()
+	if err != nil {
+		return itunes.Stopped, err
+	}
+
+	for _, state := range playerStates {
+		if state.String() == st.State {
+			return state, nil
+		}
+	}
+
+	return itunes.Stopped, errors.New(fmt.Sprintf("unknown player state: %v", st.State))
+}
+
+func (c *Client) SoundVolume() (int, error) {
+	st, err := c.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	return st.Volume, nil
+}
+
+func (c *Client) SetSoundVolume(volume int) error {
+	if volume < 0 || 100 < volume {
+		return ErrOutOfRange
+	}
+
+	return c.call("SetSoundVolume", &volume, &struct{}{})
+}
+
+func (c *Client) Mute() (bool, error) {
+	var muted bool
+	err := c.call("Mute", &struct{}{}, &muted)
+	return muted, err
+}
+
+func (c *Client) SetMute(isMuted bool) error {
+	return c.call("SetMute", &isMuted, &struct{}{})
+}
+
+func (c *Client) Position() (time.Duration, error) {
+	st, err := c.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	return st.Position, nil
+}
+
+func (c *Client) SetPosition(pos time.Duration) error {
+	return c.call("SetPosition", &pos, &struct{}{})
+}
+
+// PlayerPosition returns the player position in seconds.
+func (c *Client) PlayerPosition() (int, error) {
+	pos, err := c.Position()
+	return int(pos / time.Second), err
+}
+
+// SetPlayerPosition sets the player position in seconds.
+func (c *Client) SetPlayerPosition(pos int) error {
+	return c.SetPosition(time.Duration(pos) * time.Second)
+}
+
+// Progress returns the player position and the duration of the current track.
+func (c *Client) Progress() (pos, duration time.Duration, err error) {
+	st, err := c.Status()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return st.Position, st.Duration, nil
+}
+
+func (c *Client) control(command string) error {
+	return c.call("Control", &command, &struct{}{})
+}
+
+func (c *Client) Play() error          { return c.control("Play") }
+func (c *Client) Pause() error         { return c.control("Pause") }
+func (c *Client) PlayPause() error     { return c.control("PlayPause") }
+func (c *Client) Stop() error          { return c.control("Stop") }
+func (c *Client) Resume() error        { return c.control("Resume") }
+func (c *Client) BackTrack() error     { return c.control("BackTrack") }
+func (c *Client) NextTrack() error     { return c.control("NextTrack") }
+func (c *Client) PreviousTrack() error { return c.control("PreviousTrack") }
+func (c *Client) FastForward() error   { return c.control("FastForward") }
+func (c *Client) Rewind() error        { return c.control("Rewind") }
+
+func (c *Client) getTrack(method string, args interface{}) (*Track, error) {
+	var info TrackInfo
+	err := c.call(method, args, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTrack(c, &info), nil
+}
+
+func (c *Client) CurrentTrack() (*Track, error) {
+	return c.getTrack("CurrentTrack", &struct{}{})
+}
+
+func (c *Client) TrackCount() (int, error) {
+	var count int
+	err := c.call("TrackCount", &struct{}{}, &count)
+	return count, err
+}
+
+func (c *Client) GetTrack(index int) (*Track, error) {
+	return c.getTrack("GetTrack", &index)
+}
+
+func (c *Client) FindTrackByPersistentID(persistentID string) (*Track, error) {
+	return c.getTrack("FindTrack", &persistentID)
+}
+
+// GetTracks streams the tracks of the library.
+// An error in the middle of the stream is only logged and ends the channel early,
+// use GetAllTracks or EachTrack to get it.
+func (c *Client) GetTracks() (chan *Track, error) {
+	return c.streamTracks("")
+}
+
+// GetAllTracks returns the tracks of the library, it fails rather than returning a part of them.
+func (c *Client) GetAllTracks() ([]*Track, error) {
+	tracks := make([]*Track, 0, 100)
+	err := c.EachTrack(func(t *Track) error {
+		tracks = append(tracks, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+// EachTrack calls fn with the tracks of the library in their order.
+// It stops at the first error of the stream or of fn and returns it.
+func (c *Client) EachTrack(fn func(*Track) error) error {
+	return c.eachTrack("", fn)
+}
+
+func (c *Client) eachTrack(playlistID string, fn func(*Track) error) error {
+	s, err := c.openTracks(playlistID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		tracks, err := s.next()
+		if err != nil {
+			return err
+		}
+
+		for i := range tracks {
+			err = fn(newTrack(c, &tracks[i]))
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(tracks) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (c *Client) streamTracks(playlistID string) (chan *Track, error) {
+	s, err := c.openTracks(playlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan *Track)
+	go func() {
+		defer close(result)
+		for {
+			tracks, err := s.next()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			for i := range tracks {
+				result <- newTrack(c, &tracks[i])
+			}
+
+			if len(tracks) < batchSize {
+				return
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+// clientStream reads a stream of the server a batch at a time.
+type clientStream struct {
+	c          *Client
+	playlistID string
+	id         string
+	// sent is the number of tracks read, to resume the stream.
+	sent int
+}
+
+func (c *Client) openTracks(playlistID string) (*clientStream, error) {
+	s := &clientStream{c: c, playlistID: playlistID}
+	err := c.call("OpenTracks", &TracksRequest{PlaylistID: playlistID}, &s.id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// next returns the next batch, the stream ends with a batch smaller than batchSize.
+func (s *clientStream) next() ([]TrackInfo, error) {
+	var tracks []TrackInfo
+	err := s.c.call("NextTracks", &s.id, &tracks)
+	if errors.Is(err, ErrNotFound) {
+		// the stream was lost with the connection, reopen it where it stopped.
+		err = s.c.call("OpenTracks", &TracksRequest{PlaylistID: s.playlistID, Skip: s.sent}, &s.id)
+		if err == nil {
+			err = s.c.call("NextTracks", &s.id, &tracks)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.sent += len(tracks)
+	return tracks, nil
+}
+
+func (c *Client) getPlaylist(method string, args interface{}) (*Playlist, error) {
+	var info PlaylistInfo
+	err := c.call(method, args, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPlaylist(c, &info), nil
+}
+
+func (c *Client) PlaylistCount() (int, error) {
+	var count int
+	err := c.call("PlaylistCount", &struct{}{}, &count)
+	return count, err
+}
+
+func (c *Client) GetPlaylist(index int) (*Playlist, error) {
+	return c.getPlaylist("GetPlaylist", &index)
+}
+
+func (c *Client) FindPlaylistByPersistentID(persistentID string) (*Playlist, error) {
+	return c.getPlaylist("FindPlaylist", &persistentID)
+}
+
+func (c *Client) CurrentPlaylist() (*Playlist, error) {
+	return c.getPlaylist("CurrentPlaylist", &struct{}{})
+}
+
+func (c *Client) CreatePlaylist(name string) (*Playlist, error) {
+	return c.getPlaylist("CreatePlaylist", &name)
+}
@@ -0,0 +1,484 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesPlayer struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesPlayer returns the Player of an iTunes application.
+// The requests are passed to itunes as they are, its finders reject malformed IDs and its scripts quote the names.
+func NewItunesPlayer(it *itunes.Itunes) Player {
+	return &itunesPlayer{it: it}
+}
+
+// convertError wraps the typed errors of itunes in the errors of this package.
+func convertError(err error) error {
+	var notFound *itunes.NotFoundError
+	var notSupported *itunes.NotSupportedError
+	var outOfRange *itunes.OutOfRangeError
+	switch {
+	case errors.As(err, &notFound):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.As(err, &notSupported):
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	case errors.As(err, &outOfRange):
+		return fmt.Errorf("%w: %v", ErrOutOfRange, err)
+	case errors.Is(err, itunes.ErrNoCurrentTrack):
+		return ErrNoCurrentTrack
+	}
+
+	return err
+}
+
+func newTrackInfo(t *itunes.Track) *TrackInfo {
+	return &TrackInfo{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Genre:        t.Genre(),
+		Location:     t.Location(),
+		IsFile:       t.IsFileTrack(),
+		Year:         t.Year(),
+		Duration:     t.Duration(),
+		BitRate:      t.BitRate(),
+		PlayedCount:  t.PlayedCount(),
+		Rating:       t.Rating(),
+		Size:         t.Size(),
+		DateAdded:    t.DateAdded(),
+		Comment:      t.Comment(),
+		Loved:        t.Loved(),
+	}
+}
+
+func newPlaylistInfo(p *itunes.Playlist) (*PlaylistInfo, error) {
+	count, err := p.TrackCount()
+	if err != nil {
+		return nil, convertError(err)
+	}
+
+	return &PlaylistInfo{
+		PersistentID: p.PersistentID(),
+		Name:         p.Name(),
+		IsUser:       p.IsUserPlaylist(),
+		TrackCount:   count,
+	}, nil
+}
+
+func (p *itunesPlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	volume, err := p.it.SoundVolume()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: state.String(), Volume: volume}
+	t, err := p.it.CurrentTrack()
+	if errors.Is(err, itunes.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	st.Track = newTrackInfo(t)
+	st.Duration = t.Duration()
+
+	st.Position, err = p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+var controls = map[string]func(it *itunes.Itunes) error{
+	"Play":          (*itunes.Itunes).Play,
+	"Pause":         (*itunes.Itunes).Pause,
+	"PlayPause":     (*itunes.Itunes).PlayPause,
+	"Stop":          (*itunes.Itunes).Stop,
+	"Resume":        (*itunes.Itunes).Resume,
+	"BackTrack":     (*itunes.Itunes).BackTrack,
+	"NextTrack":     (*itunes.Itunes).NextTrack,
+	"PreviousTrack": (*itunes.Itunes).PreviousTrack,
+	"FastForward":   (*itunes.Itunes).FastForward,
+	"Rewind":        (*itunes.Itunes).Rewind,
+}
+
+func (p *itunesPlayer) Control(command string) error {
+	control, ok := controls[command]
+	if !ok {
+		return fmt.Errorf("%w: command:%v", ErrNotSupported, command)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return convertError(control(p.it))
+}
+
+func (p *itunesPlayer) SetSoundVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return convertError(p.it.SetSoundVolume(volume))
+}
+
+func (p *itunesPlayer) Mute() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	muted, err := p.it.Mute()
+	return muted, convertError(err)
+}
+
+func (p *itunesPlayer) SetMute(muted bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return convertError(p.it.SetMute(muted))
+}
+
+func (p *itunesPlayer) SetPosition(pos time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return convertError(p.it.SetPosition(pos))
+}
+
+func (p *itunesPlayer) CurrentTrack() (*TrackInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.CurrentTrack()
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	return newTrackInfo(t), nil
+}
+
+func (p *itunesPlayer) TrackCount() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count, err := p.it.TrackCount()
+	return count, convertError(err)
+}
+
+func (p *itunesPlayer) GetTrack(index int) (*TrackInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.GetTrack(index)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	return newTrackInfo(t), nil
+}
+
+func (p *itunesPlayer) FindTrack(persistentID string) (*TrackInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.FindTrackByPersistentID(persistentID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	return newTrackInfo(t), nil
+}
+
+type trackStream struct {
+	p        *itunesPlayer
+	output   chan *itunes.Track
+	playlist *itunes.Playlist
+}
+
+func (p *itunesPlayer) Tracks(playlistID string) (TrackStream, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if playlistID == "" {
+		output, err := p.it.GetTracks()
+		if err != nil {
+			return nil, convertError(err)
+		}
+
+		return &trackStream{p: p, output: output}, nil
+	}
+
+	pl, err := p.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+
+	output, err := pl.GetTracks()
+	if err != nil {
+		pl.Close()
+		return nil, convertError(err)
+	}
+
+	return &trackStream{p: p, output: output, playlist: pl}, nil
+}
+
+func (s *trackStream) Next(n int) ([]TrackInfo, error) {
+	s.p.mu.Lock()
+	defer s.p.mu.Unlock()
+
+	tracks := make([]TrackInfo, 0, n)
+	for len(tracks) < n {
+		t, ok := <-s.output
+		if !ok {
+			break
+		}
+
+		tracks = append(tracks, *newTrackInfo(t))
+		t.Close()
+	}
+
+	return tracks, nil
+}
+
+func (s *trackStream) Close() {
+	if s.playlist != nil {
+		s.playlist.Close()
+	}
+
+	// the tracks are sent until the end, drain them so that the sender finishes.
+	go func() {
+		for t := range s.output {
+			t.Close()
+		}
+	}()
+}
+
+func (p *itunesPlayer) PlayTrack(persistentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.FindTrackByPersistentID(persistentID)
+	if err != nil {
+		return convertError(err)
+	}
+	defer t.Close()
+
+	return convertError(t.Play())
+}
+
+func (p *itunesPlayer) Artworks(persistentID string) ([]ArtworkData, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.FindTrackByPersistentID(persistentID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	output, err := t.GetArtworks()
+	if err != nil {
+		return nil, convertError(err)
+	}
+
+	artworks := make([]ArtworkData, 0, 1)
+	for a := range output {
+		if err == nil {
+			var buf bytes.Buffer
+			_, err = a.WriteTo(&buf)
+			artworks = append(artworks, ArtworkData{ContentType: a.Format().MIMEType(), Data: buf.Bytes()})
+		}
+		a.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return artworks, nil
+}
+
+func (p *itunesPlayer) PlaylistCount() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count, err := p.it.PlaylistCount()
+	return count, convertError(err)
+}
+
+func (p *itunesPlayer) GetPlaylist(index int) (*PlaylistInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.GetPlaylist(index)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer pl.Close()
+
+	return newPlaylistInfo(pl)
+}
+
+func (p *itunesPlayer) FindPlaylist(persistentID string) (*PlaylistInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.FindPlaylistByPersistentID(persistentID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer pl.Close()
+
+	return newPlaylistInfo(pl)
+}
+
+func (p *itunesPlayer) CurrentPlaylist() (*PlaylistInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.CurrentPlaylist()
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer pl.Close()
+
+	return newPlaylistInfo(pl)
+}
+
+func (p *itunesPlayer) PlayPlaylist(persistentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.FindPlaylistByPersistentID(persistentID)
+	if err != nil {
+		return convertError(err)
+	}
+	defer pl.Close()
+
+	return convertError(pl.PlayFirstTrack())
+}
+
+func (p *itunesPlayer) UpdateTrack(u *TrackUpdate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.FindTrackByPersistentID(u.PersistentID)
+	if err != nil {
+		return convertError(err)
+	}
+	defer t.Close()
+
+	if u.Rating != nil {
+		err = t.SetRating(*u.Rating)
+		if err != nil {
+			return convertError(err)
+		}
+	}
+	if u.PlayedCount != nil {
+		err = t.SetPlayedCount(*u.PlayedCount)
+		if err != nil {
+			return convertError(err)
+		}
+	}
+	if u.Comment != nil {
+		err = t.SetComment(*u.Comment)
+		if err != nil {
+			return convertError(err)
+		}
+	}
+	if u.Loved != nil {
+		err = t.SetLoved(*u.Loved)
+		if err != nil {
+			return convertError(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *itunesPlayer) CreatePlaylist(name string) (*PlaylistInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.CreatePlaylist(name)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer pl.Close()
+
+	return newPlaylistInfo(pl)
+}
+
+// withPlaylist calls fn with the playlist, under the lock.
+func (p *itunesPlayer) withPlaylist(playlistID string, fn func(pl *itunes.Playlist) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return convertError(err)
+	}
+	defer pl.Close()
+
+	return convertError(fn(pl))
+}
+
+func (p *itunesPlayer) SetPlaylistName(playlistID, name string) error {
+	return p.withPlaylist(playlistID, func(pl *itunes.Playlist) error {
+		return pl.SetName(name)
+	})
+}
+
+func (p *itunesPlayer) AddPlaylistTrack(playlistID, trackID string) error {
+	return p.withPlaylist(playlistID, func(pl *itunes.Playlist) error {
+		t, err := p.it.FindTrackByPersistentID(trackID)
+		if err != nil {
+			return err
+		}
+		defer t.Close()
+
+		added, err := pl.AddTrack(t)
+		if err != nil {
+			return err
+		}
+		added.Close()
+
+		return nil
+	})
+}
+
+func (p *itunesPlayer) RemovePlaylistTrack(playlistID, trackID string) error {
+	return p.withPlaylist(playlistID, func(pl *itunes.Playlist) error {
+		t, err := p.it.FindTrackByPersistentID(trackID)
+		if err != nil {
+			return err
+		}
+		defer t.Close()
+
+		return pl.RemoveTrack(t)
+	})
+}
+
+func (p *itunesPlayer) DeletePlaylist(playlistID string) error {
+	return p.withPlaylist(playlistID, func(pl *itunes.Playlist) error {
+		return pl.Delete()
+	})
+}
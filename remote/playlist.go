@@ -0,0 +1,86 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+// Playlist is a playlist of the remote iTunes, with the methods of itunes.Playlist.
+type Playlist struct {
+	c    *Client
+	info PlaylistInfo
+}
+
+func newPlaylist(c *Client, info *PlaylistInfo) *Playlist {
+	return &Playlist{c: c, info: *info}
+}
+
+// for compatibility
+func (_ *Playlist) Close() {
+}
+
+func (p *Playlist) Name() string {
+	return p.info.Name
+}
+
+func (p *Playlist) PersistentID() string {
+	return p.info.PersistentID
+}
+
+func (p *Playlist) IsUserPlaylist() bool {
+	return p.info.IsUser
+}
+
+// TrackCount returns the number of tracks when the playlist was got, counting those added and removed since by p.
+func (p *Playlist) TrackCount() (int, error) {
+	return p.info.TrackCount, nil
+}
+
+// GetTracks streams the tracks of the playlist, errors are handled like Client.GetTracks.
+func (p *Playlist) GetTracks() (chan *Track, error) {
+	return p.c.streamTracks(p.info.PersistentID)
+}
+
+// EachTrack calls fn with the tracks of the playlist in their order.
+// It stops at the first error of the stream or of fn and returns it.
+func (p *Playlist) EachTrack(fn func(*Track) error) error {
+	return p.c.eachTrack(p.info.PersistentID, fn)
+}
+
+func (p *Playlist) PlayFirstTrack() error {
+	return p.c.call("PlayPlaylist", &p.info.PersistentID, &struct{}{})
+}
+
+// AddTrack appends the track to the user playlist.
+func (p *Playlist) AddTrack(t *Track) (*Track, error) {
+	err := p.c.call("AddPlaylistTrack", &PlaylistRequest{PlaylistID: p.info.PersistentID, TrackID: t.info.PersistentID}, &struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	p.info.TrackCount++
+	return newTrack(p.c, &t.info), nil
+}
+
+// RemoveTrack removes the track from the user playlist, it stays in the library.
+func (p *Playlist) RemoveTrack(t *Track) error {
+	err := p.c.call("RemovePlaylistTrack", &PlaylistRequest{PlaylistID: p.info.PersistentID, TrackID: t.info.PersistentID}, &struct{}{})
+	if err != nil {
+		return err
+	}
+
+	p.info.TrackCount--
+	return nil
+}
+
+func (p *Playlist) SetName(name string) error {
+	err := p.c.call("SetPlaylistName", &PlaylistRequest{PlaylistID: p.info.PersistentID, Name: name}, &struct{}{})
+	if err != nil {
+		return err
+	}
+
+	p.info.Name = name
+	return nil
+}
+
+func (p *Playlist) Delete() error {
+	return p.c.call("DeletePlaylist", &p.info.PersistentID, &struct{}{})
+}
@@ -0,0 +1,196 @@
+// Package remote controls the player of another machine.
+//
+// The server wraps the player of the machine running iTunes and the client offers the API of Itunes
+// over the network, so that services elsewhere can use it.
+// The protocol is JSON-RPC 1.0 of net/rpc/jsonrpc, preceded by a handshake carrying the token.
+// Serve it over TLS unless the network is trusted.
+//
+// GetTracks streams the tracks a batch at a time, and the client reconnects when the connection is lost,
+// resuming a stream where it stopped.
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors for a missing track, playlist or stream.
+	ErrNotFound = errors.New("not found")
+	// ErrNotSupported is wrapped by the errors for a feature the platform of the server does not provide.
+	ErrNotSupported = errors.New("not supported")
+	// ErrNoCurrentTrack is wrapped by the errors when the player has no track.
+	ErrNoCurrentTrack = errors.New("no current track")
+	// ErrOutOfRange is wrapped by the errors for a value such as the volume out of range.
+	ErrOutOfRange = errors.New("out of range")
+	// ErrUnauthorized is returned by Dial when the server rejects the token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrTimeout is wrapped by the errors of the calls exceeding Options.CallTimeout.
+	ErrTimeout = errors.New("timeout")
+)
+
+// the errors are sent as text, these keep their identity across the connection.
+var wireErrors = []error{ErrNotFound, ErrNotSupported, ErrNoCurrentTrack, ErrOutOfRange, ErrUnauthorized}
+
+// TrackInfo is a track sent by the server, it is a type of the wire protocol.
+// The client wraps it in a Track.
+type TrackInfo struct {
+	PersistentID string
+	Name         string
+	Artist       string
+	Album        string
+	Genre        string
+	// Location is the file path of a file track or the URL of a URL track.
+	Location    string
+	IsFile      bool
+	Year        int
+	Duration    time.Duration
+	BitRate     int
+	PlayedCount int
+	// Rating is from 0 to 100, 20 per star.
+	Rating    int
+	Size      int64
+	DateAdded time.Time
+	Comment   string
+	Loved     bool
+}
+
+// PlaylistInfo is a playlist sent by the server, it is a type of the wire protocol.
+type PlaylistInfo struct {
+	PersistentID string
+	Name         string
+	IsUser       bool
+	TrackCount   int
+}
+
+// TrackUpdate sets the user metadata of a track, the nil fields are left unchanged.
+// It is a type of the wire protocol.
+type TrackUpdate struct {
+	PersistentID string
+	Rating       *int
+	PlayedCount  *int
+	Comment      *string
+	Loved        *bool
+}
+
+// PlaylistRequest edits a user playlist, it is a type of the wire protocol.
+type PlaylistRequest struct {
+	PlaylistID string
+	// TrackID is the track added or removed.
+	TrackID string `json:",omitempty"`
+	// Name is the new name of the playlist.
+	Name string `json:",omitempty"`
+}
+
+// ArtworkData is an artwork with its image, it is a type of the wire protocol.
+type ArtworkData struct {
+	ContentType string
+	Data        []byte
+}
+
+type Status struct {
+	// State is the String of itunes.PlayerState, such as "Playing".
+	State    string
+	Volume   int
+	Position time.Duration
+	Duration time.Duration
+	// Track is nil if the player has no track.
+	Track *TrackInfo
+}
+
+// Commands are the player commands without arguments, run by Player.Control.
+var Commands = []string{
+	"Play", "Pause", "PlayPause", "Stop", "Resume",
+	"BackTrack", "NextTrack", "PreviousTrack", "FastForward", "Rewind",
+}
+
+// TrackStream yields the tracks of the library or of a playlist.
+type TrackStream interface {
+	// Next returns up to n tracks, fewer only at the end.
+	Next(n int) ([]TrackInfo, error)
+	Close()
+}
+
+// Player is what the server exposes, NewItunesPlayer implements it for iTunes.
+// The IDs and names are passed as the clients sent them, a player must not trust them.
+type Player interface {
+	Status() (*Status, error)
+	// Control runs one of Commands.
+	Control(command string) error
+	SetSoundVolume(volume int) error
+	Mute() (bool, error)
+	SetMute(muted bool) error
+	SetPosition(pos time.Duration) error
+
+	CurrentTrack() (*TrackInfo, error)
+	TrackCount() (int, error)
+	GetTrack(index int) (*TrackInfo, error)
+	FindTrack(persistentID string) (*TrackInfo, error)
+	// Tracks streams the tracks of the playlist, or of the library if playlistID is empty.
+	Tracks(playlistID string) (TrackStream, error)
+	PlayTrack(persistentID string) error
+	Artworks(persistentID string) ([]ArtworkData, error)
+
+	PlaylistCount() (int, error)
+	GetPlaylist(index int) (*PlaylistInfo, error)
+	FindPlaylist(persistentID string) (*PlaylistInfo, error)
+	CurrentPlaylist() (*PlaylistInfo, error)
+	PlayPlaylist(persistentID string) error
+
+	UpdateTrack(u *TrackUpdate) error
+	CreatePlaylist(name string) (*PlaylistInfo, error)
+	SetPlaylistName(playlistID, name string) error
+	// AddPlaylistTrack appends the track to the user playlist.
+	AddPlaylistTrack(playlistID, trackID string) error
+	// RemovePlaylistTrack removes the track from the user playlist, it stays in the library.
+	RemovePlaylistTrack(playlistID, trackID string) error
+	DeletePlaylist(playlistID string) error
+}
+
+// the version of the protocol, exchanged by the handshake.
+const protocolVersion = 1
+
+type handshake struct {
+	Version int    `json:"version"`
+	Token   string `json:"token"`
+}
+
+type handshakeReply struct {
+	Error string `json:"error,omitempty"`
+}
+
+// TracksRequest opens a stream of tracks, it is a type of the wire protocol.
+type TracksRequest struct {
+	// PlaylistID is empty for the library.
+	PlaylistID string
+	// Skip is the number of tracks the client already has, when it reopens a stream after reconnecting.
+	Skip int
+}
+
+// encodeError makes the message of err start with the text of its wire error.
+func encodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, e := range wireErrors {
+		if errors.Is(err, e) && !strings.HasPrefix(err.Error(), e.Error()) {
+			return fmt.Errorf("%w: %v", e, err)
+		}
+	}
+
+	return err
+}
+
+// decodeError restores the wire error of a message sent by encodeError.
+func decodeError(message string) error {
+	for _, e := range wireErrors {
+		if message == e.Error() || strings.HasPrefix(message, e.Error()+": ") {
+			return fmt.Errorf("%w%v", e, message[len(e.Error()):])
+		}
+	}
+
+	return errors.New(message)
+}
@@ -0,0 +1,657 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type fakePlayer struct {
+	mu     sync.Mutex
+	status Status
+	calls  []string
+	tracks []TrackInfo
+}
+
+func newFakePlayer(n int) *fakePlayer {
+	p := &fakePlayer{status: Status{State: "Playing", Volume: 30}}
+	for i := 0; i < n; i++ {
+		p.tracks = append(p.tracks, TrackInfo{PersistentID: fmt.Sprintf("T%03d", i), Name: fmt.Sprintf("Track %v", i), Duration: time.Minute})
+	}
+	p.status.Track = &p.tracks[0]
+	p.status.Duration = time.Minute
+	return p
+}
+
+func (p *fakePlayer) call(format string, args ...interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (p *fakePlayer) takeCalls() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	calls := strings.Join(p.calls, ",")
+	p.calls = nil
+	return calls
+}
+
+func (p *fakePlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.status
+	return &st, nil
+}
+
+func (p *fakePlayer) Control(command string) error {
+	if command == "FastForward" {
+		return fmt.Errorf("%w: FastForward", ErrNotSupported)
+	}
+	return p.call("%v", command)
+}
+
+func (p *fakePlayer) SetSoundVolume(volume int) error     { return p.call("volume %v", volume) }
+func (p *fakePlayer) Mute() (bool, error)                 { return true, nil }
+func (p *fakePlayer) SetMute(muted bool) error            { return p.call("mute %v", muted) }
+func (p *fakePlayer) SetPosition(pos time.Duration) error { return p.call("position %v", pos) }
+
+func (p *fakePlayer) CurrentTrack() (*TrackInfo, error) {
+	st, _ := p.Status()
+	if st.Track == nil {
+		return nil, ErrNoCurrentTrack
+	}
+	return st.Track, nil
+}
+
+func (p *fakePlayer) TrackCount() (int, error) {
+	return len(p.tracks), nil
+}
+
+func (p *fakePlayer) GetTrack(index int) (*TrackInfo, error) {
+	if index < 0 || len(p.tracks) <= index {
+		return nil, fmt.Errorf("%w: track:%v", ErrNotFound, index)
+	}
+	return &p.tracks[index], nil
+}
+
+func (p *fakePlayer) FindTrack(persistentID string) (*TrackInfo, error) {
+	for i := range p.tracks {
+		if p.tracks[i].PersistentID == persistentID {
+			return &p.tracks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: track:%v", ErrNotFound, persistentID)
+}
+
+type fakeStream struct {
+	tracks []TrackInfo
+	// failAt fails the batch reaching the track at this index if it is not 0.
+	failAt int
+	read   int
+}
+
+func (s *fakeStream) Next(n int) ([]TrackInfo, error) {
+	if s.failAt > 0 && s.read+n > s.failAt {
+		return nil, errors.New("library changed")
+	}
+	s.read += n
+	if n > len(s.tracks) {
+		n = len(s.tracks)
+	}
+	tracks := s.tracks[:n]
+	s.tracks = s.tracks[n:]
+	return tracks, nil
+}
+
+func (s *fakeStream) Close() {}
+
+func (p *fakePlayer) Tracks(playlistID string) (TrackStream, error) {
+	switch playlistID {
+	case "":
+		return &fakeStream{tracks: p.tracks}, nil
+	case "P1":
+		return &fakeStream{tracks: p.tracks[:3]}, nil
+	case "P2":
+		return &fakeStream{tracks: p.tracks, failAt: 150}, nil
+	}
+	return nil, fmt.Errorf("%w: playlist:%v", ErrNotFound, playlistID)
+}
+
+func (p *fakePlayer) PlayTrack(persistentID string) error {
+	if persistentID == "slow" {
+		time.Sleep(500 * time.Millisecond)
+	}
+	return p.call("play %v", persistentID)
+}
+
+func (p *fakePlayer) Artworks(persistentID string) ([]ArtworkData, error) {
+	if persistentID != "T000" {
+		return []ArtworkData{}, nil
+	}
+	return []ArtworkData{{ContentType: "image/png", Data: []byte("\x89PNG\x00\xff")}}, nil
+}
+
+var playlists = []PlaylistInfo{{PersistentID: "P1", Name: "Mix", IsUser: true}, {PersistentID: "P2", Name: "Library"}}
+
+func (p *fakePlayer) PlaylistCount() (int, error) {
+	return len(playlists), nil
+}
+
+func (p *fakePlayer) GetPlaylist(index int) (*PlaylistInfo, error) {
+	if index < 0 || len(playlists) <= index {
+		return nil, fmt.Errorf("%w: playlist:%v", ErrNotFound, index)
+	}
+	return &playlists[index], nil
+}
+
+func (p *fakePlayer) FindPlaylist(persistentID string) (*PlaylistInfo, error) {
+	for i := range playlists {
+		if playlists[i].PersistentID == persistentID {
+			return &playlists[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: playlist:%v", ErrNotFound, persistentID)
+}
+
+func (p *fakePlayer) CurrentPlaylist() (*PlaylistInfo, error) {
+	return &playlists[0], nil
+}
+
+func (p *fakePlayer) PlayPlaylist(persistentID string) error {
+	return p.call("playlist %v", persistentID)
+}
+
+func (p *fakePlayer) UpdateTrack(u *TrackUpdate) error {
+	_, err := p.FindTrack(u.PersistentID)
+	if err != nil {
+		return err
+	}
+
+	fields := []string{"update " + u.PersistentID}
+	if u.Rating != nil {
+		fields = append(fields, fmt.Sprintf("rating %v", *u.Rating))
+	}
+	if u.PlayedCount != nil {
+		fields = append(fields, fmt.Sprintf("played %v", *u.PlayedCount))
+	}
+	if u.Comment != nil {
+		fields = append(fields, fmt.Sprintf("comment %q", *u.Comment))
+	}
+	if u.Loved != nil {
+		fields = append(fields, fmt.Sprintf("loved %v", *u.Loved))
+	}
+	return p.call("%v", strings.Join(fields, " "))
+}
+
+func (p *fakePlayer) CreatePlaylist(name string) (*PlaylistInfo, error) {
+	p.call("create %v", name)
+	return &PlaylistInfo{PersistentID: "P3", Name: name, IsUser: true}, nil
+}
+
+// editPlaylist records the edit of a user playlist.
+func (p *fakePlayer) editPlaylist(playlistID string, format string, args ...interface{}) error {
+	pl, err := p.FindPlaylist(playlistID)
+	if err != nil {
+		return err
+	}
+	if !pl.IsUser {
+		return fmt.Errorf("%v is not a user playlist", playlistID)
+	}
+	return p.call("%v "+format, append([]interface{}{playlistID}, args...)...)
+}
+
+func (p *fakePlayer) SetPlaylistName(playlistID, name string) error {
+	return p.editPlaylist(playlistID, "name %v", name)
+}
+
+func (p *fakePlayer) AddPlaylistTrack(playlistID, trackID string) error {
+	return p.editPlaylist(playlistID, "add %v", trackID)
+}
+
+func (p *fakePlayer) RemovePlaylistTrack(playlistID, trackID string) error {
+	return p.editPlaylist(playlistID, "remove %v", trackID)
+}
+
+func (p *fakePlayer) DeletePlaylist(playlistID string) error {
+	return p.editPlaylist(playlistID, "delete")
+}
+
+// testTLS returns the configurations of a server with a self-signed certificate and of its clients.
+func testTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "itunes"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: roots}
+}
+
+// testListener remembers the accepted connections so that a test can cut them.
+type testListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *testListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, c)
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+// shutdown closes the listener and the connections, as if the server stopped.
+func (l *testListener) shutdown() {
+	l.Listener.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+}
+
+func serve(t *testing.T, p Player, addr string, config *tls.Config) *testListener {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config != nil {
+		l = tls.NewListener(l, config)
+	}
+
+	tl := &testListener{Listener: l}
+	t.Cleanup(tl.shutdown)
+	go NewServer(p, "sesame").Serve(tl)
+	return tl
+}
+
+func TestClient(t *testing.T) {
+	serverTLS, clientTLS := testTLS(t)
+	p := newFakePlayer(3)
+	l := serve(t, p, "127.0.0.1:0", serverTLS)
+
+	c, err := Dial(l.Addr().String(), &Options{TLSConfig: clientTLS, Token: "sesame"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	state, err := c.PlayerState()
+	if err != nil || state != itunes.Playing {
+		t.Errorf("unexpected state: %v %v", state, err)
+	}
+	pos, duration, err := c.Progress()
+	if err != nil || pos != 0 || duration != time.Minute {
+		t.Errorf("unexpected progress: %v %v %v", pos, duration, err)
+	}
+
+	for _, f := range []func() error{c.Play, c.PlayPause, c.NextTrack, c.BackTrack} {
+		err = f()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	err = c.SetSoundVolume(50)
+	if err != nil {
+		t.Error(err)
+	}
+	err = c.SetMute(true)
+	if err != nil {
+		t.Error(err)
+	}
+	err = c.SetPosition(90 * time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+	track, err := c.FindTrackByPersistentID("T001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = track.Play()
+	if err != nil {
+		t.Error(err)
+	}
+	pl, err := c.FindPlaylistByPersistentID("P1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pl.PlayFirstTrack()
+	if err != nil {
+		t.Error(err)
+	}
+	if calls := p.takeCalls(); calls != "Play,PlayPause,NextTrack,BackTrack,volume 50,mute true,position 1m30s,play T001,playlist P1" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+
+	err = c.FastForward()
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expect ErrNotSupported, but %v", err)
+	}
+	_, err = c.FindTrackByPersistentID("T999")
+	if !errors.Is(err, ErrNotFound) || err.Error() != "not found: track:T999" {
+		t.Errorf("expect ErrNotFound, but %v", err)
+	}
+	err = c.SetSoundVolume(101)
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expect ErrOutOfRange, but %v", err)
+	}
+
+	track, err = c.CurrentTrack()
+	if err != nil || track.Name() != "Track 0" {
+		t.Errorf("unexpected track: %v %v", track, err)
+	}
+	p.mu.Lock()
+	p.status.Track = nil
+	p.mu.Unlock()
+	_, err = c.CurrentTrack()
+	if !errors.Is(err, ErrNoCurrentTrack) {
+		t.Errorf("expect ErrNoCurrentTrack, but %v", err)
+	}
+
+	artworks, err := track.GetArtworks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for a := range artworks {
+		var data strings.Builder
+		_, err = a.WriteTo(&data)
+		if err != nil || a.Format() != itunes.PNG || data.String() != "\x89PNG\x00\xff" {
+			t.Errorf("unexpected artwork: %v %q %v", a.Format(), data.String(), err)
+		}
+	}
+
+	count, err := c.PlaylistCount()
+	if err != nil || count != 2 {
+		t.Errorf("unexpected playlist count: %v %v", count, err)
+	}
+	pl, err = c.GetPlaylist(1)
+	if err != nil || pl.Name() != "Library" {
+		t.Errorf("unexpected playlist: %v %v", pl, err)
+	}
+	pl, err = c.CurrentPlaylist()
+	if err != nil || pl.PersistentID() != "P1" || !pl.IsUserPlaylist() {
+		t.Errorf("unexpected playlist: %v %v", pl, err)
+	}
+
+	output, err := pl.GetTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for track := range output {
+		names = append(names, track.Name())
+	}
+	if strings.Join(names, ",") != "Track 0,Track 1,Track 2" {
+		t.Errorf("unexpected tracks: %v", names)
+	}
+
+	missing := newPlaylist(c, &PlaylistInfo{PersistentID: "P9"})
+	_, err = missing.GetTracks()
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect ErrNotFound, but %v", err)
+	}
+
+	track, err = c.FindTrackByPersistentID("T001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []func() error{
+		func() error { return track.SetRating(80) },
+		func() error { return track.SetComment("live") },
+		func() error { return track.SetLoved(true) },
+	} {
+		err = f()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if track.Rating() != 80 || track.Comment() != "live" || !track.Loved() {
+		t.Errorf("unexpected track: %v %q %v", track.Rating(), track.Comment(), track.Loved())
+	}
+	err = newTrack(c, &TrackInfo{PersistentID: "T999"}).SetRating(80)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect ErrNotFound, but %v", err)
+	}
+	created, err := c.CreatePlaylist("New")
+	if err != nil || created.PersistentID() != "P3" || created.Name() != "New" {
+		t.Errorf("unexpected playlist: %v %v", created, err)
+	}
+	other, err := c.FindTrackByPersistentID("T002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := c.FindTrackByPersistentID("T000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []func() error{
+		func() error { return pl.SetName("Mix 2") },
+		func() error { _, err := pl.AddTrack(other); return err },
+		func() error { return pl.RemoveTrack(first) },
+		func() error { return pl.Delete() },
+	} {
+		err = f()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if calls := p.takeCalls(); calls != `update T001 rating 80,update T001 comment "live",update T001 loved true,create New,P1 name Mix 2,P1 add T002,P1 remove T000,P1 delete` {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if pl.Name() != "Mix 2" {
+		t.Errorf("unexpected name: %v", pl.Name())
+	}
+	_, err = missing.AddTrack(first)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect ErrNotFound, but %v", err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	serverTLS, clientTLS := testTLS(t)
+	l := serve(t, newFakePlayer(1), "127.0.0.1:0", serverTLS)
+	addr := l.Addr().String()
+
+	_, err := Dial(addr, &Options{TLSConfig: clientTLS, Token: "wrong"})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expect ErrUnauthorized, but %v", err)
+	}
+
+	_, err = Dial(addr, &Options{TLSConfig: &tls.Config{}, Token: "sesame"})
+	if err == nil {
+		t.Error("expect the certificate to be rejected")
+	}
+
+	_, err = Dial(addr, &Options{Token: "sesame", DialTimeout: time.Second})
+	if err == nil {
+		t.Error("expect a plain connection to fail")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	p := newFakePlayer(250)
+	l := serve(t, p, "127.0.0.1:0", nil)
+	addr := l.Addr().String()
+
+	c, err := Dial(addr, &Options{Token: "sesame"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	output, err := c.GetTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for track := range output {
+		ids = append(ids, track.PersistentID())
+		if len(ids) == 150 {
+			// the server restarts in the middle of the stream.
+			l.shutdown()
+			serve(t, p, addr, nil)
+		}
+	}
+
+	if len(ids) != 250 {
+		t.Fatalf("expect 250 tracks, but %v", len(ids))
+	}
+	for i, id := range ids {
+		if id != fmt.Sprintf("T%03d", i) {
+			t.Fatalf("unexpected track at %v: %v", i, id)
+		}
+	}
+
+	count, err := c.TrackCount()
+	if err != nil || count != 250 {
+		t.Errorf("unexpected track count: %v %v", count, err)
+	}
+	err = c.Play()
+	if err != nil {
+		t.Error(err)
+	}
+
+	l.shutdown()
+	c.Close()
+	_, err = c.TrackCount()
+	if err == nil {
+		t.Error("expect a closed client to fail")
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	l := serve(t, newFakePlayer(250), "127.0.0.1:0", nil)
+
+	c, err := Dial(l.Addr().String(), &Options{Token: "sesame"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	pl, err := c.FindPlaylistByPersistentID("P2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	err = pl.EachTrack(func(*Track) error {
+		n++
+		return nil
+	})
+	if err == nil || n != 100 {
+		t.Errorf("expect the stream to fail after a batch, but %v tracks and %v", n, err)
+	}
+
+	tracks, err := c.GetAllTracks()
+	if err != nil || len(tracks) != 250 {
+		t.Errorf("unexpected tracks: %v %v", len(tracks), err)
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	p := newFakePlayer(1)
+	l := serve(t, p, "127.0.0.1:0", nil)
+
+	c, err := Dial(l.Addr().String(), &Options{Token: "sesame", CallTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	err = newTrack(c, &TrackInfo{PersistentID: "slow"}).Play()
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expect ErrTimeout, but %v", err)
+	}
+
+	// the next call dials again.
+	err = newTrack(c, &TrackInfo{PersistentID: "T000"}).Play()
+	if err != nil {
+		t.Error(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if calls := p.takeCalls(); calls != "play T000,play slow" {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+func TestStreamLimit(t *testing.T) {
+	svc := &service{player: newFakePlayer(1), streams: map[string]TrackStream{}}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ids []string
+	for i := 0; i < maxStreams*2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var id string
+			err := svc.OpenTracks(&TracksRequest{}, &id)
+			if err == nil {
+				mu.Lock()
+				ids = append(ids, id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != maxStreams || len(svc.streams) != maxStreams {
+		t.Fatalf("expect %v streams, but %v opened and %v kept", maxStreams, len(ids), len(svc.streams))
+	}
+
+	// a failed stream gives its place back.
+	var id string
+	svc.closeStream(ids[0])
+	err := svc.OpenTracks(&TracksRequest{PlaylistID: "P9"}, &id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect not found, but %v", err)
+	}
+	err = svc.OpenTracks(&TracksRequest{}, &id)
+	if err != nil {
+		t.Errorf("expect a stream, but %v", err)
+	}
+}
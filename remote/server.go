@@ -0,0 +1,368 @@
+package remote
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+	"time"
+)
+
+const (
+	// the number of tracks sent by each NextTracks.
+	batchSize = 100
+	// a client streams a few at a time, more are leaked streams.
+	maxStreams       = 16
+	handshakeTimeout = 10 * time.Second
+)
+
+type Server struct {
+	player Player
+	token  string
+}
+
+// NewServer returns the server of the player. Clients must send the token unless it is empty.
+func NewServer(player Player, token string) *Server {
+	return &Server{player: player, token: token}
+}
+
+// ListenAndServe listens on the TCP address addr and serves the clients, over TLS unless config is nil.
+func (s *Server) ListenAndServe(addr string, config *tls.Config) error {
+	var l net.Listener
+	var err error
+	if config != nil {
+		l, err = tls.Listen("tcp", addr, config)
+	} else {
+		l, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve serves the clients accepted by l until l fails.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.serveConn(c)
+	}
+}
+
+// handshake checks the version and the token sent by the client before any call.
+func (s *Server) handshake(c net.Conn) error {
+	c.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer c.SetDeadline(time.Time{})
+
+	var h handshake
+	// the client waits for the reply before calling, so the decoder buffers nothing else.
+	err := json.NewDecoder(c).Decode(&h)
+	if err != nil {
+		return err
+	}
+
+	var reply handshakeReply
+	switch {
+	case h.Version != protocolVersion:
+		reply.Error = fmt.Sprintf("protocol version %v is not supported", h.Version)
+	case s.token != "" && subtle.ConstantTimeCompare([]byte(h.Token), []byte(s.token)) != 1:
+		reply.Error = ErrUnauthorized.Error()
+	}
+
+	err = json.NewEncoder(c).Encode(&reply)
+	if err != nil {
+		return err
+	}
+	if reply.Error != "" {
+		return decodeError(reply.Error)
+	}
+
+	return nil
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+
+	err := s.handshake(c)
+	if err != nil {
+		log.Printf("%v: %v", c.RemoteAddr(), err)
+		return
+	}
+
+	// the streams belong to the connection, so each connection has its own service.
+	svc := &service{player: s.player, streams: map[string]TrackStream{}}
+	defer svc.close()
+
+	rs := rpc.NewServer()
+	err = rs.RegisterName("Itunes", svc)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	rs.ServeCodec(jsonrpc.NewServerCodec(c))
+}
+
+// service is the RPC service of a connection.
+type service struct {
+	player Player
+
+	mu      sync.Mutex
+	streams map[string]TrackStream
+	// the number of streams being opened, they count against maxStreams.
+	opening int
+}
+
+func (s *service) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, stream := range s.streams {
+		stream.Close()
+		delete(s.streams, id)
+	}
+}
+
+func (s *service) Status(_ *struct{}, reply *Status) error {
+	st, err := s.player.Status()
+	if err != nil {
+		return encodeError(err)
+	}
+
+	*reply = *st
+	return nil
+}
+
+func (s *service) Control(command *string, _ *struct{}) error {
+	return encodeError(s.player.Control(*command))
+}
+
+func (s *service) SetSoundVolume(volume *int, _ *struct{}) error {
+	return encodeError(s.player.SetSoundVolume(*volume))
+}
+
+func (s *service) Mute(_ *struct{}, reply *bool) error {
+	muted, err := s.player.Mute()
+	*reply = muted
+	return encodeError(err)
+}
+
+func (s *service) SetMute(muted *bool, _ *struct{}) error {
+	return encodeError(s.player.SetMute(*muted))
+}
+
+func (s *service) SetPosition(pos *time.Duration, _ *struct{}) error {
+	return encodeError(s.player.SetPosition(*pos))
+}
+
+func storeTrack(t *TrackInfo, err error, reply *TrackInfo) error {
+	if err != nil {
+		return encodeError(err)
+	}
+
+	*reply = *t
+	return nil
+}
+
+func (s *service) CurrentTrack(_ *struct{}, reply *TrackInfo) error {
+	t, err := s.player.CurrentTrack()
+	return storeTrack(t, err, reply)
+}
+
+func (s *service) TrackCount(_ *struct{}, reply *int) error {
+	count, err := s.player.TrackCount()
+	*reply = count
+	return encodeError(err)
+}
+
+func (s *service) GetTrack(index *int, reply *TrackInfo) error {
+	t, err := s.player.GetTrack(*index)
+	return storeTrack(t, err, reply)
+}
+
+func (s *service) FindTrack(persistentID *string, reply *TrackInfo) error {
+	t, err := s.player.FindTrack(*persistentID)
+	return storeTrack(t, err, reply)
+}
+
+func newStreamID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// OpenTracks opens a stream of tracks, the client reads it by NextTracks until it gets fewer than a batch.
+// The stream is closed at its end or with the connection.
+func (s *service) OpenTracks(req *TracksRequest, reply *string) error {
+	s.mu.Lock()
+	count := len(s.streams) + s.opening
+	if count >= maxStreams {
+		s.mu.Unlock()
+		return fmt.Errorf("too many streams: %v", count)
+	}
+	s.opening++
+	s.mu.Unlock()
+
+	stream, err := s.openTracks(req)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opening--
+	if err != nil {
+		return err
+	}
+
+	id, err := newStreamID()
+	if err != nil {
+		stream.Close()
+		return err
+	}
+
+	s.streams[id] = stream
+	*reply = id
+	return nil
+}
+
+// openTracks opens the stream of the player and skips the tracks read already.
+func (s *service) openTracks(req *TracksRequest) (TrackStream, error) {
+	stream, err := s.player.Tracks(req.PlaylistID)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+
+	for skip := req.Skip; skip > 0; skip -= batchSize {
+		n := skip
+		if n > batchSize {
+			n = batchSize
+		}
+
+		tracks, err := stream.Next(n)
+		if err != nil {
+			stream.Close()
+			return nil, encodeError(err)
+		}
+
+		// the tracks changed since the stream was lost, the client gets no more tracks.
+		if len(tracks) < n {
+			break
+		}
+	}
+
+	return stream, nil
+}
+
+func (s *service) NextTracks(id *string, reply *[]TrackInfo) error {
+	s.mu.Lock()
+	stream, ok := s.streams[*id]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: stream:%v", ErrNotFound, *id)
+	}
+
+	tracks, err := stream.Next(batchSize)
+	if err != nil {
+		return encodeError(err)
+	}
+
+	if len(tracks) < batchSize {
+		s.closeStream(*id)
+	}
+
+	*reply = tracks
+	return nil
+}
+
+func (s *service) closeStream(id string) {
+	s.mu.Lock()
+	stream, ok := s.streams[id]
+	delete(s.streams, id)
+	s.mu.Unlock()
+
+	if ok {
+		stream.Close()
+	}
+}
+
+func (s *service) PlayTrack(persistentID *string, _ *struct{}) error {
+	return encodeError(s.player.PlayTrack(*persistentID))
+}
+
+func (s *service) Artworks(persistentID *string, reply *[]ArtworkData) error {
+	artworks, err := s.player.Artworks(*persistentID)
+	*reply = artworks
+	return encodeError(err)
+}
+
+func storePlaylist(p *PlaylistInfo, err error, reply *PlaylistInfo) error {
+	if err != nil {
+		return encodeError(err)
+	}
+
+	*reply = *p
+	return nil
+}
+
+func (s *service) PlaylistCount(_ *struct{}, reply *int) error {
+	count, err := s.player.PlaylistCount()
+	*reply = count
+	return encodeError(err)
+}
+
+func (s *service) GetPlaylist(index *int, reply *PlaylistInfo) error {
+	p, err := s.player.GetPlaylist(*index)
+	return storePlaylist(p, err, reply)
+}
+
+func (s *service) FindPlaylist(persistentID *string, reply *PlaylistInfo) error {
+	p, err := s.player.FindPlaylist(*persistentID)
+	return storePlaylist(p, err, reply)
+}
+
+func (s *service) CurrentPlaylist(_ *struct{}, reply *PlaylistInfo) error {
+	p, err := s.player.CurrentPlaylist()
+	return storePlaylist(p, err, reply)
+}
+
+func (s *service) PlayPlaylist(persistentID *string, _ *struct{}) error {
+	return encodeError(s.player.PlayPlaylist(*persistentID))
+}
+
+func (s *service) UpdateTrack(u *TrackUpdate, _ *struct{}) error {
+	return encodeError(s.player.UpdateTrack(u))
+}
+
+func (s *service) CreatePlaylist(name *string, reply *PlaylistInfo) error {
+	p, err := s.player.CreatePlaylist(*name)
+	return storePlaylist(p, err, reply)
+}
+
+func (s *service) SetPlaylistName(req *PlaylistRequest, _ *struct{}) error {
+	return encodeError(s.player.SetPlaylistName(req.PlaylistID, req.Name))
+}
+
+func (s *service) AddPlaylistTrack(req *PlaylistRequest, _ *struct{}) error {
+	return encodeError(s.player.AddPlaylistTrack(req.PlaylistID, req.TrackID))
+}
+
+func (s *service) RemovePlaylistTrack(req *PlaylistRequest, _ *struct{}) error {
+	return encodeError(s.player.RemovePlaylistTrack(req.PlaylistID, req.TrackID))
+}
+
+func (s *service) DeletePlaylist(playlistID *string, _ *struct{}) error {
+	return encodeError(s.player.DeletePlaylist(*playlistID))
+}
@@ -0,0 +1,164 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package remote
+
+import "time"
+
+// Track is a track of the remote iTunes, with the methods of itunes.Track.
+// Its metadata is read when the track is got, RefreshInfo reads it again.
+type Track struct {
+	c    *Client
+	info TrackInfo
+}
+
+func newTrack(c *Client, info *TrackInfo) *Track {
+	return &Track{c: c, info: *info}
+}
+
+// for compatibility
+func (_ *Track) Close() {
+}
+
+func (t *Track) Name() string {
+	return t.info.Name
+}
+
+func (t *Track) Artist() string {
+	return t.info.Artist
+}
+
+func (t *Track) Album() string {
+	return t.info.Album
+}
+
+func (t *Track) Duration() time.Duration {
+	return t.info.Duration
+}
+
+// Location returns the file path of a file track, in the file system of the server, or the URL of a URL track.
+func (t *Track) Location() string {
+	return t.info.Location
+}
+
+func (t *Track) IsFileTrack() bool {
+	return t.info.IsFile
+}
+
+func (t *Track) BitRate() int {
+	return t.info.BitRate
+}
+
+func (t *Track) PlayedCount() int {
+	return t.info.PlayedCount
+}
+
+func (t *Track) DateAdded() time.Time {
+	return t.info.DateAdded
+}
+
+func (t *Track) Genre() string {
+	return t.info.Genre
+}
+
+func (t *Track) Year() int {
+	return t.info.Year
+}
+
+func (t *Track) Size() int64 {
+	return t.info.Size
+}
+
+// Rating returns the rating from 0 to 100, 20 per star.
+func (t *Track) Rating() int {
+	return t.info.Rating
+}
+
+func (t *Track) Comment() string {
+	return t.info.Comment
+}
+
+func (t *Track) PersistentID() string {
+	return t.info.PersistentID
+}
+
+func (t *Track) Loved() bool {
+	return t.info.Loved
+}
+
+func (t *Track) RefreshInfo() error {
+	var info TrackInfo
+	err := t.c.call("FindTrack", &t.info.PersistentID, &info)
+	if err != nil {
+		return err
+	}
+
+	t.info = info
+	return nil
+}
+
+func (t *Track) Play() error {
+	return t.c.call("PlayTrack", &t.info.PersistentID, &struct{}{})
+}
+
+// GetArtworks returns the artworks of the track, their images are transferred at once.
+func (t *Track) GetArtworks() (chan *Artwork, error) {
+	var artworks []ArtworkData
+	err := t.c.call("Artworks", &t.info.PersistentID, &artworks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan *Artwork, len(artworks))
+	for i := range artworks {
+		result <- newArtwork(&artworks[i])
+	}
+	close(result)
+
+	return result, nil
+}
+
+func (t *Track) update(u *TrackUpdate) error {
+	u.PersistentID = t.info.PersistentID
+	return t.c.call("UpdateTrack", u, &struct{}{})
+}
+
+func (t *Track) SetRating(rating int) error {
+	err := t.update(&TrackUpdate{Rating: &rating})
+	if err != nil {
+		return err
+	}
+
+	t.info.Rating = rating
+	return nil
+}
+
+func (t *Track) SetPlayedCount(count int) error {
+	err := t.update(&TrackUpdate{PlayedCount: &count})
+	if err != nil {
+		return err
+	}
+
+	t.info.PlayedCount = count
+	return nil
+}
+
+func (t *Track) SetComment(comment string) error {
+	err := t.update(&TrackUpdate{Comment: &comment})
+	if err != nil {
+		return err
+	}
+
+	t.info.Comment = comment
+	return nil
+}
+
+func (t *Track) SetLoved(loved bool) error {
+	err := t.update(&TrackUpdate{Loved: &loved})
+	if err != nil {
+		return err
+	}
+
+	t.info.Loved = loved
+	return nil
+}
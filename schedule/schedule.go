@@ -0,0 +1,414 @@
+// Package schedule runs sleep timers and alarms against iTunes.
+//
+// Pending jobs are saved to a JSON file so that they survive restarts.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type Kind string
+
+const (
+	// Pause pauses the player at Job.At.
+	Pause Kind = "pause"
+	// PauseAtEndOfTrack pauses the player when Job.TrackID finishes.
+	PauseAtEndOfTrack Kind = "pause-at-end-of-track"
+	// Alarm starts the playlist Job.PlaylistID at Job.At.
+	Alarm Kind = "alarm"
+)
+
+// jobs missed by more than this while the scheduler was not running are dropped,
+// a sleep timer or an alarm going off long after its time would surprise the user.
+const missedJobGrace = 5 * time.Minute
+
+const pollInterval = time.Second
+
+const fileVersion = 1
+
+type Job struct {
+	ID   string `json:"id"`
+	Kind Kind   `json:"kind"`
+
+	// At is when the job runs, for PauseAtEndOfTrack the end of the track expected when it was scheduled.
+	At         time.Time     `json:"at,omitempty"`
+	TrackID    string        `json:"trackId,omitempty"`
+	PlaylistID string        `json:"playlistId,omitempty"`
+	FadeOut    time.Duration `json:"fadeOut,omitempty"`
+}
+
+type file struct {
+	Version int    `json:"version"`
+	NextID  int    `json:"nextId"`
+	Jobs    []*Job `json:"jobs"`
+}
+
+// Handle cancels a scheduled job.
+type Handle struct {
+	s   *Scheduler
+	job Job
+}
+
+func (h *Handle) Job() Job {
+	return h.job
+}
+
+func (h *Handle) Cancel() error {
+	return h.s.Cancel(h.job.ID)
+}
+
+type Scheduler struct {
+	it   *itunes.Itunes
+	path string
+
+	mu      sync.Mutex
+	nextID  int
+	jobs    map[string]*Job
+	cancels map[string]chan struct{}
+	closed  bool
+}
+
+// New creates a scheduler that saves pending jobs to path.
+// Jobs already saved in path are scheduled again.
+func New(it *itunes.Itunes, path string) (*Scheduler, error) {
+	s := &Scheduler{
+		it:      it,
+		path:    path,
+		jobs:    map[string]*Job{},
+		cancels: map[string]chan struct{}{},
+	}
+
+	f, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID = f.NextID
+	now := time.Now()
+	for _, job := range f.Jobs {
+		if missed(job, now) {
+			log.Printf("drop missed %v job:%v", job.Kind, job.ID)
+			continue
+		}
+		s.start(job)
+	}
+
+	err = s.save()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// missed reports whether the job should have run more than missedJobGrace before now.
+func missed(job *Job, now time.Time) bool {
+	return now.Sub(job.At) > missedJobGrace
+}
+
+func load(path string) (*file, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &file{Version: fileVersion}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Version != fileVersion {
+		return nil, errors.New(fmt.Sprintf("unsupported schedule file version:%v", f.Version))
+	}
+
+	return &f, nil
+}
+
+// save must be called with s.mu held.
+func (s *Scheduler) save() error {
+	f := file{
+		Version: fileVersion,
+		NextID:  s.nextID,
+		Jobs:    make([]*Job, 0, len(s.jobs)),
+	}
+	for _, job := range s.jobs {
+		f.Jobs = append(f.Jobs, job)
+	}
+
+	data, err := json.MarshalIndent(&f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Scheduler) add(job *Job) (*Handle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, errors.New("scheduler is closed.")
+	}
+
+	s.nextID++
+	job.ID = fmt.Sprintf("%d", s.nextID)
+	s.start(job)
+
+	err := s.save()
+	if err != nil {
+		s.stop(job.ID)
+		return nil, err
+	}
+
+	return &Handle{s: s, job: *job}, nil
+}
+
+// start must be called with s.mu held.
+func (s *Scheduler) start(job *Job) {
+	cancel := make(chan struct{})
+	s.jobs[job.ID] = job
+	s.cancels[job.ID] = cancel
+
+	go func() {
+		err := s.run(job, cancel)
+		if err != nil {
+			log.Printf("job %v failed:%v", job.ID, err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+
+		s.stop(job.ID)
+		err = s.save()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+}
+
+// stop must be called with s.mu held.
+func (s *Scheduler) stop(id string) bool {
+	cancel, ok := s.cancels[id]
+	if !ok {
+		return false
+	}
+
+	close(cancel)
+	delete(s.cancels, id)
+	delete(s.jobs, id)
+	return true
+}
+
+// PauseAfter pauses the player after d, fading the volume out during the last fadeOut.
+func (s *Scheduler) PauseAfter(d, fadeOut time.Duration) (*Handle, error) {
+	return s.add(&Job{
+		Kind:    Pause,
+		At:      time.Now().Add(d),
+		FadeOut: fadeOut,
+	})
+}
+
+// PauseAtEndOfTrack pauses the player when the current track finishes,
+// fading the volume out during the last fadeOut of the track.
+func (s *Scheduler) PauseAtEndOfTrack(fadeOut time.Duration) (*Handle, error) {
+	t, err := s.it.CurrentTrack()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	pos, err := s.it.PlayerPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.add(&Job{
+		Kind:    PauseAtEndOfTrack,
+		At:      time.Now().Add(t.Duration() - time.Duration(pos)*time.Second),
+		TrackID: t.PersistentID(),
+		FadeOut: fadeOut,
+	})
+}
+
+// AlarmAt starts the playlist at the given time.
+func (s *Scheduler) AlarmAt(at time.Time, playlistPersistentID string) (*Handle, error) {
+	return s.add(&Job{
+		Kind:       Alarm,
+		At:         at,
+		PlaylistID: playlistPersistentID,
+	})
+}
+
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+
+	return jobs
+}
+
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.stop(id) {
+		return errors.New(fmt.Sprintf("not found job:%v", id))
+	}
+
+	return s.save()
+}
+
+// Close stops all jobs without removing them from the file.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for id, cancel := range s.cancels {
+		close(cancel)
+		delete(s.cancels, id)
+	}
+}
+
+func wait(d time.Duration, cancel chan struct{}) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-cancel:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Scheduler) run(job *Job, cancel chan struct{}) error {
+	switch job.Kind {
+	case Pause:
+		if !wait(time.Until(job.At.Add(-job.FadeOut)), cancel) {
+			return nil
+		}
+		return s.pause(job.FadeOut, cancel)
+	case PauseAtEndOfTrack:
+		return s.pauseAtEndOfTrack(job, cancel)
+	case Alarm:
+		if !wait(time.Until(job.At), cancel) {
+			return nil
+		}
+		p, err := s.it.FindPlaylistByPersistentID(job.PlaylistID)
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+		return p.PlayFirstTrack()
+	}
+
+	return errors.New(fmt.Sprintf("unknown job kind:%v", job.Kind))
+}
+
+func (s *Scheduler) pauseAtEndOfTrack(job *Job, cancel chan struct{}) error {
+	for {
+		t, err := s.it.CurrentTrack()
+		if err != nil {
+			return err
+		}
+		id, duration := t.PersistentID(), t.Duration()
+		t.Close()
+
+		if id != job.TrackID {
+			// the track has already finished.
+			return s.pause(0, cancel)
+		}
+
+		pos, err := s.it.PlayerPosition()
+		if err != nil {
+			return err
+		}
+
+		remaining := duration - time.Duration(pos)*time.Second - job.FadeOut
+		if remaining <= pollInterval {
+			if !wait(remaining, cancel) {
+				return nil
+			}
+			return s.pause(job.FadeOut, cancel)
+		}
+
+		if !wait(pollInterval, cancel) {
+			return nil
+		}
+	}
+}
+
+// pause fades the volume out over fadeOut, pauses and then restores the volume.
+func (s *Scheduler) pause(fadeOut time.Duration, cancel chan struct{}) error {
+	volume, err := s.it.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	if fadeOut > 0 {
+		const steps = 20
+		for i := 1; i <= steps; i++ {
+			if !wait(fadeOut/steps, cancel) {
+				return s.it.SetSoundVolume(volume)
+			}
+
+			err = s.it.SetSoundVolume(volume * (steps - i) / steps)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = s.it.Pause()
+	if err != nil {
+		return err
+	}
+
+	return s.it.SetSoundVolume(volume)
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func sortedJobs(s *Scheduler) []Job {
+	jobs := s.Jobs()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+
+	s, err := New(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Now().Add(time.Hour).Round(time.Second)
+	_, err = s.PauseAfter(2*time.Hour, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alarm, err := s.AlarmAt(at, "P1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := s.AlarmAt(at, "P2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = New(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	jobs := sortedJobs(s)
+	if len(jobs) != 2 {
+		t.Fatalf("unexpected jobs: %+v", jobs)
+	}
+	if jobs[0].ID != "1" || jobs[0].Kind != Pause || jobs[0].FadeOut != 30*time.Second {
+		t.Errorf("unexpected pause job: %+v", jobs[0])
+	}
+	if a := jobs[1]; a.ID != alarm.Job().ID || a.Kind != Alarm || a.PlaylistID != "P1" || !a.At.Equal(at) {
+		t.Errorf("expect %+v, but %+v", alarm.Job(), jobs[1])
+	}
+
+	// ids are not reused after a restart.
+	h, err = s.AlarmAt(at, "P3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Job().ID != "4" {
+		t.Errorf("unexpected id: %v", h.Job().ID)
+	}
+}
+
+func TestMissedJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	now := time.Now()
+	f := file{
+		Version: fileVersion,
+		NextID:  4,
+		Jobs: []*Job{
+			{ID: "1", Kind: Pause, At: now.Add(-time.Hour)},
+			{ID: "2", Kind: PauseAtEndOfTrack, At: now.Add(-time.Hour), TrackID: "T1"},
+			{ID: "3", Kind: Alarm, At: now.Add(-time.Hour), PlaylistID: "P1"},
+			{ID: "4", Kind: Alarm, At: now.Add(time.Hour), PlaylistID: "P1"},
+		},
+	}
+	data, err := json.Marshal(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	jobs := sortedJobs(s)
+	if len(jobs) != 1 || jobs[0].ID != "4" {
+		t.Errorf("unexpected jobs: %+v", jobs)
+	}
+
+	// the dropped jobs are removed from the file as well.
+	saved, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Jobs) != 1 || saved.Jobs[0].ID != "4" || saved.NextID != 4 {
+		t.Errorf("unexpected file: %+v", saved)
+	}
+}
+
+func TestMissed(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		at     time.Time
+		missed bool
+	}{
+		{now.Add(time.Minute), false},
+		{now.Add(-time.Minute), false},
+		{now.Add(-missedJobGrace - time.Second), true},
+		{time.Time{}, true},
+	}
+	for _, tt := range tests {
+		for _, kind := range []Kind{Pause, PauseAtEndOfTrack, Alarm} {
+			if m := missed(&Job{Kind: kind, At: tt.at}, now); m != tt.missed {
+				t.Errorf("expect missed %v of %v at %v, but %v", tt.missed, kind, tt.at, m)
+			}
+		}
+	}
+}
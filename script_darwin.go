@@ -2,6 +2,7 @@ package itunes
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,22 +17,168 @@ function p(/*...args*/) {
 	console.log("!"+Array.prototype.slice.call(arguments).map(encodeURIComponent).join(","));
 }
 
+// the columns of logTrack and logTracks must be in the order createTrack reads them.
 function logTrack(track) {
 	if (track != null) {
 		p(
 			track.persistentID(),
 			track.album(),
 			track.artist(),
-			track.name()
+			track.name(),
+			track.duration(),
+			trackLocation(track),
+			isFileTrack(track.class()),
+			track.bitRate(),
+			track.playedCount(),
+			isoDate(track.dateAdded()),
+			track.genre(),
+			track.year(),
+			track.size(),
+			track.rating(),
+			track.comment(),
+			track.loved()
 		);
 	}
 }
 
+// logTracks gets each property of all tracks in the playlist at once,
+// which is much faster than calling logTrack for each track.
+// from and to optionally limit the tracks logged to a range of indices.
+function logTracks(playlist, from, to) {
+	var tracks = playlist.tracks;
+	var ids = tracks.persistentID();
+	from = from === undefined ? 0 : from;
+	to = to === undefined ? ids.length : Math.min(to, ids.length);
+	if (from >= to) {
+		return;
+	}
+
+	var locations = {};
+	try {
+		var fileIds = playlist.fileTracks.persistentID();
+		var fileLocations = playlist.fileTracks.location();
+		for (var i = 0; i < fileIds.length; i++) {
+			locations[fileIds[i]] = fileLocations[i] == null ? "" : fileLocations[i].toString();
+		}
+	} catch (e) {
+	}
+	try {
+		var urlIds = playlist.urlTracks.persistentID();
+		var addresses = playlist.urlTracks.address();
+		for (var i = 0; i < urlIds.length; i++) {
+			locations[urlIds[i]] = addresses[i];
+		}
+	} catch (e) {
+	}
+
+	var albums = tracks.album();
+	var artists = tracks.artist();
+	var names = tracks.name();
+	var durations = tracks.duration();
+	var classes = tracks.class();
+	var bitRates = tracks.bitRate();
+	var playedCounts = tracks.playedCount();
+	var datesAdded = tracks.dateAdded();
+	var genres = tracks.genre();
+	var years = tracks.year();
+	var sizes = tracks.size();
+	var ratings = tracks.rating();
+	var comments = tracks.comment();
+	var loved = tracks.loved();
+	for (var i = from; i < to; i++) {
+		p(
+			ids[i],
+			albums[i],
+			artists[i],
+			names[i],
+			durations[i],
+			locations[ids[i]] || "",
+			isFileTrack(classes[i]),
+			bitRates[i],
+			playedCounts[i],
+			isoDate(datesAdded[i]),
+			genres[i],
+			years[i],
+			sizes[i],
+			ratings[i],
+			comments[i],
+			loved[i]
+		);
+	}
+}
+
+// audio CD tracks have a file location too, they are file tracks.
+function isFileTrack(c) {
+	return c == "fileTrack" || c == "audioCDTrack";
+}
+
+function isoDate(d) {
+	return d == null ? "" : d.toISOString();
+}
+
+// location is a path for file tracks and audio CD tracks, and address is a URL for URL tracks.
+// the other tracks have neither of them.
+function trackLocation(track) {
+	try {
+		var c = track.class();
+		if (isFileTrack(c)) {
+			var l = track.location();
+			return l == null ? "" : l.toString();
+		}
+		if (c == "urlTrack") {
+			return track.address();
+		}
+	} catch (e) {
+	}
+	return "";
+}
+
 function logPlaylist(playlist) {
 	if (playlist != null) {
 		p(
 			playlist.persistentID(),
-			playlist.name()
+			playlist.name(),
+			isUserPlaylist(playlist)
+		);
+	}
+}
+
+function isUserPlaylist(playlist) {
+	try {
+		return playlist.class() == "userPlaylist" && !playlist.smart() && playlist.specialKind() == "none";
+	} catch (e) {
+		return false;
+	}
+}
+
+function logEQPreset(preset) {
+	if (preset != null) {
+		p(
+			preset.name(),
+			preset.modifiable()
+		);
+	}
+}
+
+function logAirPlayDevice(device) {
+	if (device != null) {
+		p(
+			device.persistentID(),
+			device.name(),
+			device.kind(),
+			device.active(),
+			device.selected(),
+			device.soundVolume()
+		);
+	}
+}
+
+function logSource(source) {
+	if (source != null) {
+		p(
+			source.persistentID(),
+			source.name(),
+			source.kind()
 		);
 	}
 }
@@ -51,6 +198,43 @@ function findTrackByPersistentId(persistentId) {
 
 function findPlaylistByPersistentId(persistentId) {
 	var index = app.playlists.persistentID().indexOf(persistentId);
+	if (index >= 0) {
+		return app.playlists[index];
+	}
+
+	// playlists of devices and shared libraries are not in app.playlists.
+	var sources = app.sources;
+	for (var i = 0; i < sources.length; i++) {
+		var playlists = sources[i].playlists;
+		index = playlists.persistentID().indexOf(persistentId);
+		if (index >= 0) {
+			return playlists[index];
+		}
+	}
+
+	return null;
+}
+
+function findSourceByPersistentId(persistentId) {
+	var index = app.sources.persistentID().indexOf(persistentId);
+	if (index < 0) {
+		return null;
+	}
+
+	return app.sources[index];
+}
+
+function findAirPlayDeviceByPersistentId(persistentId) {
+	var index = app.airPlayDevices.persistentID().indexOf(persistentId);
+	if (index < 0) {
+		return null;
+	}
+
+	return app.airPlayDevices[index];
+}
+
+function findPlaylistByName(name) {
+	var index = app.playlists.name().indexOf(name);
 	if (index < 0) {
 		return null;
 	}
@@ -58,6 +242,21 @@ function findPlaylistByPersistentId(persistentId) {
 	return app.playlists[index];
 }
 
+// getVisiblePlaylist skips the playlist named hidden, like the playlist of the queue.
+function getVisiblePlaylist(index, hidden) {
+	var names = app.playlists.name();
+	var hiddenIndex = names.indexOf(hidden);
+	if (hiddenIndex >= 0 && index >= hiddenIndex) {
+		index++;
+	}
+
+	if (index < 0 || index >= names.length) {
+		return null;
+	}
+
+	return app.playlists[index];
+}
+
 function createPlaylist(name) {
 	return app.Playlist({name: name}).make();
 }
@@ -137,7 +336,7 @@ end tell
 
 const getArtworksScript = `
 tell application "iTunes"
-    set t to FindTrackByName("%v") of me
+    set t to FindTrackByPersistentID("%v") of me
     if t is not null then
         repeat with a in artworks of t
             set f to format of a
@@ -243,3 +442,9 @@ func getColumnsByAS(script string) ([]string, error) {
 func getColumnsByJS(script string) ([]string, error) {
 	return getColumns(execJS, script)
 }
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
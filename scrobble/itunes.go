@@ -0,0 +1,57 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package scrobble
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesPlayer struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesPlayer returns the Player of an iTunes application.
+func NewItunesPlayer(it *itunes.Itunes) Player {
+	return &itunesPlayer{it: it}
+}
+
+func (p *itunesPlayer) Status() (*Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{Playing: state == itunes.Playing}
+	t, err := p.it.CurrentTrack()
+	if errors.Is(err, itunes.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	st.Track = &Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Duration:     t.Duration(),
+	}
+
+	st.Position, err = p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
@@ -0,0 +1,159 @@
+package scrobble
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LastFMURL is the endpoint of the Last.fm API, Libre.fm and other services offer compatible ones.
+const LastFMURL = "https://ws.audioscrobbler.com/2.0/"
+
+// LastFMError is an error returned by the API.
+type LastFMError struct {
+	Code    int
+	Message string
+}
+
+func (e *LastFMError) Error() string {
+	return fmt.Sprintf("last.fm error %v: %v", e.Code, e.Message)
+}
+
+// Is reports invalid parameters as ErrRejected, submitting the same scrobbles again fails the same way.
+func (e *LastFMError) Is(target error) bool {
+	return target == ErrRejected && (e.Code == 6 || e.Code == 7)
+}
+
+// LastFM submits scrobbles to a Last.fm compatible API.
+type LastFM struct {
+	URL        string
+	APIKey     string
+	Secret     string
+	SessionKey string
+	Client     *http.Client
+}
+
+// NewLastFM returns the submitter of the user of the session key, see MobileSession to get one.
+func NewLastFM(apiKey, secret, sessionKey string) *LastFM {
+	return &LastFM{
+		URL:        LastFMURL,
+		APIKey:     apiKey,
+		Secret:     secret,
+		SessionKey: sessionKey,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// sign computes api_sig, the md5 of the parameters sorted by name and concatenated, followed by the secret.
+func sign(params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "format" && k != "callback" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(params.Get(k))
+	}
+	b.WriteString(secret)
+
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// call posts a signed method call and decodes the result.
+func (l *LastFM) call(params url.Values, result interface{}) error {
+	params.Set("api_key", l.APIKey)
+	params.Set("api_sig", sign(params, l.Secret))
+	params.Set("format", "json")
+
+	res, err := l.Client.PostForm(l.URL, params)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	var e struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	err = json.Unmarshal(data, &e)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unexpected response %v: %.100q", res.Status, data))
+	}
+	if e.Error != 0 {
+		return &LastFMError{Code: e.Error, Message: e.Message}
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("unexpected response %v", res.Status))
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// MobileSession gets a session key from the name and the password of the user.
+// The key does not expire, keep it instead of the password.
+func (l *LastFM) MobileSession(username, password string) (string, error) {
+	params := url.Values{
+		"method":   {"auth.getMobileSession"},
+		"username": {username},
+		"password": {password},
+	}
+
+	var result struct {
+		Session struct {
+			Key string `json:"key"`
+		} `json:"session"`
+	}
+	err := l.call(params, &result)
+	if err != nil {
+		return "", err
+	}
+
+	if result.Session.Key == "" {
+		return "", errors.New("session key is empty.")
+	}
+
+	return result.Session.Key, nil
+}
+
+func (l *LastFM) Submit(scrobbles []Scrobble) error {
+	params := url.Values{
+		"method": {"track.scrobble"},
+		"sk":     {l.SessionKey},
+	}
+	for i, s := range scrobbles {
+		index := "[" + strconv.Itoa(i) + "]"
+		params.Set("artist"+index, s.Artist)
+		params.Set("track"+index, s.Track)
+		params.Set("timestamp"+index, strconv.FormatInt(s.Timestamp.Unix(), 10))
+		if s.Album != "" {
+			params.Set("album"+index, s.Album)
+		}
+		if s.Duration > 0 {
+			params.Set("duration"+index, strconv.Itoa(int(s.Duration.Seconds())))
+		}
+	}
+
+	// ignored scrobbles, such as too old ones, are accepted by the call and never succeed later.
+	var result struct{}
+	return l.call(params, &result)
+}
@@ -0,0 +1,162 @@
+package scrobble
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// lastFMServer stands in for the API, checking the signature of each call.
+type lastFMServer struct {
+	t        *testing.T
+	requests []url.Values
+	response string
+}
+
+func (s *lastFMServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		s.t.Error(err)
+		return
+	}
+
+	params := r.PostForm
+	s.requests = append(s.requests, params)
+
+	if params.Get("api_key") != "key" || params.Get("format") != "json" {
+		s.t.Errorf("unexpected params: %v", params)
+	}
+
+	signed := url.Values{}
+	for k, v := range params {
+		if k != "api_sig" {
+			signed[k] = v
+		}
+	}
+	if params.Get("api_sig") != sign(signed, "secret") {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":13,"message":"Invalid method signature supplied"}`)
+		return
+	}
+
+	fmt.Fprint(w, s.response)
+}
+
+func newLastFMServer(t *testing.T) (*lastFMServer, *LastFM) {
+	s := &lastFMServer{t: t}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	l := NewLastFM("key", "secret", "session")
+	l.URL = ts.URL
+	return s, l
+}
+
+func TestSign(t *testing.T) {
+	params := url.Values{
+		"method":  {"auth.getMobileSession"},
+		"api_key": {"key"},
+		"format":  {"json"},
+	}
+
+	// md5("api_keykeymethodauth.getMobileSessionsecret")
+	expected := "018322def6bdaf0b7eba8f03ac376100"
+	sig := sign(params, "secret")
+	if sig != expected {
+		t.Errorf("expect %v but %v", expected, sig)
+	}
+}
+
+func TestLastFMSubmit(t *testing.T) {
+	s, l := newLastFMServer(t)
+	s.response = `{"scrobbles":{"@attr":{"accepted":2,"ignored":0}}}`
+
+	err := l.Submit([]Scrobble{
+		{Artist: "artist0", Track: "track0", Album: "album0", Duration: 3 * time.Minute, Timestamp: time.Unix(1500000000, 0)},
+		{Artist: "artist1", Track: "track1", Timestamp: time.Unix(1500000180, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.requests) != 1 {
+		t.Fatalf("expect 1 request but %v", len(s.requests))
+	}
+	params := s.requests[0]
+	expected := map[string]string{
+		"method":       "track.scrobble",
+		"sk":           "session",
+		"artist[0]":    "artist0",
+		"track[0]":     "track0",
+		"album[0]":     "album0",
+		"duration[0]":  "180",
+		"timestamp[0]": "1500000000",
+		"artist[1]":    "artist1",
+		"track[1]":     "track1",
+		"timestamp[1]": "1500000180",
+	}
+	for k, v := range expected {
+		if params.Get(k) != v {
+			t.Errorf("%v: expect %v but %v", k, v, params.Get(k))
+		}
+	}
+	if _, ok := params["album[1]"]; ok {
+		t.Errorf("unexpected album: %v", params["album[1]"])
+	}
+	if _, ok := params["duration[1]"]; ok {
+		t.Errorf("unexpected duration: %v", params["duration[1]"])
+	}
+}
+
+func TestLastFMError(t *testing.T) {
+	s, l := newLastFMServer(t)
+
+	s.response = `{"error":6,"message":"Invalid parameters"}`
+	err := l.Submit([]Scrobble{{Artist: "artist", Track: "track", Timestamp: time.Unix(0, 0)}})
+	var e *LastFMError
+	if !errors.As(err, &e) || e.Code != 6 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, ErrRejected) {
+		t.Errorf("invalid parameters should be rejected: %v", err)
+	}
+
+	// a wrong secret or an unavailable service may be fixed later.
+	l.Secret = "wrong"
+	err = l.Submit([]Scrobble{{Artist: "artist", Track: "track", Timestamp: time.Unix(0, 0)}})
+	if !errors.As(err, &e) || e.Code != 13 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errors.Is(err, ErrRejected) {
+		t.Errorf("invalid signature should not be rejected: %v", err)
+	}
+
+	l.Secret = "secret"
+	s.response = `<html>Service Unavailable</html>`
+	err = l.Submit([]Scrobble{{Artist: "artist", Track: "track", Timestamp: time.Unix(0, 0)}})
+	if err == nil || errors.Is(err, ErrRejected) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMobileSession(t *testing.T) {
+	s, l := newLastFMServer(t)
+	s.response = `{"session":{"name":"user","key":"sessionkey","subscriber":0}}`
+
+	key, err := l.MobileSession("user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "sessionkey" {
+		t.Errorf("expect sessionkey but %v", key)
+	}
+
+	params := s.requests[0]
+	if params.Get("method") != "auth.getMobileSession" || params.Get("username") != "user" || params.Get("password") != "password" {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
@@ -0,0 +1,135 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const queueVersion = 1
+
+type queueFile struct {
+	Version   int        `json:"version"`
+	Scrobbles []Scrobble `json:"scrobbles"`
+}
+
+// Queue keeps the scrobbles not submitted yet in a file, oldest first.
+type Queue struct {
+	path string
+
+	mu        sync.Mutex
+	scrobbles []Scrobble
+}
+
+// OpenQueue loads the queue saved in path. The queue is empty if path does not exist.
+func OpenQueue(path string) (*Queue, error) {
+	q := &Queue{path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f queueFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Version != queueVersion {
+		return nil, errors.New(fmt.Sprintf("unsupported scrobble queue version:%v", f.Version))
+	}
+
+	q.scrobbles = f.Scrobbles
+	return q, nil
+}
+
+func (q *Queue) save() error {
+	f := queueFile{
+		Version:   queueVersion,
+		Scrobbles: q.scrobbles,
+	}
+
+	data, err := json.MarshalIndent(&f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(q.path), filepath.Base(q.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), q.path)
+}
+
+// Add appends the scrobble and saves the queue.
+func (q *Queue) Add(s Scrobble) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.scrobbles = append(q.scrobbles, s)
+	err := q.save()
+	if err != nil {
+		q.scrobbles = q.scrobbles[:len(q.scrobbles)-1]
+		return err
+	}
+
+	return nil
+}
+
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.scrobbles)
+}
+
+// Peek returns up to n of the oldest scrobbles.
+func (q *Queue) Peek(n int) []Scrobble {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n > len(q.scrobbles) {
+		n = len(q.scrobbles)
+	}
+
+	return append([]Scrobble(nil), q.scrobbles[:n]...)
+}
+
+// Remove drops the n oldest scrobbles, once they are submitted, and saves the queue.
+func (q *Queue) Remove(n int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n > len(q.scrobbles) {
+		n = len(q.scrobbles)
+	}
+
+	all := q.scrobbles
+	q.scrobbles = q.scrobbles[n:]
+	err := q.save()
+	if err != nil {
+		q.scrobbles = all
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package scrobble
+
+import (
+	"errors"
+
+	"github.com/yaegaki/itunes-app-interface"
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+type remotePlayer struct {
+	c *remote.Client
+}
+
+// NewRemotePlayer returns the Player of iTunes on another machine, served by the remote package.
+func NewRemotePlayer(c *remote.Client) Player {
+	return &remotePlayer{c: c}
+}
+
+func (p *remotePlayer) Status() (*Status, error) {
+	state, err := p.c.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{Playing: state == itunes.Playing}
+	t, err := p.c.CurrentTrack()
+	if errors.Is(err, remote.ErrNoCurrentTrack) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st.Track = &Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Duration:     t.Duration(),
+	}
+
+	st.Position, err = p.c.Position()
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
@@ -0,0 +1,252 @@
+// Package scrobble records the plays of the player to a service such as Last.fm.
+//
+// A Scrobbler polls the player and follows the rules of Last.fm: a track longer than 30 seconds
+// is scrobbled once it has played for half its duration or for 4 minutes, whichever comes first.
+// Only the time spent playing counts, seeking forward or pausing does not.
+// Scrobbles wait in a queue file until the Submitter accepts them, so the plays while offline
+// are submitted when the service is reachable again, even after a restart.
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrRejected is wrapped by the Submitter errors for scrobbles the service will never accept,
+// they are dropped from the queue instead of being submitted again.
+var ErrRejected = errors.New("rejected")
+
+type Track struct {
+	PersistentID string
+	Name         string
+	Artist       string
+	Album        string
+	Duration     time.Duration
+}
+
+type Status struct {
+	Playing  bool
+	Position time.Duration
+	// Track is nil if the player has no track.
+	Track *Track
+}
+
+// Player is what the scrobbler watches, NewItunesPlayer implements it for iTunes.
+type Player interface {
+	Status() (*Status, error)
+}
+
+type Scrobble struct {
+	Artist   string        `json:"artist"`
+	Track    string        `json:"track"`
+	Album    string        `json:"album,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Timestamp is when the track started playing.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Submitter sends scrobbles to a service, LastFM implements it for Last.fm compatible APIs.
+type Submitter interface {
+	// Submit sends up to BatchSize scrobbles. On error they stay in the queue and are submitted again later,
+	// unless the error wraps ErrRejected.
+	Submit(scrobbles []Scrobble) error
+}
+
+const (
+	// BatchSize is the number of scrobbles a submission takes at most, the limit of Last.fm.
+	BatchSize = 50
+
+	// tracks up to this duration are never scrobbled.
+	minDuration = 30 * time.Second
+	// a track longer than 8 minutes is scrobbled after 4 minutes.
+	maxRequired = 4 * time.Minute
+
+	defaultInterval = 5 * time.Second
+	minBackoff      = time.Minute
+	maxBackoff      = 30 * time.Minute
+)
+
+// Required returns how long a track has to play to be scrobbled, or 0 if it is never scrobbled.
+func Required(duration time.Duration) time.Duration {
+	if duration <= minDuration {
+		return 0
+	}
+
+	if duration/2 > maxRequired {
+		return maxRequired
+	}
+
+	return duration / 2
+}
+
+// play is a play of a track observed by the scrobbler.
+type play struct {
+	track    Track
+	started  time.Time
+	played   time.Duration
+	position time.Duration
+	playing  bool
+	// scrobbled is true once the play is queued, a play is scrobbled at most once.
+	scrobbled bool
+}
+
+type Scrobbler struct {
+	player    Player
+	queue     *Queue
+	submitter Submitter
+	interval  time.Duration
+	now       func() time.Time
+
+	play       *play
+	last       time.Time
+	backoff    time.Duration
+	nextSubmit time.Time
+}
+
+// New returns the scrobbler of the player, queueing the scrobbles in queue until submitter accepts them.
+func New(player Player, queue *Queue, submitter Submitter) *Scrobbler {
+	return &Scrobbler{
+		player:    player,
+		queue:     queue,
+		submitter: submitter,
+		interval:  defaultInterval,
+		now:       time.Now,
+	}
+}
+
+// Run polls the player until ctx is done. The errors of the player and of the submitter are logged,
+// the player may be quit and the service unreachable for a while.
+func (s *Scrobbler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.poll()
+		if err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scrobbler) poll() error {
+	now := s.now()
+	st, err := s.player.Status()
+	if err != nil {
+		// the time until the player answers again is unknown, so it does not count.
+		s.last = time.Time{}
+		return err
+	}
+
+	err = s.observe(st, now)
+	if err != nil {
+		return err
+	}
+
+	if s.queue.Len() == 0 || now.Before(s.nextSubmit) {
+		return nil
+	}
+
+	err = s.Flush()
+	if err != nil {
+		s.backoff *= 2
+		if s.backoff < minBackoff {
+			s.backoff = minBackoff
+		}
+		if s.backoff > maxBackoff {
+			s.backoff = maxBackoff
+		}
+		s.nextSubmit = now.Add(s.backoff)
+		return err
+	}
+
+	s.backoff = 0
+	return nil
+}
+
+// observe accounts the time played since the last status and queues the play when it is long enough.
+func (s *Scrobbler) observe(st *Status, now time.Time) error {
+	elapsed := time.Duration(0)
+	if !s.last.IsZero() {
+		elapsed = now.Sub(s.last)
+	}
+	s.last = now
+
+	if st.Track == nil {
+		s.play = nil
+		return nil
+	}
+
+	p := s.play
+	// a scrobbled track starting over near its beginning is played again, such as on repeat.
+	restarted := p != nil && p.scrobbled && st.Position < p.position && st.Position < minDuration
+	if p == nil || p.track.PersistentID != st.Track.PersistentID || restarted {
+		s.play = &play{
+			track:    *st.Track,
+			started:  now.Add(-st.Position),
+			position: st.Position,
+			playing:  st.Playing,
+		}
+		return nil
+	}
+
+	// the position caps the time, so that a seek forward or a suspended machine does not count.
+	if p.playing && st.Playing {
+		advanced := st.Position - p.position
+		if advanced > elapsed {
+			advanced = elapsed
+		}
+		if advanced > 0 {
+			p.played += advanced
+		}
+	}
+	p.position = st.Position
+	p.playing = st.Playing
+
+	required := Required(p.track.Duration)
+	if p.scrobbled || required == 0 || p.played < required {
+		return nil
+	}
+
+	// services require the artist and the title.
+	p.scrobbled = true
+	if p.track.Artist == "" || p.track.Name == "" {
+		return nil
+	}
+
+	return s.queue.Add(Scrobble{
+		Artist:    p.track.Artist,
+		Track:     p.track.Name,
+		Album:     p.track.Album,
+		Duration:  p.track.Duration,
+		Timestamp: p.started,
+	})
+}
+
+// Flush submits the queued scrobbles now, in batches of BatchSize.
+// Run flushes by itself, call it only while Run is not running, such as before exiting.
+func (s *Scrobbler) Flush() error {
+	for s.queue.Len() > 0 {
+		batch := s.queue.Peek(BatchSize)
+		err := s.submitter.Submit(batch)
+		if errors.Is(err, ErrRejected) {
+			log.Printf("dropped %v scrobbles: %v", len(batch), err)
+		} else if err != nil {
+			return err
+		}
+
+		err = s.queue.Remove(len(batch))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,333 @@
+package scrobble
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakePlayer struct {
+	status *Status
+	err    error
+}
+
+func (p *fakePlayer) Status() (*Status, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	st := *p.status
+	return &st, nil
+}
+
+type fakeSubmitter struct {
+	err       error
+	calls     int
+	submitted []Scrobble
+}
+
+func (s *fakeSubmitter) Submit(scrobbles []Scrobble) error {
+	s.calls++
+	if s.err != nil {
+		return s.err
+	}
+	s.submitted = append(s.submitted, scrobbles...)
+	return nil
+}
+
+type fixture struct {
+	t         *testing.T
+	player    *fakePlayer
+	submitter *fakeSubmitter
+	queue     *Queue
+	scrobbler *Scrobbler
+	clock     time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	queue, err := OpenQueue(filepath.Join(t.TempDir(), "queue.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		t:         t,
+		player:    &fakePlayer{status: &Status{}},
+		submitter: &fakeSubmitter{},
+		queue:     queue,
+		clock:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	f.scrobbler = New(f.player, queue, f.submitter)
+	f.scrobbler.now = func() time.Time { return f.clock }
+	return f
+}
+
+// step advances the clock by d, moving the position by d while playing, and polls.
+func (f *fixture) step(d time.Duration) error {
+	f.clock = f.clock.Add(d)
+	if f.player.status.Playing {
+		f.player.status.Position += d
+	}
+	return f.scrobbler.poll()
+}
+
+// run steps every 5 seconds for d.
+func (f *fixture) run(d time.Duration) {
+	for ; d > 0; d -= 5 * time.Second {
+		err := f.step(5 * time.Second)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+	}
+}
+
+func (f *fixture) start(t *Track) {
+	f.player.status = &Status{Playing: true, Track: t}
+	err := f.scrobbler.poll()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func newTrack(id string, duration time.Duration) *Track {
+	return &Track{
+		PersistentID: id,
+		Name:         "name" + id,
+		Artist:       "artist" + id,
+		Album:        "album" + id,
+		Duration:     duration,
+	}
+}
+
+func TestRequired(t *testing.T) {
+	cases := []struct {
+		duration time.Duration
+		required time.Duration
+	}{
+		{0, 0},
+		{30 * time.Second, 0},
+		{31 * time.Second, 15500 * time.Millisecond},
+		{3 * time.Minute, 90 * time.Second},
+		{8 * time.Minute, 4 * time.Minute},
+		{time.Hour, 4 * time.Minute},
+	}
+
+	for _, c := range cases {
+		r := Required(c.duration)
+		if r != c.required {
+			t.Errorf("Required(%v): expect %v but %v", c.duration, c.required, r)
+		}
+	}
+}
+
+func TestScrobble(t *testing.T) {
+	f := newFixture(t)
+	started := f.clock
+
+	f.start(newTrack("1", 3*time.Minute))
+	f.run(85 * time.Second)
+	if f.submitter.calls != 0 {
+		t.Fatalf("scrobbled before half the track: %v", f.submitter.submitted)
+	}
+
+	f.run(5 * time.Second)
+	if len(f.submitter.submitted) != 1 {
+		t.Fatalf("expect 1 scrobble but %v", f.submitter.submitted)
+	}
+	s := f.submitter.submitted[0]
+	expected := Scrobble{Artist: "artist1", Track: "name1", Album: "album1", Duration: 3 * time.Minute, Timestamp: started}
+	if s != expected {
+		t.Errorf("expect %v but %v", expected, s)
+	}
+	if f.queue.Len() != 0 {
+		t.Errorf("queue is not empty: %v", f.queue.Len())
+	}
+
+	// the rest of the same play is not scrobbled again.
+	f.run(80 * time.Second)
+	if len(f.submitter.submitted) != 1 {
+		t.Errorf("scrobbled twice: %v", f.submitter.submitted)
+	}
+
+	// on repeat, the track starts over.
+	f.player.status.Position = 0
+	f.run(95 * time.Second)
+	if len(f.submitter.submitted) != 2 {
+		t.Errorf("repeat is not scrobbled: %v", f.submitter.submitted)
+	}
+}
+
+func TestScrobbleLongTrack(t *testing.T) {
+	f := newFixture(t)
+
+	f.start(newTrack("1", 20*time.Minute))
+	f.run(235 * time.Second)
+	if f.submitter.calls != 0 {
+		t.Fatalf("scrobbled before 4 minutes: %v", f.submitter.submitted)
+	}
+	f.run(5 * time.Second)
+	if len(f.submitter.submitted) != 1 {
+		t.Errorf("expect 1 scrobble but %v", f.submitter.submitted)
+	}
+}
+
+func TestScrobbleNotPlayed(t *testing.T) {
+	f := newFixture(t)
+
+	// short tracks are never scrobbled.
+	f.start(newTrack("1", 30*time.Second))
+	f.run(30 * time.Second)
+
+	// pausing does not count.
+	f.start(newTrack("2", 3*time.Minute))
+	f.run(60 * time.Second)
+	f.player.status.Playing = false
+	f.run(10 * time.Minute)
+	f.player.status.Playing = true
+	f.run(25 * time.Second)
+
+	// seeking forward does not count.
+	f.start(newTrack("3", 3*time.Minute))
+	f.run(10 * time.Second)
+	f.player.status.Position = 170 * time.Second
+	f.run(10 * time.Second)
+
+	// skipping to another track starts a new play.
+	f.start(newTrack("4", 3*time.Minute))
+	f.run(60 * time.Second)
+	f.start(newTrack("5", 3*time.Minute))
+	f.run(60 * time.Second)
+
+	// tracks without an artist are not scrobbled.
+	track := newTrack("6", 3*time.Minute)
+	track.Artist = ""
+	f.start(track)
+	f.run(3 * time.Minute)
+
+	if f.submitter.calls != 0 {
+		t.Errorf("unexpected scrobbles: %v", f.submitter.submitted)
+	}
+
+	// playing track 2 again long enough is scrobbled.
+	f.start(newTrack("2", 3*time.Minute))
+	f.run(90 * time.Second)
+	if len(f.submitter.submitted) != 1 {
+		t.Errorf("expect 1 scrobble but %v", f.submitter.submitted)
+	}
+}
+
+func TestScrobblePlayerError(t *testing.T) {
+	f := newFixture(t)
+
+	f.start(newTrack("1", 3*time.Minute))
+	f.run(60 * time.Second)
+
+	// the time while the player does not answer is not counted.
+	f.player.err = errors.New("quit")
+	err := f.step(10 * time.Minute)
+	if err == nil {
+		t.Fatal("expect the error of the player")
+	}
+	f.player.err = nil
+	f.run(30 * time.Second)
+	if f.submitter.calls != 0 {
+		t.Fatalf("unexpected scrobbles: %v", f.submitter.submitted)
+	}
+
+	f.run(5 * time.Second)
+	if len(f.submitter.submitted) != 1 {
+		t.Errorf("expect 1 scrobble but %v", f.submitter.submitted)
+	}
+}
+
+func TestScrobbleOffline(t *testing.T) {
+	f := newFixture(t)
+	f.submitter.err = errors.New("offline")
+
+	for i := 0; i < BatchSize+10; i++ {
+		f.start(newTrack(fmt.Sprint(i), time.Minute))
+		f.step(30 * time.Second)
+	}
+
+	if f.queue.Len() != BatchSize+10 {
+		t.Fatalf("expect %v queued scrobbles but %v", BatchSize+10, f.queue.Len())
+	}
+	// failed submissions back off, they are retried after 1, 2, 4 and 8 minutes.
+	if f.submitter.calls != 5 {
+		t.Errorf("expect 5 submissions but %v", f.submitter.calls)
+	}
+
+	// the queue survives a restart.
+	queue, err := OpenQueue(f.queue.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.Len() != BatchSize+10 {
+		t.Fatalf("expect %v saved scrobbles but %v", BatchSize+10, queue.Len())
+	}
+
+	f.submitter = &fakeSubmitter{}
+	s := New(f.player, queue, f.submitter)
+	err = s.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.submitter.calls != 2 {
+		t.Errorf("expect 2 batches but %v", f.submitter.calls)
+	}
+	for i, s := range f.submitter.submitted {
+		if s.Track != fmt.Sprintf("name%v", i) {
+			t.Errorf("expect name%v but %v", i, s.Track)
+		}
+	}
+	if queue.Len() != 0 {
+		t.Errorf("queue is not empty: %v", queue.Len())
+	}
+}
+
+func TestFlushRejected(t *testing.T) {
+	f := newFixture(t)
+	f.submitter.err = fmt.Errorf("invalid: %w", ErrRejected)
+
+	f.start(newTrack("1", time.Minute))
+	f.step(30 * time.Second)
+
+	if f.submitter.calls != 1 {
+		t.Errorf("expect 1 submission but %v", f.submitter.calls)
+	}
+	if f.queue.Len() != 0 {
+		t.Errorf("rejected scrobbles are not dropped: %v", f.queue.Len())
+	}
+}
+
+func TestOpenQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := OpenQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err = q.Add(Scrobble{Artist: "artist", Track: fmt.Sprint(i), Timestamp: time.Unix(int64(i), 0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = q.Remove(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = OpenQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrobbles := q.Peek(BatchSize)
+	if len(scrobbles) != 2 || scrobbles[0].Track != "1" || scrobbles[1].Track != "2" {
+		t.Errorf("unexpected scrobbles: %v", scrobbles)
+	}
+	if !scrobbles[0].Timestamp.Equal(time.Unix(1, 0)) {
+		t.Errorf("unexpected timestamp: %v", scrobbles[0].Timestamp)
+	}
+}
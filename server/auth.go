@@ -0,0 +1,32 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// TokenAuth rejects the requests without "Authorization: Bearer <token>".
+// The OpenAPI document is served without a token so that clients can discover the API.
+// Browsers cannot set headers on WebSocket requests, so those may pass the token as "?access_token=<token>".
+func TokenAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Trim(r.URL.Path, "/") == "openapi.json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		if auth == "" && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			auth = "Bearer " + r.URL.Query().Get("access_token")
+		}
+		const prefix = "Bearer "
+		if !strings.HasPrefix(auth, prefix) || subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="itunes"`)
+			writeError(w, &httpError{status: http.StatusUnauthorized, message: "invalid token"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event is pushed to the WebSocket clients of /events.
+type Event struct {
+	// Type is what changed: "snapshot" on connect, "track", "state" or "position".
+	Type       string      `json:"type"`
+	NowPlaying *NowPlaying `json:"nowPlaying"`
+}
+
+const (
+	pollInterval = time.Second
+	// the server pings clients at this interval and drops the ones not answering within two.
+	heartbeatInterval = 30 * time.Second
+	writeTimeout      = 10 * time.Second
+	// events queued for a client, a client falling further behind is resynced with a snapshot.
+	clientBufferSize = 16
+)
+
+// feed polls the player while clients are connected and pushes the changes to them.
+type feed struct {
+	player    Player
+	interval  time.Duration
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	last    *NowPlaying
+	stop    chan struct{}
+}
+
+func newFeed(player Player) *feed {
+	return &feed{
+		player:    player,
+		interval:  pollInterval,
+		heartbeat: heartbeatInterval,
+		upgrader: websocket.Upgrader{
+			// overlays are served from anywhere, even from files. the token protects the feed.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		clients: map[*client]bool{},
+	}
+}
+
+type client struct {
+	conn *websocket.Conn
+	send chan Event
+	done chan struct{}
+}
+
+// push queues ev without blocking.
+// A slow client's pending events are dropped and replaced with a snapshot of the latest state,
+// so that it catches up without holding back the other clients.
+func (c *client) push(ev Event) {
+	select {
+	case c.send <- ev:
+		return
+	default:
+	}
+
+	for drained := false; !drained; {
+		select {
+		case <-c.send:
+		default:
+			drained = true
+		}
+	}
+
+	select {
+	case c.send <- Event{Type: "snapshot", NowPlaying: ev.NowPlaying}:
+	default:
+	}
+}
+
+// change returns the type of the event from prev to n, or "" if nothing changed.
+func change(prev, n *NowPlaying) string {
+	switch {
+	case (prev.Track == nil) != (n.Track == nil):
+		return "track"
+	case prev.Track != nil && *prev.Track != *n.Track:
+		return "track"
+	case prev.State != n.State:
+		return "state"
+	case prev.Position != n.Position:
+		return "position"
+	}
+
+	return ""
+}
+
+func (f *feed) poll(stop chan struct{}) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		n, err := f.player.NowPlaying()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		f.mu.Lock()
+		typ := change(f.last, n)
+		f.last = n
+		if typ != "" {
+			for c := range f.clients {
+				c.push(Event{Type: typ, NowPlaying: n})
+			}
+		}
+		f.mu.Unlock()
+	}
+}
+
+// add registers a client after queuing the snapshot for it.
+func (f *feed) add(c *client) error {
+	n, err := f.player.NowPlaying()
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c.send <- Event{Type: "snapshot", NowPlaying: n}
+	f.clients[c] = true
+	f.last = n
+	if f.stop == nil {
+		f.stop = make(chan struct{})
+		go f.poll(f.stop)
+	}
+
+	return nil
+}
+
+func (f *feed) remove(c *client) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.clients[c] {
+		return
+	}
+
+	delete(f.clients, c)
+	close(c.done)
+	if len(f.clients) == 0 {
+		close(f.stop)
+		f.stop = nil
+	}
+}
+
+func (f *feed) write(c *client) {
+	defer c.conn.Close()
+	ticker := time.NewTicker(f.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case ev := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			err := c.conn.WriteJSON(&ev)
+			if err != nil {
+				f.remove(c)
+				return
+			}
+		case <-ticker.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			if err != nil {
+				f.remove(c)
+				return
+			}
+		}
+	}
+}
+
+func (f *feed) serve(w http.ResponseWriter, r *http.Request) error {
+	conn, err := f.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has replied already.
+		return nil
+	}
+
+	c := &client{
+		conn: conn,
+		send: make(chan Event, clientBufferSize),
+		done: make(chan struct{}),
+	}
+	err = f.add(c)
+	if err != nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()), time.Now().Add(writeTimeout))
+		conn.Close()
+		return nil
+	}
+	go f.write(c)
+
+	// clients do not send messages, reading only processes pongs and closes.
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * f.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * f.heartbeat))
+	})
+	for {
+		_, _, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+	}
+	f.remove(c)
+
+	return nil
+}
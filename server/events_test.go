@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type feedPlayer struct {
+	fakePlayer
+	mu  sync.Mutex
+	now NowPlaying
+}
+
+func (p *feedPlayer) NowPlaying() (*NowPlaying, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.now
+	return &n, nil
+}
+
+func (p *feedPlayer) set(f func(n *NowPlaying)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f(&p.now)
+}
+
+func TestEvents(t *testing.T) {
+	p := &feedPlayer{now: NowPlaying{State: "Playing", Track: &Track{PersistentID: "T1"}}}
+	handler := New(p)
+	handler.feed.interval = 10 * time.Millisecond
+	handler.feed.heartbeat = 50 * time.Millisecond
+	s := httptest.NewServer(TokenAuth(token, handler))
+	defer s.Close()
+
+	url := "ws" + strings.TrimPrefix(s.URL, "http") + "/events"
+	_, res, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expect 401 without a token, but %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	expect := func(typ string) *NowPlaying {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var ev Event
+		err := conn.ReadJSON(&ev)
+		if err != nil {
+			t.Fatalf("expect %v, but %v", typ, err)
+		}
+		if ev.Type != typ {
+			t.Fatalf("expect %v, but %+v", typ, ev)
+		}
+		return ev.NowPlaying
+	}
+
+	n := expect("snapshot")
+	if n.Track.PersistentID != "T1" {
+		t.Errorf("unexpected snapshot: %+v", n)
+	}
+
+	p.set(func(n *NowPlaying) { n.Track = &Track{PersistentID: "T2"} })
+	n = expect("track")
+	if n.Track.PersistentID != "T2" {
+		t.Errorf("unexpected track: %+v", n.Track)
+	}
+
+	p.set(func(n *NowPlaying) { n.Position = 3 })
+	expect("position")
+	p.set(func(n *NowPlaying) { n.State = "Stopped" })
+	expect("state")
+
+	var pings int
+	conn.SetPingHandler(func(data string) error {
+		pings++
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, _, err = conn.ReadMessage()
+	if ne, ok := err.(interface{ Timeout() bool }); !ok || !ne.Timeout() {
+		t.Errorf("expect no event, but %v", err)
+	}
+	if pings == 0 {
+		t.Error("expect pings")
+	}
+}
+
+func TestPush(t *testing.T) {
+	c := &client{send: make(chan Event, 2)}
+	for _, id := range []string{"T1", "T2", "T3"} {
+		c.push(Event{Type: "track", NowPlaying: &NowPlaying{Track: &Track{PersistentID: id}}})
+	}
+
+	if len(c.send) != 1 {
+		t.Fatalf("expect the queue to be replaced, but %v events", len(c.send))
+	}
+	ev := <-c.send
+	if ev.Type != "snapshot" || ev.NowPlaying.Track.PersistentID != "T3" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
@@ -0,0 +1,195 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesPlayer struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesPlayer returns the Player of an iTunes application.
+func NewItunesPlayer(it *itunes.Itunes) Player {
+	return &itunesPlayer{it: it}
+}
+
+// convertError wraps the typed errors of itunes in the errors of this package.
+func convertError(err error) error {
+	var notFound *itunes.NotFoundError
+	var notSupported *itunes.NotSupportedError
+	switch {
+	case errors.As(err, &notFound):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.As(err, &notSupported):
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	}
+
+	return err
+}
+
+func newTrack(t *itunes.Track) Track {
+	return Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Duration:     t.Duration().Seconds(),
+	}
+}
+
+func (p *itunesPlayer) NowPlaying() (*NowPlaying, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.it.PlayerState()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NowPlaying{State: state.String()}
+	t, err := p.it.CurrentTrack()
+	if errors.Is(err, itunes.ErrNoCurrentTrack) {
+		return n, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	track := newTrack(t)
+	n.Track = &track
+
+	pos, err := p.it.Position()
+	if err != nil {
+		return nil, err
+	}
+	n.Position = pos.Seconds()
+
+	return n, nil
+}
+
+func (p *itunesPlayer) Play() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Play()
+}
+
+func (p *itunesPlayer) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.Pause()
+}
+
+func (p *itunesPlayer) NextTrack() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.NextTrack()
+}
+
+func (p *itunesPlayer) SoundVolume() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SoundVolume()
+}
+
+func (p *itunesPlayer) SetSoundVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.it.SetSoundVolume(volume)
+}
+
+func (p *itunesPlayer) Playlists() ([]Playlist, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count, err := p.it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := p.it.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, Playlist{PersistentID: pl.PersistentID(), Name: pl.Name()})
+		pl.Close()
+	}
+
+	return playlists, nil
+}
+
+func (p *itunesPlayer) PlaylistTracks(playlistID string, offset, limit int) ([]Track, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pl, err := p.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return nil, 0, convertError(err)
+	}
+	defer pl.Close()
+
+	total, err := pl.TrackCount()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	output, err := pl.GetTracksRange(offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracks := make([]Track, 0, limit)
+	for t := range output {
+		tracks = append(tracks, newTrack(t))
+		t.Close()
+	}
+
+	return tracks, total, nil
+}
+
+func (p *itunesPlayer) Artwork(trackID string) (*Artwork, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, err := p.it.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	artworks, err := t.GetArtworks()
+	if err != nil {
+		return nil, err
+	}
+
+	var a *Artwork
+	for artwork := range artworks {
+		if a == nil && err == nil {
+			var buf bytes.Buffer
+			_, err = artwork.WriteTo(&buf)
+			a = &Artwork{ContentType: artwork.Format().MIMEType(), Data: buf.Bytes()}
+		}
+		artwork.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, convertError(&itunes.NotFoundError{Kind: "artwork", ID: trackID})
+	}
+
+	return a, nil
+}
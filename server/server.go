@@ -0,0 +1,347 @@
+// Package server exposes the player and the library over an HTTP REST API.
+//
+// The API is described by the OpenAPI document served at /openapi.json.
+// Requests are authenticated by a bearer token, see TokenAuth.
+// The changes of the player are pushed to the WebSocket clients of /events.
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotFound is wrapped by the Player errors for a missing track, playlist or artwork.
+	ErrNotFound = errors.New("not found")
+	// ErrNotSupported is wrapped by the Player errors for a feature the platform does not provide.
+	ErrNotSupported = errors.New("not supported")
+)
+
+type Track struct {
+	PersistentID string `json:"persistentId"`
+	Name         string `json:"name"`
+	Artist       string `json:"artist"`
+	Album        string `json:"album"`
+	// Duration is in seconds.
+	Duration float64 `json:"duration"`
+}
+
+type NowPlaying struct {
+	State string `json:"state"`
+	// Position is in seconds.
+	Position float64 `json:"position"`
+	// Track is nil if the player has no track.
+	Track *Track `json:"track"`
+}
+
+type Playlist struct {
+	PersistentID string `json:"persistentId"`
+	Name         string `json:"name"`
+}
+
+type Artwork struct {
+	ContentType string
+	Data        []byte
+}
+
+// Player is what the server exposes, NewItunesPlayer implements it for iTunes.
+type Player interface {
+	NowPlaying() (*NowPlaying, error)
+	Play() error
+	Pause() error
+	NextTrack() error
+	SoundVolume() (int, error)
+	SetSoundVolume(volume int) error
+
+	Playlists() ([]Playlist, error)
+	// PlaylistTracks returns up to limit tracks from offset and the number of tracks in the playlist.
+	PlaylistTracks(playlistID string, offset, limit int) ([]Track, int, error)
+	// Artwork returns the first artwork of the track.
+	Artwork(trackID string) (*Artwork, error)
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+//go:embed openapi.json
+var openAPI []byte
+
+// OpenAPI returns the OpenAPI 3 document describing the API.
+func OpenAPI() []byte {
+	return append([]byte(nil), openAPI...)
+}
+
+type Server struct {
+	player Player
+	feed   *feed
+}
+
+// New returns the handler of the API. Wrap it with TokenAuth unless it only listens on a trusted interface.
+func New(player Player) *Server {
+	return &Server{player: player, feed: newFeed(player)}
+}
+
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func statusOf(err error) int {
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		return he.status
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNotSupported):
+		return http.StatusNotImplemented
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusOf(err))
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// route is a request path split into its segments.
+type route []string
+
+func (r route) match(pattern ...string) bool {
+	if len(r) != len(pattern) {
+		return false
+	}
+
+	for i, p := range pattern {
+		if p != "*" && p != r[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params route) error
+
+// methods maps the allowed methods to their handlers.
+type methods map[string]handlerFunc
+
+func (s *Server) route(path string) (methods, route) {
+	r := route(strings.Split(strings.Trim(path, "/"), "/"))
+	switch {
+	case r.match("openapi.json"):
+		return methods{http.MethodGet: s.openAPI}, r
+	case r.match("now-playing"):
+		return methods{http.MethodGet: s.nowPlaying}, r
+	case r.match("events"):
+		return methods{http.MethodGet: s.events}, r
+	case r.match("player", "play"), r.match("player", "pause"), r.match("player", "next"):
+		return methods{http.MethodPost: s.control}, r
+	case r.match("volume"):
+		return methods{http.MethodGet: s.volume, http.MethodPut: s.setVolume}, r
+	case r.match("playlists"):
+		return methods{http.MethodGet: s.playlists}, r
+	case r.match("playlists", "*", "tracks"):
+		return methods{http.MethodGet: s.playlistTracks}, r
+	case r.match("tracks", "*", "artwork"):
+		return methods{http.MethodGet: s.artwork}, r
+	}
+
+	return nil, r
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ms, params := s.route(r.URL.Path)
+	if ms == nil {
+		writeError(w, &httpError{status: http.StatusNotFound, message: "no such endpoint"})
+		return
+	}
+
+	h, ok := ms[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(ms))
+		for m := range ms {
+			allowed = append(allowed, m)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, &httpError{status: http.StatusMethodNotAllowed, message: "method not allowed"})
+		return
+	}
+
+	err := h(w, r, params)
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, _ *http.Request, _ route) error {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(openAPI)
+	return err
+}
+
+func (s *Server) nowPlaying(w http.ResponseWriter, _ *http.Request, _ route) error {
+	n, err := s.player.NowPlaying()
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, n)
+	return nil
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request, _ route) error {
+	return s.feed.serve(w, r)
+}
+
+func (s *Server) control(w http.ResponseWriter, _ *http.Request, params route) error {
+	var err error
+	switch params[1] {
+	case "play":
+		err = s.player.Play()
+	case "pause":
+		err = s.player.Pause()
+	case "next":
+		err = s.player.NextTrack()
+	}
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type volume struct {
+	Volume *int `json:"volume"`
+}
+
+func (s *Server) volume(w http.ResponseWriter, _ *http.Request, _ route) error {
+	v, err := s.player.SoundVolume()
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, volume{Volume: &v})
+	return nil
+}
+
+func (s *Server) setVolume(w http.ResponseWriter, r *http.Request, params route) error {
+	var body volume
+	err := json.NewDecoder(io.LimitReader(r.Body, 1024)).Decode(&body)
+	if err != nil {
+		return badRequest("invalid body:%v", err)
+	}
+
+	if body.Volume == nil || *body.Volume < 0 || *body.Volume > 100 {
+		return badRequest("volume must be from 0 to 100")
+	}
+
+	err = s.player.SetSoundVolume(*body.Volume)
+	if err != nil {
+		return err
+	}
+
+	return s.volume(w, r, params)
+}
+
+func (s *Server) playlists(w http.ResponseWriter, _ *http.Request, _ route) error {
+	playlists, err := s.player.Playlists()
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, http.StatusOK, playlists)
+	return nil
+}
+
+type trackPage struct {
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+	Total  int     `json:"total"`
+	Tracks []Track `json:"tracks"`
+}
+
+func queryInt(r *http.Request, name string, defaultValue, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || (max > 0 && n > max) {
+		return 0, badRequest("invalid %v:%v", name, v)
+	}
+
+	return n, nil
+}
+
+func (s *Server) playlistTracks(w http.ResponseWriter, r *http.Request, params route) error {
+	offset, err := queryInt(r, "offset", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	limit, err := queryInt(r, "limit", defaultPageSize, maxPageSize)
+	if err != nil {
+		return err
+	}
+
+	tracks, total, err := s.player.PlaylistTracks(params[1], offset, limit)
+	if err != nil {
+		return err
+	}
+	if tracks == nil {
+		tracks = []Track{}
+	}
+
+	writeJSON(w, http.StatusOK, trackPage{Offset: offset, Limit: limit, Total: total, Tracks: tracks})
+	return nil
+}
+
+func (s *Server) artwork(w http.ResponseWriter, _ *http.Request, params route) error {
+	a, err := s.player.Artwork(params[1])
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(a.Data)))
+	// the headers are sent already, a failed write cannot be reported.
+	w.Write(a.Data)
+	return nil
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakePlayer struct {
+	state  string
+	volume int
+	calls  []string
+	tracks []Track
+}
+
+func (p *fakePlayer) NowPlaying() (*NowPlaying, error) {
+	return &NowPlaying{State: p.state, Position: 12, Track: &p.tracks[0]}, nil
+}
+
+func (p *fakePlayer) Play() error      { p.calls = append(p.calls, "play"); return nil }
+func (p *fakePlayer) Pause() error     { p.calls = append(p.calls, "pause"); return nil }
+func (p *fakePlayer) NextTrack() error { p.calls = append(p.calls, "next"); return nil }
+
+func (p *fakePlayer) SoundVolume() (int, error)       { return p.volume, nil }
+func (p *fakePlayer) SetSoundVolume(volume int) error { p.volume = volume; return nil }
+
+func (p *fakePlayer) Playlists() ([]Playlist, error) {
+	return []Playlist{{PersistentID: "P1", Name: "Mix"}}, nil
+}
+
+func (p *fakePlayer) PlaylistTracks(playlistID string, offset, limit int) ([]Track, int, error) {
+	if playlistID != "P1" {
+		return nil, 0, fmt.Errorf("%w: playlist:%v", ErrNotFound, playlistID)
+	}
+
+	end := offset + limit
+	if end > len(p.tracks) {
+		end = len(p.tracks)
+	}
+	if offset > end {
+		offset = end
+	}
+	return p.tracks[offset:end], len(p.tracks), nil
+}
+
+func (p *fakePlayer) Artwork(trackID string) (*Artwork, error) {
+	if trackID != "T1" {
+		return nil, fmt.Errorf("%w: artwork:%v", ErrNotFound, trackID)
+	}
+	return &Artwork{ContentType: "image/png", Data: []byte("\x89PNG")}, nil
+}
+
+const token = "secret"
+
+func newTestServer() (*fakePlayer, *httptest.Server) {
+	p := &fakePlayer{state: "Playing", volume: 30}
+	for i := 1; i <= 5; i++ {
+		p.tracks = append(p.tracks, Track{PersistentID: fmt.Sprintf("T%d", i), Name: fmt.Sprintf("Song %d", i)})
+	}
+
+	return p, httptest.NewServer(TokenAuth(token, New(p)))
+}
+
+func request(t *testing.T, s *httptest.Server, method, path, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(data)
+}
+
+func TestAuth(t *testing.T) {
+	_, s := newTestServer()
+	defer s.Close()
+
+	for _, auth := range []string{"", "Bearer wrong", token} {
+		req, _ := http.NewRequest(http.MethodGet, s.URL+"/now-playing", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%q: expect 401, but %v", auth, res.StatusCode)
+		}
+	}
+
+	res, err := http.Get(s.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("openapi.json: expect 200, but %v", res.StatusCode)
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	p, s := newTestServer()
+	defer s.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		contains           string
+	}{
+		{"GET", "/now-playing", "", 200, `"state":"Playing","position":12,"track":{"persistentId":"T1"`},
+		{"POST", "/player/pause", "", 204, ""},
+		{"POST", "/player/next", "", 204, ""},
+		{"GET", "/player/play", "", 405, "method not allowed"},
+		{"PUT", "/volume", `{"volume":70}`, 200, `{"volume":70}`},
+		{"PUT", "/volume", `{"volume":101}`, 400, "volume must be"},
+		{"PUT", "/volume", `{}`, 400, "volume must be"},
+		{"GET", "/playlists", "", 200, `[{"persistentId":"P1","name":"Mix"}]`},
+		{"GET", "/playlists/P1/tracks?offset=3&limit=10", "", 200, `"offset":3,"limit":10,"total":5,"tracks":[{"persistentId":"T4"`},
+		{"GET", "/playlists/P1/tracks?offset=9", "", 200, `"tracks":[]`},
+		{"GET", "/playlists/P1/tracks?limit=1000", "", 400, "invalid limit"},
+		{"GET", "/playlists/P2/tracks", "", 404, "not found"},
+		{"GET", "/tracks/T2/artwork", "", 404, "not found"},
+		{"GET", "/nothing", "", 404, "no such endpoint"},
+	}
+
+	for _, test := range tests {
+		res, body := request(t, s, test.method, test.path, test.body)
+		if res.StatusCode != test.status || !strings.Contains(body, test.contains) {
+			t.Errorf("%v %v: expect %v %v, but %v %v", test.method, test.path, test.status, test.contains, res.StatusCode, body)
+		}
+	}
+
+	if strings.Join(p.calls, ",") != "pause,next" || p.volume != 70 {
+		t.Errorf("unexpected player: %+v", p)
+	}
+
+	res, body := request(t, s, "GET", "/tracks/T1/artwork", "")
+	if res.Header.Get("Content-Type") != "image/png" || body != "\x89PNG" {
+		t.Errorf("unexpected artwork: %v %q", res.Header, body)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]interface{} `json:"paths"`
+	}
+	err := json.Unmarshal(OpenAPI(), &doc)
+	if err != nil {
+		t.Fatalf("invalid OpenAPI document.\n%v", err)
+	}
+
+	s := New(&fakePlayer{})
+	for path, operations := range doc.Paths {
+		path = strings.Replace(path, "{pid}", "X", 1)
+		ms, _ := s.route(path)
+		for method := range operations {
+			if _, ok := ms[strings.ToUpper(method)]; !ok {
+				t.Errorf("%v %v is documented but not routed", method, path)
+			}
+		}
+	}
+}
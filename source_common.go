@@ -0,0 +1,67 @@
+package itunes
+
+type SourceKind int
+
+const (
+	SourceKindUnknown SourceKind = iota
+	SourceKindLibrary
+	SourceKindIPod
+	SourceKindAudioCD
+	SourceKindMP3CD
+	SourceKindDevice
+	SourceKindRadioTuner
+	SourceKindSharedLibrary
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceKindUnknown:
+		return "Unknown"
+	case SourceKindLibrary:
+		return "Library"
+	case SourceKindIPod:
+		return "IPod"
+	case SourceKindAudioCD:
+		return "AudioCD"
+	case SourceKindMP3CD:
+		return "MP3CD"
+	case SourceKindDevice:
+		return "Device"
+	case SourceKindRadioTuner:
+		return "RadioTuner"
+	case SourceKindSharedLibrary:
+		return "SharedLibrary"
+	}
+
+	return ""
+}
+
+func (s *Source) Name() string {
+	return s.name
+}
+
+func (s *Source) Kind() SourceKind {
+	return s.kind
+}
+
+func (s *Source) GetPlaylists() ([]*Playlist, error) {
+	count, err := s.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]*Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		p, err := s.GetPlaylist(i)
+		if err != nil {
+			for _, p := range playlists {
+				p.Close()
+			}
+			return nil, err
+		}
+
+		playlists = append(playlists, p)
+	}
+
+	return playlists, nil
+}
@@ -0,0 +1,25 @@
+package itunes
+
+import "testing"
+
+func TestKindString(t *testing.T) {
+	names := map[string]bool{}
+	for k := SourceKindUnknown; k <= SourceKindSharedLibrary; k++ {
+		if k.String() == "" || names[k.String()] {
+			t.Errorf("source kind %d has no name of its own: %q", int(k), k.String())
+		}
+		names[k.String()] = true
+	}
+
+	names = map[string]bool{}
+	for k := AirPlayKindUnknown; k <= AirPlayKindHomePod; k++ {
+		if k.String() == "" || names[k.String()] {
+			t.Errorf("AirPlay kind %d has no name of its own: %q", int(k), k.String())
+		}
+		names[k.String()] = true
+	}
+
+	if SourceKind(100).String() != "" || AirPlayDeviceKind(100).String() != "" {
+		t.Error("expect an unknown kind to have no name")
+	}
+}
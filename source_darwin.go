@@ -0,0 +1,112 @@
+package itunes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type Source struct {
+	persistentID string
+
+	name string
+	kind SourceKind
+}
+
+func parseSourceKind(v string) SourceKind {
+	switch v {
+	case "library":
+		return SourceKindLibrary
+	case "iPod":
+		return SourceKindIPod
+	case "audio CD":
+		return SourceKindAudioCD
+	case "MP3 CD":
+		return SourceKindMP3CD
+	case "device":
+		return SourceKindDevice
+	case "radio tuner":
+		return SourceKindRadioTuner
+	case "shared library":
+		return SourceKindSharedLibrary
+	}
+
+	return SourceKindUnknown
+}
+
+func createSource(values []string) (*Source, error) {
+	if len(values) < 3 {
+		return nil, errors.New("values is empty.")
+	}
+
+	s := &Source{
+		persistentID: values[0],
+
+		name: values[1],
+		kind: parseSourceKind(values[2]),
+	}
+
+	return s, nil
+}
+
+// for compatibility
+func (_ *Source) Close() {
+}
+
+func (_ *Itunes) Sources() ([]*Source, error) {
+	o, err := execJS(`app.sources().forEach(logSource);`)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]*Source, 0, 4)
+	for line := range o {
+		columns, err := validateResult(line)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := createSource(columns)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources, nil
+}
+
+func (s *Source) PersistentID() string {
+	return s.persistentID
+}
+
+func (s *Source) PlaylistCount() (int, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`p(findSourceByPersistentId(%s).playlists.length);`, jsString(s.persistentID)))
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, errors.New("PlaylistCount is nil.")
+	}
+
+	count, err := strconv.ParseInt(columns[0], 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func (s *Source) GetPlaylist(index int) (*Playlist, error) {
+	columns, err := getColumnsByJS(fmt.Sprintf(`logPlaylist(findSourceByPersistentId(%s).playlists[%d]());`, jsString(s.persistentID), index))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+	}
+
+	return createPlaylist(columns)
+}
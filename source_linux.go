@@ -0,0 +1,28 @@
+package itunes
+
+type Source struct {
+	persistentID string
+
+	name string
+	kind SourceKind
+}
+
+// for compatibility
+func (_ *Source) Close() {
+}
+
+func (_ *Itunes) Sources() ([]*Source, error) {
+	return nil, &NotSupportedError{Feature: "Sources", Platform: "Linux"}
+}
+
+func (s *Source) PersistentID() string {
+	return s.persistentID
+}
+
+func (_ *Source) PlaylistCount() (int, error) {
+	return 0, &NotSupportedError{Feature: "Source.PlaylistCount", Platform: "Linux"}
+}
+
+func (_ *Source) GetPlaylist(index int) (*Playlist, error) {
+	return nil, &NotSupportedError{Feature: "Source.GetPlaylist", Platform: "Linux"}
+}
@@ -0,0 +1,154 @@
+package itunes
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/yaegaki/go-ole-handler"
+)
+
+type Source struct {
+	handler *olehandler.OleHandler
+
+	itunes    *Itunes
+	playlists *olehandler.OleHandler
+	highID    uint32
+	lowID     uint32
+
+	name string
+	kind SourceKind
+}
+
+// ITSourceKind
+const (
+	sourceKindUnknown       = 0
+	sourceKindLibrary       = 1
+	sourceKindIPod          = 2
+	sourceKindAudioCD       = 3
+	sourceKindMP3CD         = 4
+	sourceKindDevice        = 5
+	sourceKindRadioTuner    = 6
+	sourceKindSharedLibrary = 7
+)
+
+func parseSourceKind(v int) SourceKind {
+	switch v {
+	case sourceKindLibrary:
+		return SourceKindLibrary
+	case sourceKindIPod:
+		return SourceKindIPod
+	case sourceKindAudioCD:
+		return SourceKindAudioCD
+	case sourceKindMP3CD:
+		return SourceKindMP3CD
+	case sourceKindDevice:
+		return SourceKindDevice
+	case sourceKindRadioTuner:
+		return SourceKindRadioTuner
+	case sourceKindSharedLibrary:
+		return SourceKindSharedLibrary
+	}
+
+	return SourceKindUnknown
+}
+
+func createSource(it *Itunes, handler *olehandler.OleHandler) (*Source, error) {
+	v, err := it.handler.GetProperty("ITObjectPersistentIDHigh", handler.Handle)
+	if err != nil {
+		return nil, err
+	}
+	highID := uint32(v.Val)
+
+	v, err = it.handler.GetProperty("ITObjectPersistentIDLow", handler.Handle)
+	if err != nil {
+		return nil, err
+	}
+	lowID := uint32(v.Val)
+
+	name, err := handler.GetStringProperty("Name")
+	if err != nil {
+		return nil, err
+	}
+
+	kind, err := handler.GetIntProperty("Kind")
+	if err != nil {
+		return nil, err
+	}
+
+	playlists, err := handler.GetOleHandler("Playlists")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Source{
+		handler: handler,
+
+		itunes:    it,
+		playlists: playlists,
+		highID:    highID,
+		lowID:     lowID,
+
+		name: name,
+		kind: parseSourceKind(kind),
+	}
+
+	return s, nil
+}
+
+func (s *Source) Close() {
+	s.handler.Close()
+}
+
+func (it *Itunes) Sources() ([]*Source, error) {
+	collection, err := it.handler.GetOleHandler("Sources")
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Close()
+
+	count, err := collection.GetIntProperty("Count")
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]*Source, 0, count)
+	for i := 1; i <= count; i++ {
+		var s *Source
+		err = collection.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+			s, err = createSource(it, handler)
+			return err
+		}, i)
+
+		if err != nil {
+			for _, s := range sources {
+				s.Close()
+			}
+			return nil, err
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources, nil
+}
+
+func (s *Source) PersistentID() string {
+	return fmt.Sprintf("%x%x", s.highID, s.lowID)
+}
+
+func (s *Source) PlaylistCount() (int, error) {
+	return s.playlists.GetIntProperty("Count")
+}
+
+func (s *Source) GetPlaylist(index int) (p *Playlist, err error) {
+	err = s.playlists.GetOleHandlerWithCallbackAndArgs("Item", func(handler *olehandler.OleHandler) error {
+		if isNull(handler) {
+			return &NotFoundError{Kind: "playlist", ID: strconv.Itoa(index)}
+		}
+
+		p, err = createPlaylist(s.itunes, handler)
+		return err
+	}, index+1)
+
+	return p, err
+}
@@ -0,0 +1,226 @@
+// Package stats computes totals and breakdowns over a library.
+//
+// *itunes.Track implements Track. Get all tracks at once with GetAllTracks,
+// which fetches the whole library in a single call on OSX:
+//
+//	all, err := it.GetAllTracks()
+//	tracks := make([]stats.Track, len(all))
+//	for i, t := range all {
+//		tracks[i] = t
+//	}
+//	s := stats.Compute(tracks, 10)
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Track interface {
+	Name() string
+	Artist() string
+	Genre() string
+	Year() int
+	Duration() time.Duration
+	Size() int64
+	Rating() int
+	PlayedCount() int
+}
+
+type Count struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type PlayedTrack struct {
+	Name        string `json:"name"`
+	Artist      string `json:"artist"`
+	PlayedCount int    `json:"playedCount"`
+}
+
+type Stats struct {
+	TrackCount int           `json:"trackCount"`
+	TotalTime  time.Duration `json:"totalTime"`
+	// TotalSize is in bytes.
+	TotalSize   int64 `json:"totalSize"`
+	NeverPlayed int   `json:"neverPlayed"`
+
+	// Genres and Artists are sorted by count, Years by year.
+	Genres  []Count `json:"genres"`
+	Artists []Count `json:"artists"`
+	Years   []Count `json:"years"`
+	// Ratings[n] is the number of tracks rated n stars.
+	Ratings   [6]int        `json:"ratings"`
+	TopPlayed []PlayedTrack `json:"topPlayed"`
+}
+
+func counts(m map[string]int) []Count {
+	result := make([]Count, 0, len(m))
+	for k, v := range m {
+		result = append(result, Count{Key: k, Count: v})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result
+}
+
+// Compute computes the statistics in a single pass, keeping the top most played tracks.
+func Compute(tracks []Track, top int) *Stats {
+	s := &Stats{}
+	genres := map[string]int{}
+	artists := map[string]int{}
+	years := map[int]int{}
+	played := make([]PlayedTrack, 0, len(tracks))
+
+	for _, t := range tracks {
+		s.TrackCount++
+		s.TotalTime += t.Duration()
+		s.TotalSize += t.Size()
+
+		genres[t.Genre()]++
+		artists[t.Artist()]++
+		years[t.Year()]++
+
+		stars := (t.Rating() + 10) / 20
+		if stars < 0 {
+			stars = 0
+		} else if stars > 5 {
+			stars = 5
+		}
+		s.Ratings[stars]++
+
+		if t.PlayedCount() == 0 {
+			s.NeverPlayed++
+		} else {
+			played = append(played, PlayedTrack{
+				Name:        t.Name(),
+				Artist:      t.Artist(),
+				PlayedCount: t.PlayedCount(),
+			})
+		}
+	}
+
+	s.Genres = counts(genres)
+	s.Artists = counts(artists)
+
+	s.Years = make([]Count, 0, len(years))
+	yearKeys := make([]int, 0, len(years))
+	for y := range years {
+		yearKeys = append(yearKeys, y)
+	}
+	sort.Ints(yearKeys)
+	for _, y := range yearKeys {
+		key := strconv.Itoa(y)
+		if y == 0 {
+			key = ""
+		}
+		s.Years = append(s.Years, Count{Key: key, Count: years[y]})
+	}
+
+	sort.SliceStable(played, func(i, j int) bool {
+		return played[i].PlayedCount > played[j].PlayedCount
+	})
+	if len(played) > top {
+		played = played[:top]
+	}
+	s.TopPlayed = played
+
+	return s
+}
+
+func (s *Stats) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("Tracks:       %d\n", s.TrackCount)
+	printf("Total time:   %v\n", s.TotalTime)
+	printf("Total size:   %.1f MB\n", float64(s.TotalSize)/(1024*1024))
+	printf("Never played: %d\n", s.NeverPlayed)
+
+	section := func(title string, counts []Count) {
+		printf("\n%v:\n", title)
+		for _, c := range counts {
+			key := c.Key
+			if key == "" {
+				key = "(none)"
+			}
+			printf("  %6d  %v\n", c.Count, key)
+		}
+	}
+	section("Genres", s.Genres)
+	section("Artists", s.Artists)
+	section("Years", s.Years)
+
+	printf("\nRatings:\n")
+	for stars, count := range s.Ratings {
+		printf("  %6d  %d stars\n", count, stars)
+	}
+
+	printf("\nTop played:\n")
+	for _, t := range s.TopPlayed {
+		printf("  %6d  %v - %v\n", t.PlayedCount, t.Artist, t.Name)
+	}
+
+	return err
+}
+
+func (s *Stats) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteCSV writes the statistics as rows of section, key and value.
+func (s *Stats) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"section", "key", "value"},
+		{"total", "tracks", strconv.Itoa(s.TrackCount)},
+		{"total", "seconds", strconv.FormatInt(int64(s.TotalTime/time.Second), 10)},
+		{"total", "bytes", strconv.FormatInt(s.TotalSize, 10)},
+		{"total", "never played", strconv.Itoa(s.NeverPlayed)},
+	}
+
+	for _, section := range []struct {
+		name   string
+		counts []Count
+	}{
+		{"genre", s.Genres},
+		{"artist", s.Artists},
+		{"year", s.Years},
+	} {
+		for _, c := range section.counts {
+			rows = append(rows, []string{section.name, c.Key, strconv.Itoa(c.Count)})
+		}
+	}
+
+	for stars, count := range s.Ratings {
+		rows = append(rows, []string{"rating", strconv.Itoa(stars), strconv.Itoa(count)})
+	}
+
+	for _, t := range s.TopPlayed {
+		rows = append(rows, []string{"top played", t.Artist + " - " + t.Name, strconv.Itoa(t.PlayedCount)})
+	}
+
+	err := cw.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type track struct {
+	name, artist, genre string
+	year, rating, count int
+	duration            time.Duration
+	size                int64
+}
+
+func (t *track) Name() string            { return t.name }
+func (t *track) Artist() string          { return t.artist }
+func (t *track) Genre() string           { return t.genre }
+func (t *track) Year() int               { return t.year }
+func (t *track) Duration() time.Duration { return t.duration }
+func (t *track) Size() int64             { return t.size }
+func (t *track) Rating() int             { return t.rating }
+func (t *track) PlayedCount() int        { return t.count }
+
+func library() []Track {
+	return []Track{
+		&track{name: "a", artist: "X", genre: "Rock", year: 1999, rating: 100, count: 10, duration: time.Minute, size: 100},
+		&track{name: "b", artist: "X", genre: "Rock", year: 2001, rating: 60, count: 3, duration: time.Minute, size: 200},
+		&track{name: "c", artist: "Y", genre: "Jazz", year: 1999, rating: 0, count: 0, duration: 2 * time.Minute, size: 300},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	s := Compute(library(), 1)
+
+	if s.TrackCount != 3 || s.TotalTime != 4*time.Minute || s.TotalSize != 600 || s.NeverPlayed != 1 {
+		t.Errorf("unexpected totals: %+v", s)
+	}
+
+	if len(s.Genres) != 2 || s.Genres[0] != (Count{"Rock", 2}) {
+		t.Errorf("unexpected genres: %v", s.Genres)
+	}
+
+	if len(s.Years) != 2 || s.Years[0] != (Count{"1999", 2}) || s.Years[1] != (Count{"2001", 1}) {
+		t.Errorf("unexpected years: %v", s.Years)
+	}
+
+	if s.Ratings != [6]int{1, 0, 0, 1, 0, 1} {
+		t.Errorf("unexpected ratings: %v", s.Ratings)
+	}
+
+	if len(s.TopPlayed) != 1 || s.TopPlayed[0].Name != "a" {
+		t.Errorf("unexpected top played: %v", s.TopPlayed)
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := Compute(library(), 10)
+
+	var text bytes.Buffer
+	err := s.WriteText(&text)
+	if err != nil {
+		t.Fatalf("WriteText failed.\n%v", err)
+	}
+	if !strings.Contains(text.String(), "Tracks:       3\n") {
+		t.Errorf("unexpected text:\n%v", text.String())
+	}
+
+	var buf bytes.Buffer
+	err = s.WriteJSON(&buf)
+	if err != nil {
+		t.Fatalf("WriteJSON failed.\n%v", err)
+	}
+	var decoded Stats
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil || decoded.TrackCount != 3 {
+		t.Errorf("unexpected JSON:\n%v", buf.String())
+	}
+
+	buf.Reset()
+	err = s.WriteCSV(&buf)
+	if err != nil {
+		t.Fatalf("WriteCSV failed.\n%v", err)
+	}
+	if !strings.Contains(buf.String(), "genre,Rock,2\n") {
+		t.Errorf("unexpected CSV:\n%v", buf.String())
+	}
+}
@@ -0,0 +1,278 @@
+package subsonic
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func ping(_ *Server, _ http.ResponseWriter, _ *http.Request) (*response, error) {
+	return &response{}, nil
+}
+
+func getArtists(s *Server, _ http.ResponseWriter, _ *http.Request) (*response, error) {
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &artists{IgnoredArticles: strings.Join(ignoredArticles, " "), Index: []artistIndex{}}
+	for _, ar := range idx.artists {
+		name := indexName(ar.name)
+		if n := len(res.Index); n == 0 || res.Index[n-1].Name != name {
+			res.Index = append(res.Index, artistIndex{Name: name})
+		}
+		last := &res.Index[len(res.Index)-1]
+		last.Artists = append(last.Artists, ar.artist())
+	}
+
+	return &response{Artists: res}, nil
+}
+
+func getAlbum(s *Server, _ http.ResponseWriter, r *http.Request) (*response, error) {
+	id, err := requiredParam(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	al, ok := idx.albumByID[id]
+	if !ok {
+		return nil, notFound("Album")
+	}
+
+	res := al.album(true, idx)
+	return &response{Album: &res}, nil
+}
+
+func getPlaylists(s *Server, _ http.ResponseWriter, _ *http.Request) (*response, error) {
+	pls, err := s.lib.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &playlists{Playlists: []playlist{}}
+	for _, p := range pls {
+		res.Playlists = append(res.Playlists, playlist{ID: p.PersistentID, Name: p.Name, SongCount: p.TrackCount})
+	}
+
+	return &response{Playlists: res}, nil
+}
+
+func getPlaylist(s *Server, _ http.ResponseWriter, r *http.Request) (*response, error) {
+	id, err := requiredParam(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	pls, err := s.lib.Playlists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range pls {
+		if p.PersistentID != id {
+			continue
+		}
+
+		tracks, err := s.lib.PlaylistTracks(id)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, err := s.index()
+		if err != nil {
+			return nil, err
+		}
+
+		res := &playlist{ID: id, Name: p.Name, SongCount: len(tracks), Entries: idx.children(tracks)}
+		for _, t := range tracks {
+			res.Duration += int(t.Duration)
+		}
+		return &response{Playlist: res}, nil
+	}
+
+	return nil, notFound("Playlist")
+}
+
+func intParam(r *http.Request, name string, defaultValue int) (int, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, &apiError{Code: errGeneric, Message: "Invalid parameter " + name + ": " + v}
+	}
+	return n, nil
+}
+
+// page returns the range of a result, from the parameters named by prefix with "Count" and "Offset".
+func page(r *http.Request, prefix string, total int) (int, int, error) {
+	count, err := intParam(r, prefix+"Count", 20)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offset, err := intParam(r, prefix+"Offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if offset > total {
+		offset = total
+	}
+	end := offset + count
+	if end > total {
+		end = total
+	}
+	return offset, end, nil
+}
+
+// matchWords reports whether s contains all the words, ignoring case.
+func matchWords(s string, words []string) bool {
+	s = strings.ToLower(s)
+	for _, word := range words {
+		if !strings.Contains(s, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// search3 searches artists, albums and songs by words.
+// An empty query, which clients also send as `""`, matches everything so that they can read the whole library.
+func search3(s *Server, _ http.ResponseWriter, r *http.Request) (*response, error) {
+	query := strings.Trim(r.FormValue("query"), `"`)
+	words := strings.Fields(strings.ToLower(query))
+
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &searchResult3{Artists: []artist{}, Albums: []album{}, Songs: []child{}}
+
+	var artists []*artistEntry
+	for _, ar := range idx.artists {
+		if matchWords(ar.name, words) {
+			artists = append(artists, ar)
+		}
+	}
+	start, end, err := page(r, "artist", len(artists))
+	if err != nil {
+		return nil, err
+	}
+	for _, ar := range artists[start:end] {
+		res.Artists = append(res.Artists, ar.artist())
+	}
+
+	var albums []*albumEntry
+	for _, al := range idx.albums {
+		if matchWords(al.name, words) {
+			albums = append(albums, al)
+		}
+	}
+	start, end, err = page(r, "album", len(albums))
+	if err != nil {
+		return nil, err
+	}
+	for _, al := range albums[start:end] {
+		res.Albums = append(res.Albums, al.album(false, idx))
+	}
+
+	var songs []*Track
+	for _, t := range idx.tracks {
+		if matchWords(t.Name+"\n"+t.Artist+"\n"+t.Album, words) {
+			songs = append(songs, t)
+		}
+	}
+	start, end, err = page(r, "song", len(songs))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range songs[start:end] {
+		res.Songs = append(res.Songs, idx.child(t))
+	}
+
+	return &response{SearchResult3: res}, nil
+}
+
+// getCoverArt writes the artwork of a track, or of the first track of an album.
+func getCoverArt(s *Server, w http.ResponseWriter, r *http.Request) (*response, error) {
+	id, err := requiredParam(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(id, "al-") {
+		al, ok := idx.albumByID[id]
+		if !ok {
+			return nil, notFound("Cover art")
+		}
+		id = al.tracks[0].PersistentID
+	}
+	// only the IDs of the library are passed on, the library may write them into a script.
+	if _, ok := idx.trackByID[id]; !ok {
+		return nil, notFound("Cover art")
+	}
+
+	a, err := s.lib.Artwork(id)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(a.Data)))
+	// the headers are sent already, a failed write cannot be reported.
+	w.Write(a.Data)
+	return nil, nil
+}
+
+// stream writes the file of a track as is, with support of range requests.
+func stream(s *Server, w http.ResponseWriter, r *http.Request) (*response, error) {
+	id, err := requiredParam(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := idx.trackByID[id]
+	if !ok || t.Location == "" {
+		return nil, notFound("Song")
+	}
+
+	f, err := os.Open(t.Location)
+	if os.IsNotExist(err) {
+		return nil, notFound("Song file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", contentType(t.Location))
+	http.ServeContent(w, r, filepath.Base(t.Location), info.ModTime(), f)
+	return nil, nil
+}
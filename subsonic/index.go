@@ -0,0 +1,223 @@
+package subsonic
+
+import (
+	"fmt"
+	"hash/fnv"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	unknownArtist = "Unknown Artist"
+	unknownAlbum  = "Unknown Album"
+)
+
+// ignoredArticles are skipped when artists are sorted and indexed.
+var ignoredArticles = []string{"The", "El", "La", "Los", "Las", "Le", "Les"}
+
+var contentTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".m4b":  "audio/mp4",
+	".m4p":  "audio/mp4",
+	".aac":  "audio/aac",
+	".aif":  "audio/aiff",
+	".aiff": "audio/aiff",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".ogg":  "audio/ogg",
+}
+
+func contentType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if t, ok := contentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// makeID returns a stable ID for the names, ignoring case.
+func makeID(prefix string, names ...string) string {
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(strings.ToLower(name)))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%v-%016x", prefix, h.Sum64())
+}
+
+func sortName(name string) string {
+	for _, article := range ignoredArticles {
+		if len(name) > len(article)+1 && strings.EqualFold(name[:len(article)+1], article+" ") {
+			return name[len(article)+1:]
+		}
+	}
+	return name
+}
+
+// indexName returns the initial under which an artist is listed, "#" for the ones not starting with a letter.
+func indexName(name string) string {
+	r, _ := utf8.DecodeRuneInString(strings.ToUpper(sortName(name)))
+	if !unicode.IsLetter(r) {
+		return "#"
+	}
+	return string(r)
+}
+
+type albumEntry struct {
+	id       string
+	name     string
+	artist   *artistEntry
+	tracks   []*Track
+	duration float64
+	year     int
+	genre    string
+}
+
+type artistEntry struct {
+	id     string
+	name   string
+	albums []*albumEntry
+}
+
+// index groups the tracks of the library by artist and album.
+type index struct {
+	built   time.Time
+	tracks  []*Track
+	artists []*artistEntry
+	albums  []*albumEntry
+
+	trackByID  map[string]*Track
+	albumByID  map[string]*albumEntry
+	albumOf    map[*Track]*albumEntry
+	artistByID map[string]*artistEntry
+}
+
+func newIndex(tracks []Track) *index {
+	idx := &index{
+		built:      time.Now(),
+		trackByID:  map[string]*Track{},
+		albumByID:  map[string]*albumEntry{},
+		albumOf:    map[*Track]*albumEntry{},
+		artistByID: map[string]*artistEntry{},
+	}
+
+	for i := range tracks {
+		t := &tracks[i]
+		idx.tracks = append(idx.tracks, t)
+		idx.trackByID[t.PersistentID] = t
+
+		artistName := t.Artist
+		if artistName == "" {
+			artistName = unknownArtist
+		}
+		ar, ok := idx.artistByID[makeID("ar", artistName)]
+		if !ok {
+			ar = &artistEntry{id: makeID("ar", artistName), name: artistName}
+			idx.artistByID[ar.id] = ar
+			idx.artists = append(idx.artists, ar)
+		}
+
+		albumName := t.Album
+		if albumName == "" {
+			albumName = unknownAlbum
+		}
+		al, ok := idx.albumByID[makeID("al", artistName, albumName)]
+		if !ok {
+			al = &albumEntry{id: makeID("al", artistName, albumName), name: albumName, artist: ar}
+			idx.albumByID[al.id] = al
+			idx.albums = append(idx.albums, al)
+			ar.albums = append(ar.albums, al)
+		}
+
+		al.tracks = append(al.tracks, t)
+		al.duration += t.Duration
+		if al.year == 0 {
+			al.year = t.Year
+		}
+		if al.genre == "" {
+			al.genre = t.Genre
+		}
+		idx.albumOf[t] = al
+	}
+
+	sort.SliceStable(idx.artists, func(i, j int) bool {
+		return strings.ToLower(sortName(idx.artists[i].name)) < strings.ToLower(sortName(idx.artists[j].name))
+	})
+
+	return idx
+}
+
+func (idx *index) child(t *Track) child {
+	al := idx.albumOf[t]
+	c := child{
+		ID:       t.PersistentID,
+		Parent:   al.id,
+		Title:    t.Name,
+		Album:    t.Album,
+		Artist:   t.Artist,
+		Year:     t.Year,
+		Genre:    t.Genre,
+		CoverArt: t.PersistentID,
+		Size:     t.Size,
+		Duration: int(t.Duration),
+		BitRate:  t.BitRate,
+		AlbumID:  al.id,
+		ArtistID: al.artist.id,
+		Type:     "music",
+	}
+	if t.Location != "" {
+		c.ContentType = contentType(t.Location)
+		c.Suffix = strings.TrimPrefix(strings.ToLower(filepath.Ext(t.Location)), ".")
+	}
+
+	return c
+}
+
+// children returns the songs of tracks, the ones missing from the index are described without album.
+func (idx *index) children(tracks []Track) []child {
+	children := make([]child, 0, len(tracks))
+	for i := range tracks {
+		t, ok := idx.trackByID[tracks[i].PersistentID]
+		if !ok {
+			children = append(children, child{ID: tracks[i].PersistentID, Title: tracks[i].Name, Artist: tracks[i].Artist, Duration: int(tracks[i].Duration), Type: "music"})
+			continue
+		}
+		children = append(children, idx.child(t))
+	}
+	return children
+}
+
+func (a *albumEntry) album(withSongs bool, idx *index) album {
+	al := album{
+		ID:        a.id,
+		Name:      a.name,
+		Artist:    a.artist.name,
+		ArtistID:  a.artist.id,
+		CoverArt:  a.id,
+		SongCount: len(a.tracks),
+		Duration:  int(a.duration),
+		Year:      a.year,
+		Genre:     a.genre,
+	}
+
+	if withSongs {
+		for _, t := range a.tracks {
+			al.Songs = append(al.Songs, idx.child(t))
+		}
+	}
+
+	return al
+}
+
+func (a *artistEntry) artist() artist {
+	return artist{ID: a.id, Name: a.name, AlbumCount: len(a.albums)}
+}
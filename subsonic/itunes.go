@@ -0,0 +1,172 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package subsonic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yaegaki/itunes-app-interface"
+)
+
+type itunesLibrary struct {
+	// iTunes handles one request at a time.
+	mu sync.Mutex
+	it *itunes.Itunes
+}
+
+// NewItunesLibrary returns the Library of an iTunes application.
+func NewItunesLibrary(it *itunes.Itunes) Library {
+	return &itunesLibrary{it: it}
+}
+
+// convertError wraps the not found errors of itunes in ErrNotFound.
+func convertError(err error) error {
+	var notFound *itunes.NotFoundError
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	return err
+}
+
+// track is a track of the local or the remote iTunes.
+type track interface {
+	PersistentID() string
+	Name() string
+	Artist() string
+	Album() string
+	Genre() string
+	Year() int
+	Duration() time.Duration
+	BitRate() int
+	Size() int64
+	IsFileTrack() bool
+	Location() string
+}
+
+func newTrack(t track) Track {
+	track := Track{
+		PersistentID: t.PersistentID(),
+		Name:         t.Name(),
+		Artist:       t.Artist(),
+		Album:        t.Album(),
+		Genre:        t.Genre(),
+		Year:         t.Year(),
+		Duration:     t.Duration().Seconds(),
+		BitRate:      t.BitRate(),
+		Size:         t.Size(),
+	}
+	// the location of a URL track cannot be streamed.
+	if t.IsFileTrack() {
+		track.Location = t.Location()
+	}
+
+	return track
+}
+
+func collectTracks(output chan *itunes.Track) []Track {
+	var tracks []Track
+	for t := range output {
+		tracks = append(tracks, newTrack(t))
+		t.Close()
+	}
+	return tracks
+}
+
+func (l *itunesLibrary) Tracks() ([]Track, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	output, err := l.it.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	return collectTracks(output), nil
+}
+
+func (l *itunesLibrary) Playlists() ([]Playlist, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count, err := l.it.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := l.it.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		trackCount, err := pl.TrackCount()
+		if err != nil {
+			pl.Close()
+			return nil, err
+		}
+
+		playlists = append(playlists, Playlist{PersistentID: pl.PersistentID(), Name: pl.Name(), TrackCount: trackCount})
+		pl.Close()
+	}
+
+	return playlists, nil
+}
+
+func (l *itunesLibrary) PlaylistTracks(playlistID string) ([]Track, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pl, err := l.it.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer pl.Close()
+
+	output, err := pl.GetTracks()
+	if err != nil {
+		return nil, err
+	}
+
+	return collectTracks(output), nil
+}
+
+func (l *itunesLibrary) Artwork(trackID string) (*Artwork, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, err := l.it.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer t.Close()
+
+	artworks, err := t.GetArtworks()
+	if err != nil {
+		return nil, err
+	}
+
+	var a *Artwork
+	for artwork := range artworks {
+		if a == nil && err == nil {
+			var buf bytes.Buffer
+			_, err = artwork.WriteTo(&buf)
+			a = &Artwork{ContentType: artwork.Format().MIMEType(), Data: buf.Bytes()}
+		}
+		artwork.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, convertError(&itunes.NotFoundError{Kind: "artwork", ID: trackID})
+	}
+
+	return a, nil
+}
@@ -0,0 +1,110 @@
+//go:build darwin || windows || linux
+// +build darwin windows linux
+
+package subsonic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/yaegaki/itunes-app-interface/remote"
+)
+
+type remoteLibrary struct {
+	c *remote.Client
+}
+
+// NewRemoteLibrary returns the Library of iTunes on another machine, served by the remote package.
+// The locations are paths on that machine, mount its music folder at the same path to stream the tracks.
+func NewRemoteLibrary(c *remote.Client) Library {
+	return &remoteLibrary{c: c}
+}
+
+// convertRemoteError wraps the not found errors of remote in ErrNotFound.
+func convertRemoteError(err error) error {
+	if errors.Is(err, remote.ErrNotFound) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+
+	return err
+}
+
+func collectRemoteTracks(each func(fn func(*remote.Track) error) error) ([]Track, error) {
+	var tracks []Track
+	err := each(func(t *remote.Track) error {
+		tracks = append(tracks, newTrack(t))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (l *remoteLibrary) Tracks() ([]Track, error) {
+	return collectRemoteTracks(l.c.EachTrack)
+}
+
+func (l *remoteLibrary) Playlists() ([]Playlist, error) {
+	count, err := l.c.PlaylistCount()
+	if err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, count)
+	for i := 0; i < count; i++ {
+		pl, err := l.c.GetPlaylist(i)
+		if err != nil {
+			return nil, err
+		}
+
+		trackCount, err := pl.TrackCount()
+		if err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, Playlist{PersistentID: pl.PersistentID(), Name: pl.Name(), TrackCount: trackCount})
+	}
+
+	return playlists, nil
+}
+
+func (l *remoteLibrary) PlaylistTracks(playlistID string) ([]Track, error) {
+	pl, err := l.c.FindPlaylistByPersistentID(playlistID)
+	if err != nil {
+		return nil, convertRemoteError(err)
+	}
+
+	return collectRemoteTracks(pl.EachTrack)
+}
+
+func (l *remoteLibrary) Artwork(trackID string) (*Artwork, error) {
+	t, err := l.c.FindTrackByPersistentID(trackID)
+	if err != nil {
+		return nil, convertRemoteError(err)
+	}
+
+	artworks, err := t.GetArtworks()
+	if err != nil {
+		return nil, err
+	}
+
+	var a *Artwork
+	for artwork := range artworks {
+		if a == nil && err == nil {
+			var buf bytes.Buffer
+			_, err = artwork.WriteTo(&buf)
+			a = &Artwork{ContentType: artwork.Format().MIMEType(), Data: buf.Bytes()}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, fmt.Errorf("%w: artwork:%v", ErrNotFound, trackID)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,315 @@
+// Package subsonic serves the library over the core of the Subsonic REST API,
+// so that Subsonic clients can browse it and stream its files.
+//
+// The implemented endpoints are ping, getArtists, getAlbum, getPlaylists, getPlaylist, search3,
+// getCoverArt and stream, in both the XML and the JSON format.
+// Artists and albums are identified by hashes of their names, tracks and playlists by their persistent IDs.
+package subsonic
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is wrapped by the Library errors for a missing playlist or artwork.
+var ErrNotFound = errors.New("not found")
+
+type Track struct {
+	PersistentID string
+	Name         string
+	Artist       string
+	Album        string
+	Genre        string
+	Year         int
+	// Duration is in seconds.
+	Duration float64
+	// BitRate is in kbps.
+	BitRate int
+	Size    int64
+	// Location is the path of the file, empty if the track is not a file.
+	Location string
+}
+
+type Playlist struct {
+	PersistentID string
+	Name         string
+	TrackCount   int
+}
+
+type Artwork struct {
+	ContentType string
+	Data        []byte
+}
+
+// Library is what the server exposes, NewItunesLibrary implements it for iTunes.
+type Library interface {
+	Tracks() ([]Track, error)
+	Playlists() ([]Playlist, error)
+	PlaylistTracks(playlistID string) ([]Track, error)
+	// Artwork returns the first artwork of the track.
+	Artwork(trackID string) (*Artwork, error)
+}
+
+const (
+	apiVersion = "1.16.1"
+	xmlns      = "http://subsonic.org/restapi"
+	// the tracks are read again from the library at this interval.
+	refreshInterval = 5 * time.Minute
+)
+
+// the error codes of the API.
+const (
+	errGeneric      = 0
+	errMissingParam = 10
+	errWrongAuth    = 40
+	errNotFound     = 70
+)
+
+type apiError struct {
+	Code    int    `xml:"code,attr" json:"code"`
+	Message string `xml:"message,attr" json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func notFound(kind string) error {
+	return &apiError{Code: errNotFound, Message: kind + " not found"}
+}
+
+// response is the subsonic-response element, a handler fills one of the payloads.
+type response struct {
+	XMLName xml.Name `xml:"subsonic-response" json:"-"`
+	Xmlns   string   `xml:"xmlns,attr" json:"-"`
+	Status  string   `xml:"status,attr" json:"status"`
+	Version string   `xml:"version,attr" json:"version"`
+
+	Error         *apiError      `xml:"error,omitempty" json:"error,omitempty"`
+	Artists       *artists       `xml:"artists,omitempty" json:"artists,omitempty"`
+	Album         *album         `xml:"album,omitempty" json:"album,omitempty"`
+	Playlists     *playlists     `xml:"playlists,omitempty" json:"playlists,omitempty"`
+	Playlist      *playlist      `xml:"playlist,omitempty" json:"playlist,omitempty"`
+	SearchResult3 *searchResult3 `xml:"searchResult3,omitempty" json:"searchResult3,omitempty"`
+}
+
+type child struct {
+	ID          string `xml:"id,attr" json:"id"`
+	Parent      string `xml:"parent,attr,omitempty" json:"parent,omitempty"`
+	IsDir       bool   `xml:"isDir,attr" json:"isDir"`
+	Title       string `xml:"title,attr" json:"title"`
+	Album       string `xml:"album,attr,omitempty" json:"album,omitempty"`
+	Artist      string `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	Year        int    `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Genre       string `xml:"genre,attr,omitempty" json:"genre,omitempty"`
+	CoverArt    string `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	Size        int64  `xml:"size,attr,omitempty" json:"size,omitempty"`
+	ContentType string `xml:"contentType,attr,omitempty" json:"contentType,omitempty"`
+	Suffix      string `xml:"suffix,attr,omitempty" json:"suffix,omitempty"`
+	Duration    int    `xml:"duration,attr" json:"duration"`
+	BitRate     int    `xml:"bitRate,attr,omitempty" json:"bitRate,omitempty"`
+	AlbumID     string `xml:"albumId,attr,omitempty" json:"albumId,omitempty"`
+	ArtistID    string `xml:"artistId,attr,omitempty" json:"artistId,omitempty"`
+	Type        string `xml:"type,attr" json:"type"`
+}
+
+type artist struct {
+	ID         string `xml:"id,attr" json:"id"`
+	Name       string `xml:"name,attr" json:"name"`
+	AlbumCount int    `xml:"albumCount,attr" json:"albumCount"`
+}
+
+type artistIndex struct {
+	Name    string   `xml:"name,attr" json:"name"`
+	Artists []artist `xml:"artist" json:"artist"`
+}
+
+type artists struct {
+	IgnoredArticles string        `xml:"ignoredArticles,attr" json:"ignoredArticles"`
+	Index           []artistIndex `xml:"index" json:"index"`
+}
+
+type album struct {
+	ID        string  `xml:"id,attr" json:"id"`
+	Name      string  `xml:"name,attr" json:"name"`
+	Artist    string  `xml:"artist,attr" json:"artist"`
+	ArtistID  string  `xml:"artistId,attr" json:"artistId"`
+	CoverArt  string  `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	SongCount int     `xml:"songCount,attr" json:"songCount"`
+	Duration  int     `xml:"duration,attr" json:"duration"`
+	Year      int     `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Genre     string  `xml:"genre,attr,omitempty" json:"genre,omitempty"`
+	Songs     []child `xml:"song,omitempty" json:"song,omitempty"`
+}
+
+type playlist struct {
+	ID        string  `xml:"id,attr" json:"id"`
+	Name      string  `xml:"name,attr" json:"name"`
+	SongCount int     `xml:"songCount,attr" json:"songCount"`
+	Duration  int     `xml:"duration,attr" json:"duration"`
+	Entries   []child `xml:"entry,omitempty" json:"entry,omitempty"`
+}
+
+type playlists struct {
+	Playlists []playlist `xml:"playlist" json:"playlist"`
+}
+
+type searchResult3 struct {
+	Artists []artist `xml:"artist" json:"artist"`
+	Albums  []album  `xml:"album" json:"album"`
+	Songs   []child  `xml:"song" json:"song"`
+}
+
+type Server struct {
+	lib      Library
+	username string
+	password string
+
+	mu      sync.Mutex
+	idx     *index
+	refresh time.Duration
+}
+
+// New returns the handler of the API, clients authenticate as username with password.
+// Mount it at the root, the endpoints are under /rest.
+func New(lib Library, username, password string) *Server {
+	return &Server{lib: lib, username: username, password: password, refresh: refreshInterval}
+}
+
+// index returns the index of the library, reading the tracks again if it is old.
+func (s *Server) index() (*index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idx != nil && time.Since(s.idx.built) < s.refresh {
+		return s.idx, nil
+	}
+
+	tracks, err := s.lib.Tracks()
+	if err != nil {
+		return nil, err
+	}
+
+	s.idx = newIndex(tracks)
+	return s.idx, nil
+}
+
+// authenticate checks the credentials of the request, either the password "p",
+// optionally hex-encoded with the prefix "enc:", or the token "t" which is md5(password + salt "s").
+func (s *Server) authenticate(r *http.Request) error {
+	u := r.FormValue("u")
+	if u == "" {
+		return &apiError{Code: errMissingParam, Message: "Required parameter is missing: u"}
+	}
+
+	var ok bool
+	switch {
+	case r.FormValue("t") != "" && r.FormValue("s") != "":
+		sum := md5.Sum([]byte(s.password + r.FormValue("s")))
+		ok = subtle.ConstantTimeCompare([]byte(strings.ToLower(r.FormValue("t"))), []byte(hex.EncodeToString(sum[:]))) == 1
+	case r.FormValue("p") != "":
+		p := r.FormValue("p")
+		if strings.HasPrefix(p, "enc:") {
+			decoded, err := hex.DecodeString(p[len("enc:"):])
+			if err != nil {
+				break
+			}
+			p = string(decoded)
+		}
+		ok = subtle.ConstantTimeCompare([]byte(p), []byte(s.password)) == 1
+	default:
+		return &apiError{Code: errMissingParam, Message: "Required parameter is missing: p or t and s"}
+	}
+
+	if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(s.username)) != 1 {
+		return &apiError{Code: errWrongAuth, Message: "Wrong username or password"}
+	}
+
+	return nil
+}
+
+// handlerFunc returns the response to encode, or nil if it has written the response itself.
+type handlerFunc func(s *Server, w http.ResponseWriter, r *http.Request) (*response, error)
+
+var handlers map[string]handlerFunc
+
+func init() {
+	handlers = map[string]handlerFunc{
+		"ping":         ping,
+		"getArtists":   getArtists,
+		"getAlbum":     getAlbum,
+		"getPlaylists": getPlaylists,
+		"getPlaylist":  getPlaylist,
+		"search3":      search3,
+		"getCoverArt":  getCoverArt,
+		"stream":       stream,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/"), ".view")
+	h, ok := handlers[name]
+	if !ok || !strings.HasPrefix(r.URL.Path, "/rest/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	err := s.authenticate(r)
+	if err != nil {
+		writeResponse(w, r, nil, err)
+		return
+	}
+
+	res, err := h(s, w, r)
+	if res != nil || err != nil {
+		writeResponse(w, r, res, err)
+	}
+}
+
+// writeResponse writes res, or err, in the format of the parameter "f".
+// Errors are reported with the status 200 like every response of the API.
+func writeResponse(w http.ResponseWriter, r *http.Request, res *response, err error) {
+	if res == nil {
+		res = &response{}
+	}
+	res.Xmlns = xmlns
+	res.Status = "ok"
+	res.Version = apiVersion
+	if err != nil {
+		var ae *apiError
+		switch {
+		case errors.As(err, &ae):
+		case errors.Is(err, ErrNotFound):
+			ae = &apiError{Code: errNotFound, Message: err.Error()}
+		default:
+			ae = &apiError{Code: errGeneric, Message: err.Error()}
+		}
+		*res = response{Xmlns: xmlns, Status: "failed", Version: apiVersion, Error: ae}
+	}
+
+	if r.FormValue("f") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]*response{"subsonic-response": res})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(res)
+}
+
+func requiredParam(r *http.Request, name string) (string, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return "", &apiError{Code: errMissingParam, Message: "Required parameter is missing: " + name}
+	}
+	return v, nil
+}
@@ -0,0 +1,231 @@
+package subsonic
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeLibrary struct {
+	tracks []Track
+	// the IDs passed to Artwork.
+	artworkIDs []string
+}
+
+func (l *fakeLibrary) Tracks() ([]Track, error) {
+	return l.tracks, nil
+}
+
+func (l *fakeLibrary) Playlists() ([]Playlist, error) {
+	return []Playlist{{PersistentID: "P1", Name: "Mix", TrackCount: 2}}, nil
+}
+
+func (l *fakeLibrary) PlaylistTracks(playlistID string) ([]Track, error) {
+	return []Track{l.tracks[2], l.tracks[0]}, nil
+}
+
+func (l *fakeLibrary) Artwork(trackID string) (*Artwork, error) {
+	l.artworkIDs = append(l.artworkIDs, trackID)
+	if trackID != "T1" {
+		return nil, fmt.Errorf("%w: artwork:%v", ErrNotFound, trackID)
+	}
+	return &Artwork{ContentType: "image/png", Data: []byte("\x89PNG")}, nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	s, _ := newTestServerWithLibrary(t)
+	return s
+}
+
+func newTestServerWithLibrary(t *testing.T) (*httptest.Server, *fakeLibrary) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "help.mp3")
+	err := os.WriteFile(path, []byte("0123456789"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lib := &fakeLibrary{tracks: []Track{
+		{PersistentID: "T1", Name: "Help!", Artist: "The Beatles", Album: "Help!", Year: 1965, Duration: 138, Location: path, Size: 10},
+		{PersistentID: "T2", Name: "Yesterday", Artist: "The Beatles", Album: "Help!", Duration: 125},
+		{PersistentID: "T3", Name: "Help Me", Artist: "Joni Mitchell", Album: "Court and Spark", Duration: 222},
+		{PersistentID: "T4", Name: "Intro", Duration: 60},
+	}}
+
+	s := httptest.NewServer(New(lib, "alice", "sesame"))
+	t.Cleanup(s.Close)
+	return s, lib
+}
+
+func get(t *testing.T, s *httptest.Server, endpoint string, params url.Values) (*http.Response, string) {
+	res, err := http.Get(s.URL + "/rest/" + endpoint + "?" + params.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(data)
+}
+
+func params(kv ...string) url.Values {
+	v := url.Values{"u": {"alice"}, "p": {"sesame"}, "f": {"json"}}
+	for i := 0; i < len(kv); i += 2 {
+		v.Set(kv[i], kv[i+1])
+	}
+	return v
+}
+
+type envelope struct {
+	Response json.RawMessage `json:"subsonic-response"`
+}
+
+func call(t *testing.T, s *httptest.Server, endpoint string, params url.Values) string {
+	_, body := get(t, s, endpoint, params)
+	var e envelope
+	err := json.Unmarshal([]byte(body), &e)
+	if err != nil {
+		t.Fatalf("%v: invalid response %q", endpoint, body)
+	}
+	return string(e.Response)
+}
+
+func TestAuth(t *testing.T) {
+	s := newTestServer(t)
+
+	sum := md5.Sum([]byte("sesame" + "c19b2d"))
+	tests := []struct {
+		params url.Values
+		expect string
+	}{
+		{url.Values{"f": {"json"}}, `"error":{"code":10,`},
+		{url.Values{"u": {"alice"}, "f": {"json"}}, `"error":{"code":10,`},
+		{url.Values{"u": {"alice"}, "p": {"wrong"}, "f": {"json"}}, `"error":{"code":40,`},
+		{url.Values{"u": {"bob"}, "p": {"sesame"}, "f": {"json"}}, `"error":{"code":40,`},
+		{url.Values{"u": {"alice"}, "p": {"sesame"}, "f": {"json"}}, `{"status":"ok","version":"1.16.1"}`},
+		{url.Values{"u": {"alice"}, "p": {"enc:" + hex.EncodeToString([]byte("sesame"))}, "f": {"json"}}, `"status":"ok"`},
+		{url.Values{"u": {"alice"}, "t": {hex.EncodeToString(sum[:])}, "s": {"c19b2d"}, "f": {"json"}}, `"status":"ok"`},
+		{url.Values{"u": {"alice"}, "t": {hex.EncodeToString(sum[:])}, "s": {"other"}, "f": {"json"}}, `"error":{"code":40,`},
+	}
+
+	for _, test := range tests {
+		res := call(t, s, "ping.view", test.params)
+		if !strings.Contains(res, test.expect) {
+			t.Errorf("%v: expect %v, but %v", test.params, test.expect, res)
+		}
+	}
+
+	res, body := get(t, s, "ping", url.Values{"u": {"alice"}, "p": {"sesame"}})
+	expect := `<subsonic-response xmlns="http://subsonic.org/restapi" status="ok" version="1.16.1"></subsonic-response>`
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/xml") || !strings.Contains(body, expect) {
+		t.Errorf("unexpected XML: %v", body)
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	s := newTestServer(t)
+
+	beatles := makeID("ar", "The Beatles")
+	help := makeID("al", "The Beatles", "Help!")
+	tests := []struct {
+		endpoint string
+		params   url.Values
+		contains []string
+	}{
+		{"getArtists", params(), []string{
+			`"ignoredArticles":"The El La Los Las Le Les"`,
+			`{"name":"B","artist":[{"id":"` + beatles + `","name":"The Beatles","albumCount":1}]},{"name":"J"`,
+			`{"name":"U","artist":[{"id":"` + makeID("ar", unknownArtist) + `","name":"Unknown Artist","albumCount":1}]}`,
+		}},
+		{"getAlbum", params("id", help), []string{
+			`"album":{"id":"` + help + `","name":"Help!","artist":"The Beatles","artistId":"` + beatles + `","coverArt":"` + help + `","songCount":2,"duration":263,"year":1965`,
+			`"song":[{"id":"T1","parent":"` + help + `","isDir":false,"title":"Help!"`,
+			`"contentType":"audio/mpeg","suffix":"mp3","duration":138`,
+			`{"id":"T2"`,
+		}},
+		{"getAlbum", params("id", "al-0"), []string{`"error":{"code":70,"message":"Album not found"}`}},
+		{"getAlbum", params(), []string{`"error":{"code":10,"message":"Required parameter is missing: id"}`}},
+		{"getPlaylists", params(), []string{`"playlists":{"playlist":[{"id":"P1","name":"Mix","songCount":2,"duration":0}]}`}},
+		{"getPlaylist", params("id", "P1"), []string{`"playlist":{"id":"P1","name":"Mix","songCount":2,"duration":360,"entry":[{"id":"T3"`, `{"id":"T1"`}},
+		{"getPlaylist", params("id", "P2"), []string{`"code":70`}},
+		{"search3", params("query", "help"), []string{
+			`"artist":[]`,
+			`"album":[{"id":"` + help + `"`,
+			`"song":[{"id":"T1"`, `{"id":"T3"`,
+		}},
+		{"search3", params("query", "beatles yes"), []string{`"song":[{"id":"T2"`}},
+		{"search3", params("query", `""`, "songCount", "1", "songOffset", "3", "artistCount", "0"), []string{`"artist":[]`, `"song":[{"id":"T4"`}},
+		{"search3", params("query", "x", "songCount", "-1"), []string{`"error":{"code":0,`}},
+	}
+
+	for _, test := range tests {
+		res := call(t, s, test.endpoint, test.params)
+		for _, c := range test.contains {
+			if !strings.Contains(res, c) {
+				t.Errorf("%v %v: expect %v, but %v", test.endpoint, test.params, c, res)
+			}
+		}
+	}
+}
+
+func TestMedia(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, id := range []string{"T1", makeID("al", "The Beatles", "Help!")} {
+		res, body := get(t, s, "getCoverArt", params("id", id))
+		if res.Header.Get("Content-Type") != "image/png" || body != "\x89PNG" {
+			t.Errorf("%v: unexpected cover art %v %q", id, res.Header, body)
+		}
+	}
+
+	res := call(t, s, "getCoverArt", params("id", "T2"))
+	if !strings.Contains(res, `"code":70`) {
+		t.Errorf("expect not found, but %v", res)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/rest/stream?"+params("id", "T1").Encode(), nil)
+	req.Header.Set("Range", "bytes=2-5")
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	if r.StatusCode != http.StatusPartialContent || r.Header.Get("Content-Type") != "audio/mpeg" || string(data) != "2345" {
+		t.Errorf("unexpected stream: %v %v %q", r.StatusCode, r.Header, data)
+	}
+
+	res = call(t, s, "stream", params("id", "T2"))
+	if !strings.Contains(res, `"error":{"code":70,"message":"Song not found"}`) {
+		t.Errorf("expect not found, but %v", res)
+	}
+}
+
+func TestMalformedID(t *testing.T) {
+	s, lib := newTestServerWithLibrary(t)
+
+	id := `T1"));app.quit();(("`
+	for _, endpoint := range []string{"getCoverArt", "getPlaylist", "stream"} {
+		res := call(t, s, endpoint, params("id", id))
+		if !strings.Contains(res, `"code":70`) {
+			t.Errorf("%v: expect not found, but %v", endpoint, res)
+		}
+	}
+
+	if len(lib.artworkIDs) != 0 {
+		t.Errorf("expect the library not to be asked, but %v", lib.artworkIDs)
+	}
+}
@@ -0,0 +1,139 @@
+package tag
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+func readFLAC(r io.ReadSeeker) (*Tag, error) {
+	_, err := r.Seek(4, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tag{Format: FLAC}
+	header := make([]byte, 4)
+	for {
+		_, err = io.ReadFull(r, header)
+		if err != nil {
+			return nil, err
+		}
+
+		last := header[0]&0x80 != 0
+		typ := header[0] & 0x7F
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		switch typ {
+		case flacStreamInfo, flacVorbisComment:
+			b := make([]byte, size)
+			_, err = io.ReadFull(r, b)
+			if err != nil {
+				return nil, err
+			}
+
+			if typ == flacStreamInfo {
+				err = parseStreamInfo(t, b)
+			} else {
+				err = parseVorbisComment(t, b)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			_, err = r.Seek(size, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if last {
+			return t, nil
+		}
+	}
+}
+
+func parseStreamInfo(t *Tag, b []byte) error {
+	if len(b) < 18 {
+		return errors.New("tag: invalid FLAC STREAMINFO")
+	}
+
+	// 20 bits sample rate, 3 bits channels, 5 bits bits per sample and 36 bits total samples.
+	v := binary.BigEndian.Uint64(b[10:18])
+	sampleRate := v >> 44
+	samples := v & (1<<36 - 1)
+	if sampleRate != 0 {
+		t.Duration = time.Duration(samples * uint64(time.Second) / sampleRate)
+	}
+
+	return nil
+}
+
+func parseVorbisComment(t *Tag, b []byte) error {
+	next := func() (string, error) {
+		if len(b) < 4 {
+			return "", errors.New("tag: invalid FLAC VORBIS_COMMENT")
+		}
+
+		n := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		if uint64(n) > uint64(len(b)) {
+			return "", errors.New("tag: invalid FLAC VORBIS_COMMENT")
+		}
+
+		s := string(b[:n])
+		b = b[n:]
+		return s, nil
+	}
+
+	// vendor
+	_, err := next()
+	if err != nil {
+		return err
+	}
+
+	if len(b) < 4 {
+		return errors.New("tag: invalid FLAC VORBIS_COMMENT")
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+
+	for i := uint32(0); i < count; i++ {
+		comment, err := next()
+		if err != nil {
+			return err
+		}
+
+		sep := strings.IndexByte(comment, '=')
+		if sep < 0 {
+			continue
+		}
+
+		value := comment[sep+1:]
+		switch strings.ToUpper(comment[:sep]) {
+		case "TITLE":
+			t.Title = value
+		case "ARTIST":
+			t.Artist = value
+		case "ALBUM":
+			t.Album = value
+		case "ALBUMARTIST":
+			t.AlbumArtist = value
+		case "GENRE":
+			t.Genre = value
+		case "DATE":
+			t.Year = parseNumber(value)
+		case "TRACKNUMBER":
+			t.Track = parseNumber(value)
+		}
+	}
+
+	return nil
+}
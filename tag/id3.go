@@ -0,0 +1,387 @@
+package tag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const id3v1Size = 128
+
+func syncsafe(b []byte) int {
+	n := 0
+	for _, v := range b {
+		n = n<<7 | int(v&0x7F)
+	}
+
+	return n
+}
+
+// removeUnsync reverts the unsynchronisation scheme (0xFF 0x00 -> 0xFF).
+func removeUnsync(b []byte) []byte {
+	return bytes.Replace(b, []byte{0xFF, 0x00}, []byte{0xFF}, -1)
+}
+
+func readMP3(r io.ReadSeeker) (*Tag, error) {
+	t := &Tag{Format: MP3}
+
+	header := make([]byte, 10)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	audioStart := int64(0)
+	if string(header[:3]) == "ID3" {
+		size := syncsafe(header[6:10])
+		audioStart = int64(10 + size)
+		if header[5]&0x10 != 0 {
+			// footer
+			audioStart += 10
+		}
+
+		body := make([]byte, size)
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			return nil, err
+		}
+
+		err = parseID3v2(t, header[3], header[5], body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = readID3v1(t, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.Duration == 0 {
+		t.Duration, err = mpegDuration(r, audioStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func readID3v1(t *Tag, r io.ReadSeeker) error {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	if end < id3v1Size {
+		return nil
+	}
+
+	_, err = r.Seek(end-id3v1Size, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	b := make([]byte, id3v1Size)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return err
+	}
+
+	if string(b[:3]) != "TAG" {
+		return nil
+	}
+
+	field := func(b []byte) string {
+		i := bytes.IndexByte(b, 0)
+		if i >= 0 {
+			b = b[:i]
+		}
+		return strings.TrimSpace(latin1(b))
+	}
+
+	t.Title = field(b[3:33])
+	t.Artist = field(b[33:63])
+	t.Album = field(b[63:93])
+	t.Year = parseNumber(field(b[93:97]))
+	// ID3v1.1 stores the track number in the last byte of the comment.
+	if b[125] == 0 && b[126] != 0 {
+		t.Track = int(b[126])
+	}
+	t.Genre = genre(int(b[127]))
+
+	return nil
+}
+
+func parseID3v2(t *Tag, version, flags byte, body []byte) error {
+	if version < 2 || 4 < version {
+		return errors.New("tag: unsupported ID3v2 version")
+	}
+
+	if flags&0x80 != 0 && version < 4 {
+		body = removeUnsync(body)
+	}
+
+	if flags&0x40 != 0 && version >= 3 && len(body) >= 4 {
+		// skip the extended header
+		var size int
+		if version == 4 {
+			size = syncsafe(body[:4])
+		} else {
+			size = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+		if size > len(body) {
+			return errors.New("tag: invalid ID3v2 extended header")
+		}
+		body = body[size:]
+	}
+
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	for len(body) >= headerSize && body[0] != 0 {
+		id := string(body[:idSize])
+		var size int
+		var frameFlags byte
+		switch version {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = body[9]
+		case 4:
+			size = syncsafe(body[4:8])
+			frameFlags = body[9]
+		}
+
+		body = body[headerSize:]
+		if size > len(body) {
+			break
+		}
+
+		data := body[:size]
+		body = body[size:]
+
+		if version == 4 {
+			if frameFlags&0x01 != 0 && len(data) >= 4 {
+				// data length indicator
+				data = data[4:]
+			}
+			if frameFlags&0x02 != 0 {
+				data = removeUnsync(data)
+			}
+		}
+
+		// compressed or encrypted frames are ignored.
+		if (version == 3 && frameFlags&0xC0 != 0) || (version == 4 && frameFlags&0x0C != 0) {
+			continue
+		}
+
+		if len(data) == 0 || id[0] != 'T' {
+			continue
+		}
+
+		text := decodeText(data)
+		switch id {
+		case "TIT2", "TT2":
+			t.Title = text
+		case "TPE1", "TP1":
+			t.Artist = text
+		case "TALB", "TAL":
+			t.Album = text
+		case "TPE2", "TP2":
+			t.AlbumArtist = text
+		case "TCON", "TCO":
+			t.Genre = id3Genre(text)
+		case "TYER", "TYE", "TDRC":
+			t.Year = parseNumber(text)
+		case "TRCK", "TRK":
+			t.Track = parseNumber(text)
+		case "TLEN", "TLE":
+			t.Duration = time.Duration(parseNumber(text)) * time.Millisecond
+		}
+	}
+
+	return nil
+}
+
+// id3Genre resolves references to ID3v1 genres like "(17)" or "17".
+func id3Genre(s string) string {
+	if strings.HasPrefix(s, "(") {
+		end := strings.IndexByte(s, ')')
+		if end > 0 {
+			if end+1 < len(s) {
+				return s[end+1:]
+			}
+			s = s[1:end]
+		}
+	}
+
+	if s != "" && strings.Trim(s, "0123456789") == "" {
+		return genre(parseNumber(s))
+	}
+
+	return s
+}
+
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, v := range b {
+		runes[i] = rune(v)
+	}
+
+	return string(runes)
+}
+
+func decodeUTF16(b []byte, order binary.ByteOrder) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, order.Uint16(b[i:]))
+	}
+
+	return string(utf16.Decode(u))
+}
+
+// decodeText decodes a text frame, which starts with an encoding byte.
+// only the first value is returned if the frame has several values.
+func decodeText(data []byte) string {
+	encoding, b := data[0], data[1:]
+
+	var s string
+	switch encoding {
+	case 1:
+		var order binary.ByteOrder = binary.LittleEndian
+		if len(b) >= 2 {
+			if b[0] == 0xFE && b[1] == 0xFF {
+				order = binary.BigEndian
+				b = b[2:]
+			} else if b[0] == 0xFF && b[1] == 0xFE {
+				b = b[2:]
+			}
+		}
+		s = decodeUTF16(b, order)
+	case 2:
+		s = decodeUTF16(b, binary.BigEndian)
+	case 3:
+		s = string(b)
+	default:
+		s = latin1(b)
+	}
+
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+
+	return strings.TrimSpace(s)
+}
+
+var (
+	mpegBitrates = [2][3][16]int{
+		// MPEG1: layer I, II, III
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		},
+		// MPEG2 and MPEG2.5: layer I, II, III
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		},
+	}
+	mpegSampleRates = [3]int{44100, 48000, 32000}
+)
+
+// mpegDuration reads the first MPEG audio frame after start.
+// the duration is taken from the Xing/Info or VBRI header and estimated from the bit rate otherwise.
+func mpegDuration(r io.ReadSeeker, start int64) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	// the first frame is looked for in the first 64KiB, padding is sometimes put after the tag.
+	b := make([]byte, 64*1024)
+	n, err := io.ReadFull(r, b)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return 0, err
+	}
+	b = b[:n]
+
+	for i := 0; i+4 <= len(b); i++ {
+		if b[i] != 0xFF || b[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+
+		version := (b[i+1] >> 3) & 0x03 // 0: MPEG2.5, 2: MPEG2, 3: MPEG1
+		layer := (b[i+1] >> 1) & 0x03   // 1: III, 2: II, 3: I
+		bitrateIndex := b[i+2] >> 4
+		sampleRateIndex := (b[i+2] >> 2) & 0x03
+		mono := b[i+3]>>6 == 0x03
+		if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+			continue
+		}
+
+		v := 0
+		if version != 3 {
+			v = 1
+		}
+		bitrate := mpegBitrates[v][3-layer][bitrateIndex] * 1000
+		sampleRate := mpegSampleRates[sampleRateIndex]
+		switch version {
+		case 2:
+			sampleRate /= 2
+		case 0:
+			sampleRate /= 4
+		}
+
+		samplesPerFrame := 1152
+		switch {
+		case layer == 3:
+			samplesPerFrame = 384
+		case layer == 1 && version != 3:
+			samplesPerFrame = 576
+		}
+
+		sideInfo := 32
+		switch {
+		case version == 3 && mono:
+			sideInfo = 17
+		case version != 3 && !mono:
+			sideInfo = 17
+		case version != 3 && mono:
+			sideInfo = 9
+		}
+
+		frame := b[i:]
+		frames := 0
+		if x := 4 + sideInfo; len(frame) >= x+12 && (string(frame[x:x+4]) == "Xing" || string(frame[x:x+4]) == "Info") {
+			if frame[x+7]&0x01 != 0 {
+				frames = int(binary.BigEndian.Uint32(frame[x+8:]))
+			}
+		} else if x := 4 + 32; len(frame) >= x+18 && string(frame[x:x+4]) == "VBRI" {
+			frames = int(binary.BigEndian.Uint32(frame[x+14:]))
+		}
+
+		if frames > 0 {
+			return time.Duration(int64(frames) * int64(samplesPerFrame) * int64(time.Second) / int64(sampleRate)), nil
+		}
+
+		size := end - start - int64(i)
+		return time.Duration(size * 8 * int64(time.Second) / int64(bitrate)), nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,192 @@
+package tag
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+type atom struct {
+	typ string
+	// offset and size of the payload
+	offset int64
+	size   int64
+}
+
+// readAtoms lists the atoms in [offset, end).
+func readAtoms(r io.ReadSeeker, offset, end int64) ([]atom, error) {
+	atoms := make([]atom, 0, 8)
+	header := make([]byte, 16)
+	for offset+8 <= end {
+		_, err := r.Seek(offset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.ReadFull(r, header[:8])
+		if err != nil {
+			return nil, err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			_, err = io.ReadFull(r, header[8:16])
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || offset+size > end {
+			return nil, errors.New("tag: invalid MP4 atom " + typ)
+		}
+
+		atoms = append(atoms, atom{
+			typ:    typ,
+			offset: offset + headerSize,
+			size:   size - headerSize,
+		})
+		offset += size
+	}
+
+	return atoms, nil
+}
+
+func findAtom(atoms []atom, typ string) (atom, bool) {
+	for _, a := range atoms {
+		if a.typ == typ {
+			return a, true
+		}
+	}
+
+	return atom{}, false
+}
+
+// readPath descends into the atoms of path and returns the children of the last one.
+func readPath(r io.ReadSeeker, atoms []atom, path ...string) ([]atom, error) {
+	for _, typ := range path {
+		a, ok := findAtom(atoms, typ)
+		if !ok {
+			return nil, nil
+		}
+
+		offset := a.offset
+		if typ == "meta" {
+			// meta is a full atom, it has version and flags before the children.
+			offset += 4
+		}
+
+		var err error
+		atoms, err = readAtoms(r, offset, a.offset+a.size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return atoms, nil
+}
+
+func readPayload(r io.ReadSeeker, a atom) ([]byte, error) {
+	_, err := r.Seek(a.offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, a.size)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+func readMP4(r io.ReadSeeker) (*Tag, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	top, err := readAtoms(r, 0, end)
+	if err != nil {
+		return nil, err
+	}
+
+	moov, err := readPath(r, top, "moov")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tag{Format: MP4}
+	if a, ok := findAtom(moov, "mvhd"); ok {
+		b, err := readPayload(r, a)
+		if err != nil {
+			return nil, err
+		}
+
+		var timescale, duration uint64
+		if len(b) >= 32 && b[0] == 1 {
+			timescale = uint64(binary.BigEndian.Uint32(b[20:]))
+			duration = binary.BigEndian.Uint64(b[24:])
+		} else if len(b) >= 20 {
+			timescale = uint64(binary.BigEndian.Uint32(b[12:]))
+			duration = uint64(binary.BigEndian.Uint32(b[16:]))
+		}
+
+		if timescale != 0 {
+			t.Duration = time.Duration(duration * uint64(time.Second) / timescale)
+		}
+	}
+
+	items, err := readPath(r, moov, "udta", "meta", "ilst")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		children, err := readAtoms(r, item.offset, item.offset+item.size)
+		if err != nil {
+			return nil, err
+		}
+
+		data, ok := findAtom(children, "data")
+		if !ok || data.size < 8 {
+			continue
+		}
+
+		b, err := readPayload(r, data)
+		if err != nil {
+			return nil, err
+		}
+		// type indicator and locale
+		value := b[8:]
+
+		switch item.typ {
+		case "\xa9nam":
+			t.Title = string(value)
+		case "\xa9ART":
+			t.Artist = string(value)
+		case "\xa9alb":
+			t.Album = string(value)
+		case "aART":
+			t.AlbumArtist = string(value)
+		case "\xa9gen":
+			t.Genre = string(value)
+		case "gnre":
+			if len(value) >= 2 {
+				t.Genre = genre(int(binary.BigEndian.Uint16(value)) - 1)
+			}
+		case "\xa9day":
+			t.Year = parseNumber(string(value))
+		case "trkn":
+			if len(value) >= 4 {
+				t.Track = int(binary.BigEndian.Uint16(value[2:]))
+			}
+		}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,131 @@
+// Package tag reads the metadata of audio files in pure Go.
+//
+// ID3v1/ID3v2 (MP3), MP4 (M4A/M4B/M4P/MP4) and FLAC are supported.
+package tag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format int
+
+const (
+	Unknown Format = iota
+	MP3
+	MP4
+	FLAC
+)
+
+func (f Format) String() string {
+	switch f {
+	case Unknown:
+		return "Unknown"
+	case MP3:
+		return "MP3"
+	case MP4:
+		return "MP4"
+	case FLAC:
+		return "FLAC"
+	}
+
+	return ""
+}
+
+var ErrUnsupported = errors.New("tag: unsupported format")
+
+type Tag struct {
+	Format Format
+
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	Track       int
+
+	// Duration is zero if it can not be determined.
+	Duration time.Duration
+}
+
+func ReadFile(path string) (*Tag, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read detects the format from the magic bytes and reads the tag.
+func Read(r io.ReadSeeker) (*Tag, error) {
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return readMP3(r)
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return readFLAC(r)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return readMP4(r)
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return readMP3(r)
+	}
+
+	return nil, ErrUnsupported
+}
+
+// parseNumber parses the leading number of values like "2003-05-01" or "3/12".
+func parseNumber(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+		end++
+	}
+
+	n, err := strconv.Atoi(s[:end])
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// genres is the list of ID3v1 genres, also used by the gnre atom of MP4.
+var genres = [...]string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychadelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+}
+
+func genre(index int) string {
+	if index < 0 || len(genres) <= index {
+		return ""
+	}
+
+	return genres[index]
+}
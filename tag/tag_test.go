@@ -0,0 +1,199 @@
+package tag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func id3Frame(id string, text string) []byte {
+	b := make([]byte, 10, 10+1+len(text))
+	copy(b, id)
+	binary.BigEndian.PutUint32(b[4:], uint32(len(text)+1))
+	b = append(b, 3)
+	return append(b, text...)
+}
+
+func id3Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+// mpegFrame returns an MPEG1 layer III stereo 128kbps 44.1kHz frame with a Xing header.
+func mpegFrame(frames uint32) []byte {
+	b := make([]byte, 417)
+	copy(b, []byte{0xFF, 0xFB, 0x90, 0x00})
+	copy(b[4+32:], "Xing")
+	binary.BigEndian.PutUint32(b[4+32+4:], 1)
+	binary.BigEndian.PutUint32(b[4+32+8:], frames)
+	return b
+}
+
+func TestReadID3v2(t *testing.T) {
+	data := id3Tag(
+		id3Frame("TIT2", "Title"),
+		id3Frame("TPE1", "Artist"),
+		id3Frame("TALB", "Album"),
+		id3Frame("TCON", "(17)"),
+		id3Frame("TYER", "1999"),
+		id3Frame("TRCK", "3/12"),
+	)
+	data = append(data, mpegFrame(1000)...)
+
+	tag, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read failed.\n%v", err)
+	}
+
+	expect := Tag{
+		Format:   MP3,
+		Title:    "Title",
+		Artist:   "Artist",
+		Album:    "Album",
+		Genre:    "Rock",
+		Year:     1999,
+		Track:    3,
+		Duration: time.Duration(1000 * 1152 * int64(time.Second) / 44100),
+	}
+	if *tag != expect {
+		t.Errorf("expect %+v, but %+v", expect, *tag)
+	}
+}
+
+func TestReadID3v2UTF16(t *testing.T) {
+	text := []byte{1, 0xFF, 0xFE, 'T', 0, 'i', 0, 't', 0, 'l', 0, 'e', 0, 0, 0}
+	frame := make([]byte, 10)
+	copy(frame, "TIT2")
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(text)))
+	frame = append(frame, text...)
+
+	data := append(id3Tag(frame, id3Frame("TLEN", "215000")), mpegFrame(0)...)
+	tag, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read failed.\n%v", err)
+	}
+
+	if tag.Title != "Title" {
+		t.Errorf("expect Title, but %q", tag.Title)
+	}
+
+	if tag.Duration != 215*time.Second {
+		t.Errorf("expect %v, but %v", 215*time.Second, tag.Duration)
+	}
+}
+
+func mp4Atom(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func mp4Item(typ string, value []byte) []byte {
+	// type indicator 1 (UTF-8) and locale
+	data := append([]byte{0, 0, 0, 1, 0, 0, 0, 0}, value...)
+	return mp4Atom(typ, mp4Atom("data", data))
+}
+
+func TestReadMP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 61500)
+
+	data := bytes.Join([][]byte{
+		mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		mp4Atom("mdat", make([]byte, 64)),
+		mp4Atom("moov",
+			mp4Atom("mvhd", mvhd),
+			mp4Atom("udta",
+				mp4Atom("meta", []byte{0, 0, 0, 0},
+					mp4Atom("hdlr", make([]byte, 25)),
+					mp4Atom("ilst",
+						mp4Item("\xa9nam", []byte("Title")),
+						mp4Item("\xa9ART", []byte("Artist")),
+						mp4Item("\xa9alb", []byte("Album")),
+						mp4Item("gnre", []byte{0, 15}),
+						mp4Item("\xa9day", []byte("2003-05-01T00:00:00Z")),
+						mp4Item("trkn", []byte{0, 0, 0, 5, 0, 10, 0, 0}),
+					),
+				),
+			),
+		),
+	}, nil)
+
+	tag, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read failed.\n%v", err)
+	}
+
+	expect := Tag{
+		Format:   MP4,
+		Title:    "Title",
+		Artist:   "Artist",
+		Album:    "Album",
+		Genre:    "R&B",
+		Year:     2003,
+		Track:    5,
+		Duration: 61500 * time.Millisecond,
+	}
+	if *tag != expect {
+		t.Errorf("expect %+v, but %+v", expect, *tag)
+	}
+}
+
+func TestReadFLAC(t *testing.T) {
+	streamInfo := make([]byte, 34)
+	// 44100Hz, 2 channels, 16 bits, 441000 samples
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36|441000)
+
+	comments := []string{"TITLE=Title", "artist=Artist", "ALBUM=Album", "DATE=2010", "TRACKNUMBER=7"}
+	vorbis := []byte{}
+	vorbis = binary.LittleEndian.AppendUint32(vorbis, 6)
+	vorbis = append(vorbis, "vendor"...)
+	vorbis = binary.LittleEndian.AppendUint32(vorbis, uint32(len(comments)))
+	for _, c := range comments {
+		vorbis = binary.LittleEndian.AppendUint32(vorbis, uint32(len(c)))
+		vorbis = append(vorbis, c...)
+	}
+
+	block := func(typ byte, b []byte) []byte {
+		return append([]byte{typ, byte(len(b) >> 16), byte(len(b) >> 8), byte(len(b))}, b...)
+	}
+
+	data := bytes.Join([][]byte{
+		[]byte("fLaC"),
+		block(flacStreamInfo, streamInfo),
+		block(1, make([]byte, 16)),
+		block(0x80|flacVorbisComment, vorbis),
+	}, nil)
+
+	tag, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read failed.\n%v", err)
+	}
+
+	expect := Tag{
+		Format:   FLAC,
+		Title:    "Title",
+		Artist:   "Artist",
+		Album:    "Album",
+		Year:     2010,
+		Track:    7,
+		Duration: 10 * time.Second,
+	}
+	if *tag != expect {
+		t.Errorf("expect %+v, but %+v", expect, *tag)
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00WAVE")))
+	if err != ErrUnsupported {
+		t.Errorf("expect ErrUnsupported, but %v", err)
+	}
+}
@@ -1,5 +1,10 @@
 package itunes
 
+import (
+	"os"
+	"time"
+)
+
 func (t *Track) Name() string {
 	return t.name
 }
@@ -11,3 +16,65 @@ func (t *Track) Artist() string {
 func (t *Track) Album() string {
 	return t.album
 }
+
+func (t *Track) Duration() time.Duration {
+	return t.duration
+}
+
+// Location returns the file path of a file track or the URL of a URL track.
+func (t *Track) Location() string {
+	return t.location
+}
+
+func (t *Track) IsFileTrack() bool {
+	return t.isFile
+}
+
+// IsDead reports whether the file of a file track is missing.
+func (t *Track) IsDead() bool {
+	if !t.isFile {
+		return false
+	}
+
+	if t.location == "" {
+		return true
+	}
+
+	_, err := os.Stat(t.location)
+	return err != nil
+}
+
+// BitRate returns the bit rate in kbps.
+func (t *Track) BitRate() int {
+	return t.bitRate
+}
+
+func (t *Track) PlayedCount() int {
+	return t.playedCount
+}
+
+func (t *Track) DateAdded() time.Time {
+	return t.dateAdded
+}
+
+func (t *Track) Genre() string {
+	return t.genre
+}
+
+func (t *Track) Year() int {
+	return t.year
+}
+
+// Size returns the file size in bytes.
+func (t *Track) Size() int64 {
+	return t.size
+}
+
+// Rating returns the rating from 0 to 100, 20 per star.
+func (t *Track) Rating() int {
+	return t.rating
+}
+
+func (t *Track) Comment() string {
+	return t.comment
+}
@@ -4,15 +4,31 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Track struct {
 	persistentID string
 
-	album  string
-	artist string
-	name   string
+	album    string
+	artist   string
+	name     string
+	duration time.Duration
+	location string
+	isFile   bool
+
+	bitRate     int
+	playedCount int
+	dateAdded   time.Time
+	genre       string
+	year        int
+	size        int64
+	rating      int
+	comment     string
+	loved       bool
 }
 
 func createTrack(values []string) (*Track, error) {
@@ -20,32 +36,67 @@ func createTrack(values []string) (*Track, error) {
 		return nil, errors.New("values is empty.")
 	}
 
-	count := len(values)
+	column := func(i int) string {
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
 
-	persistentID := values[0]
-	count = len(values)
-	var album, artist, name string
+	track := &Track{
+		persistentID: values[0],
+
+		album:    column(1),
+		artist:   column(2),
+		name:     column(3),
+		duration: parseSeconds(column(4)),
+		location: column(5),
+		isFile:   column(6) == "true",
+
+		bitRate:     parseInt(column(7)),
+		playedCount: parseInt(column(8)),
+		dateAdded:   parseDate(column(9)),
+		genre:       column(10),
+		year:        parseInt(column(11)),
+		size:        int64(parseInt(column(12))),
+		rating:      parseInt(column(13)),
+		comment:     column(14),
+		loved:       column(15) == "true",
+	}
 
-	switch {
-	case count > 1:
-		album = values[1]
-		fallthrough
-	case count > 2:
-		artist = values[2]
-		fallthrough
-	case count > 3:
-		name = values[3]
+	return track, nil
+}
+
+// parseSeconds converts a number of seconds reported by osascript, such as "215.3", to time.Duration.
+// streams have no duration and are reported as "null", which is treated as zero.
+func parseSeconds(v string) time.Duration {
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
 	}
 
-	track := &Track{
-		persistentID: persistentID,
+	return time.Duration(seconds * float64(time.Second))
+}
 
-		album:  album,
-		artist: artist,
-		name:   name,
+// parseInt treats missing values ("null") as zero.
+// large numbers such as file sizes may be reported in exponential notation.
+func parseInt(v string) int {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
 	}
 
-	return track, nil
+	return int(n)
+}
+
+// parseDate parses dates formatted by isoDate and treats missing values as the zero time.
+func parseDate(v string) time.Time {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
 }
 
 // for compatibility
@@ -67,7 +118,7 @@ func (t *Track) Play() error {
 }
 
 func (t *Track) GetArtworks() (chan *Artwork, error) {
-	formats, err := execAS(fmt.Sprintf(getArtworksScript, t.Name))
+	formats, err := execAS(fmt.Sprintf(getArtworksScript, t.persistentID))
 	if err != nil {
 		return nil, err
 	}
@@ -112,3 +163,88 @@ func (t *Track) GetArtworks() (chan *Artwork, error) {
 func (t *Track) PersistentID() string {
 	return t.persistentID
 }
+
+func (t *Track) RefreshInfo() error {
+	_, err := getColumnsByJS(fmt.Sprintf(`app.refresh(findTrackByPersistentId(%s));`, jsString(t.persistentID)))
+	return err
+}
+
+func (t *Track) Reveal() error {
+	_, err := getColumnsByJS(fmt.Sprintf(`app.reveal(findTrackByPersistentId(%s));`, jsString(t.persistentID)))
+	return err
+}
+
+// Relocate points the track to another file.
+func (t *Track) Relocate(path string) error {
+	if !t.isFile {
+		return errors.New("track is not a file track.")
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = getColumnsByJS(fmt.Sprintf(`findTrackByPersistentId(%s).location = Path(%s);`, jsString(t.persistentID), jsString(path)))
+	if err != nil {
+		return err
+	}
+
+	t.location = path
+	return nil
+}
+
+func (t *Track) SetPlayedCount(count int) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`findTrackByPersistentId(%s).playedCount = %d;`, jsString(t.persistentID), count))
+	if err != nil {
+		return err
+	}
+
+	t.playedCount = count
+	return nil
+}
+
+// Delete removes the track from the library.
+func (t *Track) Delete() error {
+	_, err := getColumnsByJS(fmt.Sprintf(`app.delete(findTrackByPersistentId(%s));`, jsString(t.persistentID)))
+	return err
+}
+
+// SetRating sets the rating from 0 to 100, 20 per star.
+func (t *Track) SetRating(rating int) error {
+	if rating < 0 || rating > 100 {
+		return &OutOfRangeError{Name: "rating"}
+	}
+
+	_, err := getColumnsByJS(fmt.Sprintf(`findTrackByPersistentId(%s).rating = %d;`, jsString(t.persistentID), rating))
+	if err != nil {
+		return err
+	}
+
+	t.rating = rating
+	return nil
+}
+
+func (t *Track) SetComment(comment string) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`findTrackByPersistentId(%s).comment = %s;`, jsString(t.persistentID), jsString(comment)))
+	if err != nil {
+		return err
+	}
+
+	t.comment = comment
+	return nil
+}
+
+func (t *Track) Loved() bool {
+	return t.loved
+}
+
+func (t *Track) SetLoved(loved bool) error {
+	_, err := getColumnsByJS(fmt.Sprintf(`findTrackByPersistentId(%s).loved = %v;`, jsString(t.persistentID), loved))
+	if err != nil {
+		return err
+	}
+
+	t.loved = loved
+	return nil
+}
@@ -0,0 +1,179 @@
+package itunes
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type Track struct {
+	it           *Itunes
+	trackID      dbus.ObjectPath
+	persistentID string
+
+	album    string
+	artist   string
+	name     string
+	duration time.Duration
+	location string
+	isFile   bool
+	artURL   string
+
+	bitRate     int
+	playedCount int
+	dateAdded   time.Time
+	genre       string
+	year        int
+	size        int64
+	rating      int
+	comment     string
+	loved       bool
+}
+
+// createTrack maps the xesam metadata of MPRIS to a track.
+func createTrack(it *Itunes, m map[string]dbus.Variant) (*Track, error) {
+	id, _ := m["mpris:trackid"].Value().(dbus.ObjectPath)
+	if id == "" {
+		return nil, errors.New("trackid is empty.")
+	}
+
+	value := func(key string) interface{} {
+		return m[key].Value()
+	}
+
+	t := &Track{
+		it:           it,
+		trackID:      id,
+		persistentID: makePersistentID(id),
+
+		album:    variantString(value("xesam:album")),
+		artist:   variantString(value("xesam:artist")),
+		name:     variantString(value("xesam:title")),
+		duration: time.Duration(variantInt(value("mpris:length"))) * time.Microsecond,
+		artURL:   variantString(value("mpris:artUrl")),
+
+		playedCount: int(variantInt(value("xesam:useCount"))),
+		genre:       variantString(value("xesam:genre")),
+		year:        parseYear(variantString(value("xesam:contentCreated"))),
+		comment:     variantString(value("xesam:comment")),
+	}
+
+	rating, _ := variantFloat(value("xesam:userRating"))
+	t.rating = int(math.Round(rating * 100))
+
+	location := variantString(value("xesam:url"))
+	u, err := url.Parse(location)
+	if err == nil && u.Scheme == "file" {
+		t.location = u.Path
+		t.isFile = true
+
+		info, err := os.Stat(t.location)
+		if err == nil {
+			t.size = info.Size()
+		}
+	} else {
+		t.location = location
+	}
+
+	return t, nil
+}
+
+// parseYear reads the year of a date such as "2007-01-01T00:00:00Z".
+func parseYear(v string) int {
+	if len(v) < 4 {
+		return 0
+	}
+
+	year, err := strconv.Atoi(v[:4])
+	if err != nil {
+		return 0
+	}
+
+	return year
+}
+
+// for compatibility
+func (_ *Track) Close() {
+}
+
+func (t *Track) Play() error {
+	return t.it.callMethod(trackListIface+".GoTo", t.trackID)
+}
+
+// GetArtworks returns the cover art of the track when the player stores it in a local file.
+func (t *Track) GetArtworks() (chan *Artwork, error) {
+	output := make(chan *Artwork)
+	go func() {
+		defer close(output)
+		u, err := url.Parse(t.artURL)
+		if err != nil || u.Scheme != "file" {
+			return
+		}
+
+		format, err := detectArtworkFormat(u.Path)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		if format == Unknown {
+			log.Printf("unknown format:%v", u.Path)
+			return
+		}
+
+		output <- &Artwork{
+			path:   u.Path,
+			format: format,
+		}
+	}()
+
+	return output, nil
+}
+
+func (t *Track) PersistentID() string {
+	return t.persistentID
+}
+
+func (_ *Track) RefreshInfo() error {
+	return &NotSupportedError{Feature: "RefreshInfo", Platform: "Linux"}
+}
+
+func (_ *Track) Reveal() error {
+	return &NotSupportedError{Feature: "Reveal", Platform: "Linux"}
+}
+
+func (_ *Track) Relocate(path string) error {
+	return &NotSupportedError{Feature: "Relocate", Platform: "Linux"}
+}
+
+func (_ *Track) SetPlayedCount(count int) error {
+	return &NotSupportedError{Feature: "SetPlayedCount", Platform: "Linux"}
+}
+
+// Delete removes the track from the TrackList of the player.
+func (t *Track) Delete() error {
+	return t.it.callMethod(trackListIface+".RemoveTrack", t.trackID)
+}
+
+func (_ *Track) SetRating(rating int) error {
+	return &NotSupportedError{Feature: "SetRating", Platform: "Linux"}
+}
+
+func (_ *Track) SetComment(comment string) error {
+	return &NotSupportedError{Feature: "SetComment", Platform: "Linux"}
+}
+
+// Loved is always false, MPRIS has no loved tracks.
+func (_ *Track) Loved() bool {
+	return false
+}
+
+func (_ *Track) SetLoved(loved bool) error {
+	return &NotSupportedError{Feature: "SetLoved", Platform: "Linux"}
+}
@@ -1,8 +1,11 @@
 package itunes
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
+	"time"
 
 	"github.com/yaegaki/go-ole-handler"
 )
@@ -15,11 +18,30 @@ type Track struct {
 	highID uint32
 	lowID  uint32
 
-	album  string
-	artist string
-	name   string
+	album    string
+	artist   string
+	name     string
+	duration time.Duration
+	location string
+	isFile   bool
+
+	bitRate     int
+	playedCount int
+	dateAdded   time.Time
+	genre       string
+	year        int
+	size        int64
+	rating      int
+	comment     string
 }
 
+// ITTrackKind
+const (
+	trackKindFile = 1
+	trackKindCD   = 2
+	trackKindURL  = 3
+)
+
 func createTrack(it *Itunes, handler *olehandler.OleHandler) (*Track, error) {
 	v, err := it.handler.GetProperty("ITObjectPersistentIDHigh", handler.Handle)
 	if err != nil {
@@ -52,6 +74,70 @@ func createTrack(it *Itunes, handler *olehandler.OleHandler) (*Track, error) {
 		values[i] = v.ToString()
 	}
 
+	duration, err := handler.GetIntProperty("Duration")
+	if err != nil {
+		return nil, err
+	}
+
+	kind, err := handler.GetIntProperty("Kind")
+	if err != nil {
+		return nil, err
+	}
+
+	// Location is empty if the file is missing.
+	var location string
+	isFile := kind == trackKindFile || kind == trackKindCD
+	switch {
+	case isFile:
+		location, err = handler.GetStringProperty("Location")
+	case kind == trackKindURL:
+		location, err = handler.GetStringProperty("URL")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bitRate, err := handler.GetIntProperty("BitRate")
+	if err != nil {
+		return nil, err
+	}
+
+	playedCount, err := handler.GetIntProperty("PlayedCount")
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = handler.GetProperty("DateAdded")
+	if err != nil {
+		return nil, err
+	}
+	dateAdded, _ := v.Value().(time.Time)
+
+	genre, err := handler.GetStringProperty("Genre")
+	if err != nil {
+		return nil, err
+	}
+
+	year, err := handler.GetIntProperty("Year")
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := handler.GetIntProperty("Size")
+	if err != nil {
+		return nil, err
+	}
+
+	rating, err := handler.GetIntProperty("Rating")
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := handler.GetStringProperty("Comment")
+	if err != nil {
+		return nil, err
+	}
+
 	track := &Track{
 		handler:  handler,
 		artworks: artworks,
@@ -60,9 +146,21 @@ func createTrack(it *Itunes, handler *olehandler.OleHandler) (*Track, error) {
 		highID: highID,
 		lowID:  lowID,
 
-		album:  values[0],
-		artist: values[1],
-		name:   values[2],
+		album:    values[0],
+		artist:   values[1],
+		name:     values[2],
+		duration: time.Duration(duration) * time.Second,
+		location: location,
+		isFile:   isFile,
+
+		bitRate:     bitRate,
+		playedCount: playedCount,
+		dateAdded:   dateAdded,
+		genre:       genre,
+		year:        year,
+		size:        int64(size),
+		rating:      rating,
+		comment:     comment,
 	}
 
 	return track, nil
@@ -111,3 +209,88 @@ func (t *Track) GetArtworks() (chan *Artwork, error) {
 func (t *Track) PersistentID() string {
 	return fmt.Sprintf("%x%x", t.highID, t.lowID)
 }
+
+func (t *Track) RefreshInfo() error {
+	return t.handler.CallMethod("UpdateInfoFromFile")
+}
+
+func (t *Track) Reveal() error {
+	return t.handler.CallMethod("Reveal")
+}
+
+// Relocate points the track to another file.
+func (t *Track) Relocate(path string) error {
+	if !t.isFile {
+		return errors.New("track is not a file track.")
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	err = t.handler.PutProperty("Location", path)
+	if err != nil {
+		return err
+	}
+
+	t.location = path
+	return nil
+}
+
+func (t *Track) SetPlayedCount(count int) error {
+	err := t.handler.PutProperty("PlayedCount", count)
+	if err != nil {
+		return err
+	}
+
+	t.playedCount = count
+	return nil
+}
+
+// Delete removes the track from the library.
+func (t *Track) Delete() error {
+	// deleting a track of a user playlist only removes it from the playlist.
+	lt, err := t.itunes.FindTrackByPersistentID(t.PersistentID())
+	if err != nil {
+		return err
+	}
+	defer lt.Close()
+
+	return lt.handler.CallMethod("Delete")
+}
+
+// SetRating sets the rating from 0 to 100, 20 per star.
+func (t *Track) SetRating(rating int) error {
+	if rating < 0 || rating > 100 {
+		return &OutOfRangeError{Name: "rating"}
+	}
+
+	err := t.handler.PutProperty("Rating", rating)
+	if err != nil {
+		return err
+	}
+
+	t.rating = rating
+	return nil
+}
+
+func (t *Track) SetComment(comment string) error {
+	err := t.handler.PutProperty("Comment", comment)
+	if err != nil {
+		return err
+	}
+
+	t.comment = comment
+	return nil
+}
+
+// the COM interface predates loved tracks.
+// Loved is always false, the COM interface has no loved tracks.
+func (t *Track) Loved() bool {
+	return false
+}
+
+func (t *Track) SetLoved(loved bool) error {
+	return &NotSupportedError{Feature: "SetLoved", Platform: "Windows"}
+}